@@ -0,0 +1,153 @@
+package main
+
+import (
+	"image"
+	"math"
+)
+
+// AdjustOp changes the colours of the image. The zero value changes
+// nothing. The stages run in field order: brightness, contrast and gamma
+// per channel, then saturation, hue, grayscale and sepia, which follow the
+// CSS filter definitions and are combined into one colour matrix. Alpha is
+// kept.
+type AdjustOp struct {
+	Brightness float64 // -1 to 1, added to every channel
+	Contrast   float64 // -1 to 1, -1 flattens to mid grey and 1 doubles the contrast
+	Gamma      float64 // Greater than 0, 0 for no change; above 1 brightens the mid tones
+	Saturation float64 // -1 to 1, -1 removes the colour and 1 doubles it
+	Hue        float64 // Rotation in degrees
+	Grayscale  bool
+	Sepia      bool
+}
+
+func (op AdjustOp) plan(size image.Point) ([]step, error) {
+	if op == (AdjustOp{}) {
+		return nil, nil
+	}
+	st := adjustStep{
+		Scale:    float32(1 + op.Contrast),
+		Offset:   float32((op.Brightness-0.5)*(1+op.Contrast) + 0.5),
+		InvGamma: 1,
+		Matrix:   identityMatrix,
+	}
+	if op.Gamma != 0 {
+		st.InvGamma = float32(1 / op.Gamma)
+	}
+	if op.Saturation != 0 {
+		st.Matrix = saturateMatrix(1 + op.Saturation).mul(st.Matrix)
+	}
+	if op.Hue != 0 {
+		st.Matrix = hueRotateMatrix(op.Hue).mul(st.Matrix)
+	}
+	if op.Grayscale {
+		st.Matrix = saturateMatrix(0).mul(st.Matrix)
+	}
+	if op.Sepia {
+		st.Matrix = sepiaMatrix.mul(st.Matrix)
+	}
+	return []step{st}, nil
+}
+
+// colorMatrix is a row-major 3x3 matrix applied to RGB column vectors
+type colorMatrix [9]float32
+
+var identityMatrix = colorMatrix{1, 0, 0, 0, 1, 0, 0, 0, 1}
+
+var sepiaMatrix = colorMatrix{
+	0.393, 0.769, 0.189,
+	0.349, 0.686, 0.168,
+	0.272, 0.534, 0.131,
+}
+
+// mul returns m x n, the matrix applying n and then m
+func (m colorMatrix) mul(n colorMatrix) colorMatrix {
+	var out colorMatrix
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			for k := 0; k < 3; k++ {
+				out[r*3+c] += m[r*3+k] * n[k*3+c]
+			}
+		}
+	}
+	return out
+}
+
+// saturateMatrix scales the saturation by s around the Rec. 709 luma, as
+// the CSS saturate() filter does
+func saturateMatrix(s float64) colorMatrix {
+	return colorMatrix{
+		float32(0.213 + 0.787*s), float32(0.715 - 0.715*s), float32(0.072 - 0.072*s),
+		float32(0.213 - 0.213*s), float32(0.715 + 0.285*s), float32(0.072 - 0.072*s),
+		float32(0.213 - 0.213*s), float32(0.715 - 0.715*s), float32(0.072 + 0.928*s),
+	}
+}
+
+// hueRotateMatrix rotates hues by degrees, as the CSS hue-rotate() filter
+// does
+func hueRotateMatrix(degrees float64) colorMatrix {
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	return colorMatrix{
+		float32(0.213 + cos*0.787 - sin*0.213), float32(0.715 - cos*0.715 - sin*0.715), float32(0.072 - cos*0.072 + sin*0.928),
+		float32(0.213 - cos*0.213 + sin*0.143), float32(0.715 + cos*0.285 + sin*0.140), float32(0.072 - cos*0.072 - sin*0.283),
+		float32(0.213 - cos*0.213 - sin*0.787), float32(0.715 - cos*0.715 + sin*0.715), float32(0.072 + cos*0.928 + sin*0.072),
+	}
+}
+
+// adjustStep applies v = clamp(v*Scale + Offset)^InvGamma to each channel,
+// then Matrix to the RGB values, working on 0-1 values
+type adjustStep struct {
+	Scale, Offset, InvGamma float32
+	Matrix                  colorMatrix
+}
+
+func (st adjustStep) outputSize(in image.Point) image.Point { return in }
+
+func (st adjustStep) cpu(img *image.NRGBA) *image.NRGBA {
+	if img.Stride != img.Bounds().Dx()*4 {
+		img = cloneNRGBA(img)
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	parallelRows(height, func(y int) {
+		for x := 0; x < width; x++ {
+			i := (y*width + x) * 4
+			adjustPixel(img.Pix[i:i+4], out.Pix[i:i+4], st.Scale, st.Offset, st.InvGamma, &st.Matrix)
+		}
+	})
+	return out
+}
+
+func (st adjustStep) gpu(s *gpuSession, img deviceImage) (deviceImage, error) {
+	out, err := s.newImage(img.width, img.height, img.count)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	m := st.Matrix
+	err = s.launch(adjustKernelV1, img.width, img.height, img.count,
+		img.ptr, out.ptr, int32(img.width), int32(img.height),
+		st.Scale, st.Offset, st.InvGamma,
+		m[0], m[1], m[2], m[3], m[4], m[5], m[6], m[7], m[8],
+	)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	return out, nil
+}
+
+// adjustPixel applies an adjustment to one RGBA8 pixel. Shared by the CPU
+// backend and the simulated adjustKernel.
+func adjustPixel(src, dst []byte, scale, offset, invGamma float32, m *colorMatrix) {
+	var v [3]float32
+	for c := 0; c < 3; c++ {
+		f := min(max(float32(src[c])/255*scale+offset, 0), 1)
+		if invGamma != 1 {
+			f = float32(math.Pow(float64(f), float64(invGamma)))
+		}
+		v[c] = f
+	}
+	for c := 0; c < 3; c++ {
+		f := m[c*3]*v[0] + m[c*3+1]*v[1] + m[c*3+2]*v[2]
+		dst[c] = clampByte(f * 255)
+	}
+	dst[3] = src[3]
+}
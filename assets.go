@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+// defaultFont is used by text operations that name no font
+const defaultFont = "regular"
+
+// builtinFonts are the Go fonts shipped with the binary
+var builtinFonts = func() map[string]*opentype.Font {
+	fonts := make(map[string]*opentype.Font)
+	for name, ttf := range map[string][]byte{
+		"regular":     goregular.TTF,
+		"bold":        gobold.TTF,
+		"italic":      goitalic.TTF,
+		"bold-italic": gobolditalic.TTF,
+		"mono":        gomono.TTF,
+		"mono-bold":   gomonobold.TTF,
+	} {
+		f, err := opentype.Parse(ttf)
+		if err != nil {
+			panic(fmt.Sprintf("built-in font %s: %v", name, err))
+		}
+		fonts[name] = f
+	}
+	return fonts
+}()
+
+// assetStore holds the overlay images and fonts registered by name at
+// startup, which requests refer to instead of sending them inline
+type assetStore struct {
+	images map[string]*image.NRGBA
+	fonts  map[string]*opentype.Font
+}
+
+// image returns a registered image, or nil
+func (a *assetStore) image(name string) *image.NRGBA {
+	if a == nil {
+		return nil
+	}
+	return a.images[name]
+}
+
+// font returns a built-in or registered font, or nil. Built-in fonts take
+// precedence.
+func (a *assetStore) font(name string) *opentype.Font {
+	if f := builtinFonts[name]; f != nil {
+		return f
+	}
+	if a == nil {
+		return nil
+	}
+	return a.fonts[name]
+}
+
+// loadImages decodes every file in dir as an image named after the file
+// without its extension
+func (a *assetStore) loadImages(dir string) error {
+	return readAssetDir(dir, func(name string, data []byte) error {
+		img, _, err := decodeToNRGBA(data)
+		if err != nil {
+			return err
+		}
+		if a.images == nil {
+			a.images = make(map[string]*image.NRGBA)
+		}
+		a.images[name] = img
+		log.Printf("Registered image asset %q (%dx%d)", name, img.Rect.Dx(), img.Rect.Dy())
+		return nil
+	})
+}
+
+// loadFonts parses every TrueType or OpenType file in dir as a font named
+// after the file without its extension
+func (a *assetStore) loadFonts(dir string) error {
+	return readAssetDir(dir, func(name string, data []byte) error {
+		f, err := opentype.Parse(data)
+		if err != nil {
+			return err
+		}
+		if builtinFonts[name] != nil {
+			return fmt.Errorf("name is taken by a built-in font")
+		}
+		if a.fonts == nil {
+			a.fonts = make(map[string]*opentype.Font)
+		}
+		a.fonts[name] = f
+		log.Printf("Registered font %q", name)
+		return nil
+	})
+}
+
+// readAssetDir calls load with the name and contents of every file in dir
+func readAssetDir(dir string, load func(name string, data []byte) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if err := load(name, data); err != nil {
+			return fmt.Errorf("asset %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"sort"
+	"sync"
+)
+
+// Capabilities describes what a backend is able to do
+type Capabilities struct {
+	GPU bool // Runs on a GPU device
+}
+
+// errInvalidRequest marks errors caused by the request itself; the server
+// reports them to the client instead of retrying on another backend
+var errInvalidRequest = errors.New("invalid request")
+
+// OutputSpec describes one output image: its size, fit, resampling and
+// encoding
+type OutputSpec struct {
+	Width       int
+	Height      int
+	Output      EncodeOptions
+	Filter      Filter
+	Fit         Fit
+	Background  color.NRGBA // Padding colour for FitContain
+	AutoSharpen bool        // Sharpen after a downscale
+	LinearLight bool        // Resample in linear light with premultiplied alpha
+	Pipeline    []Operation // Run instead of the resize described above
+}
+
+// operations returns the spec's pipeline, or a single resize built from its
+// size, filter and fit
+func (s OutputSpec) operations() []Operation {
+	if len(s.Pipeline) > 0 {
+		return s.Pipeline
+	}
+	return []Operation{ResizeOp{
+		Width:       s.Width,
+		Height:      s.Height,
+		Filter:      s.Filter,
+		Fit:         s.Fit,
+		Background:  s.Background,
+		AutoSharpen: s.AutoSharpen,
+		LinearLight: s.LinearLight,
+	}}
+}
+
+// Variant is one named output of a multi-variant job
+type Variant struct {
+	Name string
+	OutputSpec
+}
+
+// Job is a backend-neutral description of a single resize request
+type Job struct {
+	ImageData         []byte
+	Source            *image.NRGBA // Image already decoded from a stream, used instead of ImageData
+	SourceFormat      string       // Input format of Source
+	SourceOrientation Orientation  // EXIF orientation of Source
+	IgnoreOrientation bool         // Skip EXIF auto-orientation
+	OutputSpec                     // The output, unless Variants is set
+	Variants          []Variant    // Several outputs from one decode
+	Focus             Focus        // What crops keep in frame
+	GPU               *int         // Requested GPU, nil lets the backend choose
+}
+
+// specs lists the outputs to produce: one per variant, or the job's own
+func (j *Job) specs() []OutputSpec {
+	if len(j.Variants) == 0 {
+		return []OutputSpec{j.OutputSpec}
+	}
+	specs := make([]OutputSpec, len(j.Variants))
+	for i, v := range j.Variants {
+		specs[i] = v.OutputSpec
+	}
+	return specs
+}
+
+// decode returns the job's source image, its input format and the
+// orientation that turns it upright, decoding ImageData unless the image
+// was decoded while it was received
+func (j *Job) decode() (*image.NRGBA, string, Orientation, error) {
+	img, format, orientation := j.Source, j.SourceFormat, j.SourceOrientation
+	if img == nil {
+		var err error
+		if img, format, err = decodeToNRGBA(j.ImageData); err != nil {
+			return nil, "", 0, err
+		}
+		orientation = OrientNormal
+		if format == "jpeg" {
+			orientation = jpegOrientation(j.ImageData)
+		}
+	}
+	if j.IgnoreOrientation {
+		orientation = OrientNormal
+	}
+	return img, format, orientation, nil
+}
+
+// operations returns the operations producing one output: the orientation
+// correction followed by the spec's own
+func (j *Job) operations(spec OutputSpec, orientation Orientation) []Operation {
+	return append([]Operation{orientation}, spec.operations()...)
+}
+
+// Output is one encoded output image
+type Output struct {
+	Image      []byte
+	Format     OutputFormat      // Encoding of Image
+	Candidates []FormatCandidate // Encodings tried by format negotiation
+	Width      int               // Final image width
+	Height     int               // Final image height
+	SmartCrops []image.Rectangle // Regions picked by smart crops, in pipeline order
+	FocusLoss  FocusLoss         // Parts of the job's focus cut off
+}
+
+// VariantOutput is the output for one Variant
+type VariantOutput struct {
+	Name string
+	Output
+}
+
+// Result is the output of a successfully processed Job
+type Result struct {
+	Output                     // The output, unless the job has variants
+	Variants   []VariantOutput // One per Job.Variants, in order
+	DeviceID   int             // GPU that ran the job, for GPU backends
+	DeviceName string          // Name of the device that ran the job
+}
+
+// newResult assigns outputs, one per spec of job, to a result
+func newResult(job *Job, outputs []Output) *Result {
+	if len(job.Variants) == 0 {
+		return &Result{Output: outputs[0]}
+	}
+	result := &Result{Variants: make([]VariantOutput, len(outputs))}
+	for i, out := range outputs {
+		result.Variants[i] = VariantOutput{Name: job.Variants[i].Name, Output: out}
+	}
+	return result
+}
+
+// Backend processes jobs on one kind of hardware
+type Backend interface {
+	// Name identifies the backend in the registry, logs and policies
+	Name() string
+	// Capabilities reports what the backend supports
+	Capabilities() Capabilities
+	// Health returns nil when the backend is ready to accept jobs
+	Health(ctx context.Context) error
+	// Process runs a single job
+	Process(ctx context.Context, job *Job) (*Result, error)
+}
+
+// BatchBackend is a Backend that processes several jobs together, for
+// example to share device transfers and kernel launches between them
+type BatchBackend interface {
+	Backend
+	// ProcessBatch runs jobs and returns a result or an error for each job,
+	// in the same order
+	ProcessBatch(ctx context.Context, jobs []*Job) ([]*Result, []error)
+}
+
+// processJobs runs jobs on b, as one batch when b supports it
+func processJobs(ctx context.Context, b Backend, jobs []*Job) ([]*Result, []error) {
+	if bb, ok := b.(BatchBackend); ok {
+		return bb.ProcessBatch(ctx, jobs)
+	}
+	results := make([]*Result, len(jobs))
+	errs := make([]error, len(jobs))
+	for i, job := range jobs {
+		results[i], errs[i] = b.Process(ctx, job)
+	}
+	return results, errs
+}
+
+type registeredBackend struct {
+	backend  Backend
+	priority int
+}
+
+// Registry holds the available backends ordered by priority
+type Registry struct {
+	mu       sync.RWMutex
+	backends []registeredBackend
+}
+
+// NewRegistry returns an empty backend registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a backend; higher priorities are tried first
+func (r *Registry) Register(b Backend, priority int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rb := range r.backends {
+		if rb.backend.Name() == b.Name() {
+			return fmt.Errorf("backend %q already registered", b.Name())
+		}
+	}
+	r.backends = append(r.backends, registeredBackend{backend: b, priority: priority})
+	sort.SliceStable(r.backends, func(i, j int) bool {
+		return r.backends[i].priority > r.backends[j].priority
+	})
+	return nil
+}
+
+// Get looks up a backend by name
+func (r *Registry) Get(name string) (Backend, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rb := range r.backends {
+		if rb.backend.Name() == name {
+			return rb.backend, true
+		}
+	}
+	return nil, false
+}
+
+// Backends returns all registered backends in priority order
+func (r *Registry) Backends() []Backend {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	backends := make([]Backend, len(r.backends))
+	for i, rb := range r.backends {
+		backends[i] = rb.backend
+	}
+	return backends
+}
+
+// Policy picks the backends that may run a job, in the order they should be tried
+type Policy func(ctx context.Context, job *Job, backends []Backend) []Backend
+
+// PreferGPU tries every healthy GPU backend first and falls back to the rest
+func PreferGPU(ctx context.Context, job *Job, backends []Backend) []Backend {
+	var gpu, other []Backend
+	for _, b := range backends {
+		if err := b.Health(ctx); err != nil {
+			log.Printf("Backend %s unavailable: %v", b.Name(), err)
+			continue
+		}
+		if b.Capabilities().GPU {
+			gpu = append(gpu, b)
+		} else {
+			other = append(other, b)
+		}
+	}
+	return append(gpu, other...)
+}
+
+// OnlyBackend restricts processing to the named backend
+func OnlyBackend(name string) Policy {
+	return func(ctx context.Context, job *Job, backends []Backend) []Backend {
+		for _, b := range backends {
+			if b.Name() == name {
+				return []Backend{b}
+			}
+		}
+		return nil
+	}
+}
+
+// policyFromName maps a configuration value to a Policy
+func policyFromName(name string, registry *Registry) (Policy, error) {
+	if name == "" || name == "auto" {
+		return PreferGPU, nil
+	}
+	if _, ok := registry.Get(name); !ok {
+		return nil, fmt.Errorf("unknown backend %q", name)
+	}
+	return OnlyBackend(name), nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import "context"
+
+// cpuBackend resizes images in pure Go
+type cpuBackend struct{}
+
+func (cpuBackend) Name() string { return "cpu" }
+
+func (cpuBackend) Capabilities() Capabilities { return Capabilities{} }
+
+func (cpuBackend) Health(ctx context.Context) error { return nil }
+
+func (cpuBackend) Process(ctx context.Context, job *Job) (*Result, error) {
+	return resizeImageCPU(ctx, job)
+}
+
+// resizeImageCPU runs a job's pipelines on the CPU, producing every output
+// of the job from a single decode
+func resizeImageCPU(ctx context.Context, job *Job) (*Result, error) {
+	// Decode image
+	nrgbaImg, inputFormat, orientation, err := job.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	specs := job.specs()
+	outputs := make([]Output, len(specs))
+	size := nrgbaImg.Bounds().Size()
+	for i, spec := range specs {
+		// Run the pipeline using CPU
+		steps, loss, err := planPipeline(job.operations(spec, orientation), size, job.Focus.pixels(size, orientation))
+		if err != nil {
+			return nil, err
+		}
+		resizedImg, crops, err := runStepsCPU(ctx, nrgbaImg, steps)
+		if err != nil {
+			return nil, err
+		}
+
+		// Encode in the requested output format
+		outputs[i], err = encodeImage(resizedImg, inputFormat, spec.Output)
+		if err != nil {
+			return nil, err
+		}
+		outputs[i].SmartCrops = crops
+		outputs[i].FocusLoss = loss
+	}
+
+	result := newResult(job, outputs)
+	result.DeviceName = "cpu"
+	return result, nil
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+)
+
+// openRetryDelay is how long a failed open is remembered before the
+// driver is tried again, so health checks don't hammer a broken device
+const openRetryDelay = 30 * time.Second
+
+// cudaBackend resizes images on CUDA devices through a Driver.
+// The driver, device contexts and kernel modules are set up once by open
+// and reused by every request.
+type cudaBackend struct {
+	name         string
+	driver       Driver
+	kernels      *kernelRegistry
+	maxPerDevice int
+
+	mu      sync.Mutex
+	pool    *devicePool
+	devices []string
+	openErr error     // Why the last open failed
+	retryAt time.Time // When open may try again after openErr
+}
+
+// newCUDABackend returns a CUDA backend using the given driver and kernels,
+// running at most maxPerDevice jobs on each device at once
+func newCUDABackend(name string, driver Driver, kernels *kernelRegistry, maxPerDevice int) *cudaBackend {
+	return &cudaBackend{name: name, driver: driver, kernels: kernels, maxPerDevice: maxPerDevice}
+}
+
+func (b *cudaBackend) Name() string { return b.name }
+
+func (b *cudaBackend) Capabilities() Capabilities { return Capabilities{GPU: true} }
+
+func (b *cudaBackend) Health(ctx context.Context) error {
+	_, err := b.open()
+	return err
+}
+
+func (b *cudaBackend) Process(ctx context.Context, job *Job) (*Result, error) {
+	results, errs := b.ProcessBatch(ctx, []*Job{job})
+	return results[0], errs[0]
+}
+
+// gpuBatchItem is one job of a batch on its way through the GPU
+type gpuBatchItem struct {
+	job         *Job
+	src         *image.NRGBA
+	inputFormat string
+	pipelines   [][]step            // Planned steps, one pipeline per output
+	losses      []FocusLoss         // Parts of the focus each pipeline cuts off
+	resized     []*image.NRGBA      // One per pipeline
+	crops       [][]image.Rectangle // Windows chosen by smart crops, one list per pipeline
+	dev         *poolDevice
+	err         error
+}
+
+// gpuBatchGroup is a set of jobs that share uploads and kernel launches:
+// the same source size, device request and pipelines
+type gpuBatchGroup struct {
+	source    image.Point
+	gpu       *int
+	pipelines [][]step
+	items     []*gpuBatchItem
+}
+
+// compatible reports whether item can join the group
+func (g *gpuBatchGroup) compatible(item *gpuBatchItem) bool {
+	gpu := item.job.GPU
+	return g.source == item.src.Bounds().Size() &&
+		(g.gpu == nil) == (gpu == nil) && (gpu == nil || *g.gpu == *gpu) &&
+		slices.EqualFunc(g.pipelines, item.pipelines, slices.Equal[[]step])
+}
+
+// ProcessBatch decodes every job once, runs the pipelines of compatible
+// jobs together on one stack of images on a device, then encodes each output
+func (b *cudaBackend) ProcessBatch(ctx context.Context, jobs []*Job) ([]*Result, []error) {
+	results := make([]*Result, len(jobs))
+	errs := make([]error, len(jobs))
+	pool, err := b.open()
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return results, errs
+	}
+
+	// Decode on the CPU before taking a device slot
+	items := make([]*gpuBatchItem, len(jobs))
+	parallelRows(len(jobs), func(i int) {
+		item := &gpuBatchItem{job: jobs[i]}
+		items[i] = item
+		var orientation Orientation
+		item.src, item.inputFormat, orientation, item.err = jobs[i].decode()
+		if item.err != nil {
+			return
+		}
+		size := item.src.Bounds().Size()
+		for _, spec := range jobs[i].specs() {
+			steps, loss, err := planPipeline(jobs[i].operations(spec, orientation), size, jobs[i].Focus.pixels(size, orientation))
+			if err != nil {
+				item.err = err
+				return
+			}
+			item.pipelines = append(item.pipelines, steps)
+			item.losses = append(item.losses, loss)
+		}
+	})
+
+	// Group compatible jobs, keeping the order they arrived in
+	var groups []*gpuBatchGroup
+	for _, item := range items {
+		if item.err != nil {
+			continue
+		}
+		i := slices.IndexFunc(groups, func(g *gpuBatchGroup) bool { return g.compatible(item) })
+		if i < 0 {
+			i = len(groups)
+			groups = append(groups, &gpuBatchGroup{source: item.src.Bounds().Size(), gpu: item.job.GPU, pipelines: item.pipelines})
+		}
+		groups[i].items = append(groups[i].items, item)
+	}
+
+	// Each group runs on one device; groups run concurrently and the pool
+	// limits how many share a device
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dev, release, err := pool.acquire(ctx, group.gpu)
+			if err != nil {
+				for _, item := range group.items {
+					item.err = err
+				}
+				return
+			}
+			defer release()
+
+			srcs := make([]*image.NRGBA, len(group.items))
+			for i, item := range group.items {
+				srcs[i] = item.src
+			}
+			resized, crops, err := runBatchGPU(ctx, dev.cudaDevice, srcs, group.pipelines)
+			for i, item := range group.items {
+				item.dev, item.err = dev, err
+				if err == nil {
+					for p, stack := range resized {
+						item.resized = append(item.resized, stack[i])
+						item.crops = append(item.crops, crops[p][i])
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Encode every output in its requested format
+	parallelRows(len(items), func(i int) {
+		item := items[i]
+		if item.err != nil {
+			errs[i] = item.err
+			return
+		}
+		specs := item.job.specs()
+		outputs := make([]Output, len(specs))
+		for s, spec := range specs {
+			var err error
+			outputs[s], err = encodeImage(item.resized[s], item.inputFormat, spec.Output)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			outputs[s].SmartCrops = item.crops[s]
+			outputs[s].FocusLoss = item.losses[s]
+		}
+		results[i] = newResult(item.job, outputs)
+		results[i].DeviceID, results[i].DeviceName = item.dev.ordinal, item.dev.name
+	})
+	return results, errs
+}
+
+// open initializes the driver, device contexts and pool on first use; a
+// failed attempt is returned to every caller until openRetryDelay has passed
+func (b *cudaBackend) open() (*devicePool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.pool != nil {
+		return b.pool, nil
+	}
+	if b.openErr != nil && time.Now().Before(b.retryAt) {
+		return nil, b.openErr
+	}
+
+	pool, err := b.openPool()
+	if err != nil {
+		b.openErr, b.retryAt = err, time.Now().Add(openRetryDelay)
+		return nil, err
+	}
+	b.pool, b.openErr = pool, nil
+	return pool, nil
+}
+
+// openPool does the work of open; the caller holds b.mu
+func (b *cudaBackend) openPool() (*devicePool, error) {
+	// Contexts are bound to the calling OS thread
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := b.driver.Init(); err != nil {
+		return nil, fmt.Errorf("failed to initialize CUDA: %w", err)
+	}
+	if !checkGPUAvailability(b.driver) {
+		return nil, errors.New("no CUDA device available")
+	}
+	b.devices = getGPUDevices(b.driver)
+
+	if err := b.kernels.SelfCheck(); err != nil {
+		return nil, fmt.Errorf("kernel self-check failed: %w", err)
+	}
+
+	// Devices that fail to open or whose kernels disagree with the CPU
+	// code are left out of the pool
+	var devices []*cudaDevice
+	for ordinal := range b.devices {
+		dev, err := openCUDADevice(b.driver, ordinal, b.kernels)
+		if err == nil {
+			if err = checkDevice(dev); err != nil {
+				b.driver.CtxDestroy(dev.ctx)
+			}
+		}
+		if err != nil {
+			log.Printf("Skipping GPU %d: %v", ordinal, err)
+			continue
+		}
+		devices = append(devices, dev)
+	}
+	if len(devices) == 0 {
+		return nil, errors.New("no CUDA device could be opened")
+	}
+	return newDevicePool(devices, b.maxPerDevice), nil
+}
+
+// Pool returns the device pool once the backend has been opened
+func (b *cudaBackend) Pool() *devicePool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pool
+}
+
+// Devices lists the device names found when the backend was opened
+func (b *cudaBackend) Devices() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.devices
+}
+
+// checkGPUAvailability checks if an NVIDIA GPU is available on an initialized driver
+func checkGPUAvailability(driver Driver) bool {
+	deviceCount, err := driver.DeviceCount()
+	return err == nil && deviceCount > 0
+}
+
+// getGPUDevices lists available GPU devices on an initialized driver
+func getGPUDevices(driver Driver) []string {
+	deviceCount, err := driver.DeviceCount()
+	if err != nil {
+		return nil
+	}
+
+	// Get device properties
+	var devices []string
+	for i := 0; i < deviceCount; i++ {
+		name, err := driver.DeviceName(i)
+		if err != nil {
+			name = fmt.Sprintf("device %d (%v)", i, err)
+		}
+		devices = append(devices, name)
+	}
+
+	return devices
+}
+
+// runBatchGPU runs every pipeline on a stack of same-sized images. The
+// stack is uploaded once and shared by the pipelines, each step launches
+// its kernels once for the whole stack, and each pipeline's results are
+// downloaded in one transfer. The result holds one stack of images per
+// pipeline, and for each image of the stack the windows chosen by the
+// pipeline's smart crops, in order. It stops with the error of ctx between
+// steps once ctx is done.
+func runBatchGPU(ctx context.Context, dev *cudaDevice, cpuImgs []*image.NRGBA, pipelines [][]step) ([][]*image.NRGBA, [][][]image.Rectangle, error) {
+	s, err := newGPUSession(ctx, dev)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer s.close()
+
+	src, err := s.upload(cpuImgs...)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := make([][]*image.NRGBA, len(pipelines))
+	crops := make([][][]image.Rectangle, len(pipelines))
+	for i, steps := range pipelines {
+		img := src
+		crops[i] = make([][]image.Rectangle, len(cpuImgs))
+		for _, st := range steps {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+			if sc, ok := st.(smartCropStep); ok {
+				var chosen []image.Rectangle
+				if img, chosen, err = sc.chooseGPU(s, img); err != nil {
+					return nil, nil, err
+				}
+				for j, r := range chosen {
+					crops[i][j] = append(crops[i][j], r)
+				}
+				continue
+			}
+			if img, err = st.gpu(s, img); err != nil {
+				return nil, nil, err
+			}
+		}
+		if out[i], err = s.download(img); err != nil {
+			return nil, nil, err
+		}
+	}
+	return out, crops, nil
+}
+
+// launchGrid covers a width x height output with 16x16 thread blocks, with
+// one layer of blocks per stacked image
+func launchGrid(width, height, count int) (grid, block Dim3) {
+	block = Dim3{X: 16, Y: 16, Z: 1}
+	grid = Dim3{
+		X: (width + block.X - 1) / block.X,
+		Y: (height + block.Y - 1) / block.Y,
+		Z: count,
+	}
+	return grid, block
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testJob returns a job resizing a width x height image, already decoded,
+// to a PNG half its size
+func testJob(width, height int) *Job {
+	src := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i * 7)
+	}
+	return &Job{
+		Source:       src,
+		SourceFormat: "png",
+		OutputSpec:   OutputSpec{Width: width / 2, Height: height / 2, Output: EncodeOptions{Format: OutputPNG}},
+	}
+}
+
+// TestCUDASetupOnce runs concurrent requests on two simulated devices and
+// checks that the driver, contexts and modules are set up once and every
+// buffer is freed
+func TestCUDASetupOnce(t *testing.T) {
+	d := newSimDriver("Simulated GPU 0", "Simulated GPU 1")
+	b := newSimCUDABackend("cuda-sim", d, 2)
+
+	const requests = 20
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := b.Process(context.Background(), testJob(24, 16)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := d.Stats()
+	modules := len(b.kernels.Modules())
+	if stats.Inits != 1 || stats.CtxCreates != 2 || stats.ModuleLoads != 2*modules {
+		t.Errorf("%d inits, %d contexts and %d module loads, want 1, 2 and %d", stats.Inits, stats.CtxCreates, stats.ModuleLoads, 2*modules)
+	}
+	// Each kernel is looked up at most once per device
+	if stats.FunctionLookups > 2*len(kernelSpecs) {
+		t.Errorf("%d kernel lookups, want at most %d", stats.FunctionLookups, 2*len(kernelSpecs))
+	}
+	if stats.Launches < requests*2 {
+		t.Errorf("%d launches for %d resizes", stats.Launches, requests)
+	}
+	if live := d.LiveAllocations(); len(live) != 0 {
+		t.Errorf("allocations left after the requests: %v", live)
+	}
+}
+
+// TestCUDAFreesOnError checks that a request failing after its buffers
+// were allocated leaves nothing allocated
+func TestCUDAFreesOnError(t *testing.T) {
+	d := newSimDriver("Simulated GPU")
+	delete(d.kernels, "resampleVertical")
+	b := newSimCUDABackend("cuda-sim", d, 1)
+	if _, err := b.Process(context.Background(), testJob(8, 8)); err == nil {
+		t.Fatal("resizing without the vertical pass succeeded")
+	}
+	if live := d.LiveAllocations(); len(live) != 0 {
+		t.Errorf("allocations left after a failed request: %v", live)
+	}
+}
+
+// TestCUDAOpenBackoff checks that a failed open is remembered: health
+// checks inside the retry window don't touch the driver again
+func TestCUDAOpenBackoff(t *testing.T) {
+	d := newSimDriver() // No devices
+	b := newSimCUDABackend("cuda-sim", d, 1)
+	for range 5 {
+		if err := b.Health(context.Background()); err == nil {
+			t.Fatal("health check passed without a device")
+		}
+	}
+	if got := d.Stats().Inits; got != 1 {
+		t.Errorf("%d inits inside the retry window, want 1", got)
+	}
+
+	// Once the window has passed the driver is tried again
+	b.retryAt = time.Now()
+	if err := b.Health(context.Background()); err == nil {
+		t.Fatal("health check passed without a device")
+	}
+	if got := d.Stats().Inits; got != 2 {
+		t.Errorf("%d inits after the retry window, want 2", got)
+	}
+}
+
+func TestCUDAHonoursGPUID(t *testing.T) {
+	b := newSimCUDABackend("cuda-sim", newSimDriver("Simulated GPU 0", "Simulated GPU 1"), 1)
+	for _, id := range []int{1, 0, 1} {
+		job := testJob(16, 16)
+		job.GPU = &id
+		result, err := b.Process(context.Background(), job)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.DeviceID != id || result.DeviceName != b.Devices()[id] {
+			t.Errorf("gpu_id %d ran on device %d (%s)", id, result.DeviceID, result.DeviceName)
+		}
+	}
+	stats := b.Pool().Stats()
+	if stats[0].Completed != 1 || stats[1].Completed != 2 {
+		t.Errorf("completed %d and %d jobs, want 1 and 2", stats[0].Completed, stats[1].Completed)
+	}
+}
+
+func TestCUDARejectsInvalidGPUID(t *testing.T) {
+	b := newSimCUDABackend("cuda-sim", newSimDriver("Simulated GPU 0", "Simulated GPU 1"), 1)
+	for _, id := range []int{2, -1} {
+		job := testJob(16, 16)
+		job.GPU = &id
+		if _, err := b.Process(context.Background(), job); !errors.Is(err, errInvalidRequest) {
+			t.Errorf("gpu_id %d: got %v, want an invalid request", id, err)
+		}
+	}
+}
+
+func TestDevicePoolSpreadsLoad(t *testing.T) {
+	b := newSimCUDABackend("cuda-sim", newSimDriver("Simulated GPU 0", "Simulated GPU 1", "Simulated GPU 2"), 2)
+	pool, err := b.open()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Jobs in flight go to the least-loaded device
+	var releases []func()
+	for i := range 6 {
+		dev, release, err := pool.acquire(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dev.ordinal != i%3 {
+			t.Errorf("job %d went to device %d, want %d", i, dev.ordinal, i%3)
+		}
+		releases = append(releases, release)
+	}
+	for _, s := range pool.Stats() {
+		if s.Inflight != 2 || s.Peak != 2 {
+			t.Errorf("device %d has %d in flight and a peak of %d, want 2 and 2", s.Ordinal, s.Inflight, s.Peak)
+		}
+	}
+	for _, release := range releases {
+		release()
+	}
+
+	// With equal load, the device that has done the least work is next
+	_, release, err := pool.acquire(context.Background(), new(int))
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if dev, release, err := pool.acquire(context.Background(), nil); err != nil || dev.ordinal != 1 {
+		t.Errorf("after more work on device 0, got device %v, %v, want 1", dev, err)
+	} else {
+		release()
+	}
+}
+
+func TestDevicePoolCapsConcurrency(t *testing.T) {
+	b := newSimCUDABackend("cuda-sim", newSimDriver("Simulated GPU"), 1)
+	pool, err := b.open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, release, err := pool.acquire(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A second job waits for the slot until its context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := pool.acquire(ctx, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second acquire on a full device: %v, want a deadline", err)
+	}
+	if s := pool.Stats()[0]; s.Inflight != 1 {
+		t.Errorf("%d jobs in flight after the wait was given up, want 1", s.Inflight)
+	}
+	release()
+
+	// Concurrent requests never exceed the cap
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := b.Process(context.Background(), testJob(16, 16)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if s := pool.Stats()[0]; s.Peak != 1 || s.Completed != 9 || s.Inflight != 0 {
+		t.Errorf("peak %d, completed %d, in flight %d, want 1, 9 and 0", s.Peak, s.Completed, s.Inflight)
+	}
+}
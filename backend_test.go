@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeBackend is a configurable Backend for exercising the server and
+// policies without real hardware
+type fakeBackend struct {
+	name      string
+	caps      Capabilities
+	healthErr error
+	process   func(ctx context.Context, job *Job) (*Result, error)
+	calls     atomic.Int64
+}
+
+func (f *fakeBackend) Name() string { return f.name }
+
+func (f *fakeBackend) Capabilities() Capabilities { return f.caps }
+
+func (f *fakeBackend) Health(ctx context.Context) error { return f.healthErr }
+
+// Process records the call and delegates to the configured function,
+// echoing the input image when none is set
+func (f *fakeBackend) Process(ctx context.Context, job *Job) (*Result, error) {
+	f.calls.Add(1)
+	if f.process != nil {
+		return f.process(ctx, job)
+	}
+	return &Result{Output: Output{Image: job.ImageData}}, nil
+}
+
+// Calls reports how many jobs the backend has processed
+func (f *fakeBackend) Calls() int64 {
+	return f.calls.Load()
+}
+
+func backendNames(backends []Backend) []string {
+	var names []string
+	for _, b := range backends {
+		names = append(names, b.Name())
+	}
+	return names
+}
+
+func TestRegistryRejectsDuplicateNames(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(&fakeBackend{name: "a"}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(&fakeBackend{name: "a"}, 2); err == nil {
+		t.Fatal("registering a second backend named a succeeded")
+	}
+	if got := backendNames(r.Backends()); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("backends = %v, want [a]", got)
+	}
+}
+
+func TestRegistryOrdersByPriority(t *testing.T) {
+	r := NewRegistry()
+	for _, b := range []struct {
+		name     string
+		priority int
+	}{{"low", 0}, {"high", 100}, {"mid", 50}, {"mid2", 50}} {
+		if err := r.Register(&fakeBackend{name: b.name}, b.priority); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Equal priorities keep the order they were registered in
+	want := []string{"high", "mid", "mid2", "low"}
+	if got := backendNames(r.Backends()); !slices.Equal(got, want) {
+		t.Errorf("backends = %v, want %v", got, want)
+	}
+	if b, ok := r.Get("mid2"); !ok || b.Name() != "mid2" {
+		t.Errorf("Get(mid2) = %v, %v", b, ok)
+	}
+	if _, ok := r.Get("missing"); ok {
+		t.Error("Get(missing) found a backend")
+	}
+}
+
+func TestPreferGPU(t *testing.T) {
+	cpu := &fakeBackend{name: "cpu"}
+	gpu := &fakeBackend{name: "gpu", caps: Capabilities{GPU: true}}
+	broken := &fakeBackend{name: "broken", caps: Capabilities{GPU: true}, healthErr: errors.New("no device")}
+
+	tests := []struct {
+		name     string
+		backends []Backend
+		want     []string
+	}{
+		{"GPU before CPU", []Backend{cpu, gpu}, []string{"gpu", "cpu"}},
+		{"unhealthy GPU skipped", []Backend{broken, cpu}, []string{"cpu"}},
+		{"healthy GPU kept", []Backend{broken, gpu, cpu}, []string{"gpu", "cpu"}},
+		{"nothing healthy", []Backend{broken}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := backendNames(PreferGPU(context.Background(), &Job{}, tt.backends))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("PreferGPU = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnlyBackend(t *testing.T) {
+	backends := []Backend{&fakeBackend{name: "gpu", caps: Capabilities{GPU: true}}, &fakeBackend{name: "cpu"}}
+	if got := backendNames(OnlyBackend("cpu")(context.Background(), &Job{}, backends)); !slices.Equal(got, []string{"cpu"}) {
+		t.Errorf("OnlyBackend(cpu) = %v, want [cpu]", got)
+	}
+	if got := OnlyBackend("tpu")(context.Background(), &Job{}, backends); got != nil {
+		t.Errorf("OnlyBackend(tpu) = %v, want none", backendNames(got))
+	}
+}
+
+func TestPolicyFromName(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(&fakeBackend{name: "cpu"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"", "auto", "cpu"} {
+		if _, err := policyFromName(name, r); err != nil {
+			t.Errorf("policyFromName(%q): %v", name, err)
+		}
+	}
+	if _, err := policyFromName("tpu", r); err == nil {
+		t.Error("policyFromName(tpu) succeeded for an unregistered backend")
+	}
+}
+
+func TestProcessBatchFallsBack(t *testing.T) {
+	gpu := &fakeBackend{name: "gpu", caps: Capabilities{GPU: true}, process: func(ctx context.Context, job *Job) (*Result, error) {
+		if string(job.ImageData) == "bad" {
+			return nil, fmt.Errorf("%w: unusable", errInvalidRequest)
+		}
+		return nil, errors.New("device lost")
+	}}
+	cpu := &fakeBackend{name: "cpu"}
+	r := NewRegistry()
+	for priority, b := range []Backend{cpu, gpu} {
+		if err := r.Register(b, priority); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &server{backends: r, policy: PreferGPU}
+
+	jobs := []*Job{{ImageData: []byte("good")}, {ImageData: []byte("bad")}}
+	responses, errs := s.processBatch(context.Background(), jobs)
+
+	// Device errors fall back to the CPU, invalid requests are not retried
+	if errs[0] != nil || string(responses[0].GetResizedImage()) != "good" || responses[0].GetUsedGpu() {
+		t.Errorf("job 0 = %v, %v, want the CPU result", responses[0], errs[0])
+	}
+	if status.Code(errs[1]) != codes.InvalidArgument {
+		t.Errorf("job 1 error = %v, want InvalidArgument", errs[1])
+	}
+	if gpu.Calls() != 2 || cpu.Calls() != 1 {
+		t.Errorf("calls: gpu %d, cpu %d, want 2 and 1", gpu.Calls(), cpu.Calls())
+	}
+}
+
+// TestVariants checks that every backend returns the variants of a job
+// by name and in order, each matching a job with just that output
+func TestVariants(t *testing.T) {
+	variants := []Variant{
+		{Name: "thumb", OutputSpec: OutputSpec{Width: 8, Height: 8, Fit: FitCover, Output: EncodeOptions{Format: OutputPNG}}},
+		{Name: "wide", OutputSpec: OutputSpec{Width: 30, Height: 10, Fit: FitContain, Filter: FilterBox, Background: color.NRGBA{A: 255}, Output: EncodeOptions{Format: OutputPNG}}},
+		{Name: "same", OutputSpec: OutputSpec{Width: 40, Output: EncodeOptions{Format: OutputGIF}}},
+		{Name: "thumb@2x", OutputSpec: OutputSpec{Width: 16, Height: 16, Fit: FitCover, Filter: FilterBilinear, Output: EncodeOptions{Format: OutputWebP}}},
+	}
+	want := []struct {
+		width, height int
+		format        OutputFormat
+	}{
+		{8, 8, OutputPNG},
+		{30, 10, OutputPNG},
+		{40, 30, OutputGIF},
+		{16, 16, OutputWebP},
+	}
+
+	backends := []Backend{cpuBackend{}, newSimCUDABackend("cuda-sim", newSimDriver("Simulated GPU"), 1)}
+	for _, b := range backends {
+		t.Run(b.Name(), func(t *testing.T) {
+			src := gradientImage(40, 30)
+			result, err := b.Process(context.Background(), &Job{Source: src, SourceFormat: "png", Variants: variants})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Variants) != len(variants) {
+				t.Fatalf("got %d variants, want %d", len(result.Variants), len(variants))
+			}
+			if result.Image != nil {
+				t.Error("a job with variants also returned its own output")
+			}
+			for i, got := range result.Variants {
+				v := variants[i]
+				if got.Name != v.Name {
+					t.Errorf("variant %d is named %q, want %q", i, got.Name, v.Name)
+				}
+				if got.Width != want[i].width || got.Height != want[i].height || got.Format != want[i].format {
+					t.Errorf("%s: got %dx%d %s, want %dx%d %s", v.Name, got.Width, got.Height, got.Format,
+						want[i].width, want[i].height, want[i].format)
+				}
+
+				single, err := b.Process(context.Background(), &Job{Source: src, SourceFormat: "png", OutputSpec: v.OutputSpec})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got.Image, single.Image) {
+					t.Errorf("%s differs from a job with only that output", v.Name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"image"
+	"math"
+)
+
+// Automatic sharpening after a downscale: the unsharp amount grows by
+// autoSharpenPerHalving for every halving of the pixel count's square
+// root, up to autoSharpenMaxAmount
+const (
+	autoSharpenSigma      = 0.6
+	autoSharpenThreshold  = 2
+	autoSharpenPerHalving = 0.25
+	autoSharpenMaxAmount  = 1.0
+)
+
+// BlurOp applies a Gaussian blur
+type BlurOp struct {
+	Sigma float64 // Standard deviation in pixels
+}
+
+func (op BlurOp) plan(size image.Point) ([]step, error) {
+	return []step{blurStep{Radius: gaussianRadius(op.Sigma), Sigma: float32(op.Sigma)}}, nil
+}
+
+// BoxBlurOp averages every pixel with its neighbours within Radius
+type BoxBlurOp struct {
+	Radius int
+}
+
+func (op BoxBlurOp) plan(size image.Point) ([]step, error) {
+	return []step{blurStep{Radius: op.Radius}}, nil
+}
+
+// UnsharpMaskOp sharpens by adding back the difference between the image
+// and a Gaussian blur of it
+type UnsharpMaskOp struct {
+	Sigma     float64 // Standard deviation of the blur in pixels
+	Amount    float64 // Fraction of the difference added back
+	Threshold int     // Channel differences below this are left alone
+}
+
+func (op UnsharpMaskOp) plan(size image.Point) ([]step, error) {
+	return []step{unsharpStep{
+		Radius:    gaussianRadius(op.Sigma),
+		Sigma:     float32(op.Sigma),
+		Amount:    float32(op.Amount),
+		Threshold: op.Threshold,
+	}}, nil
+}
+
+// autoSharpen returns the unsharp mask that restores the detail lost when
+// scaling from to to, or nil when the image is not made smaller
+func autoSharpen(from, to image.Point) []step {
+	ratio := math.Sqrt(float64(from.X*from.Y) / float64(to.X*to.Y))
+	if ratio <= 1 {
+		return nil
+	}
+	steps, _ := UnsharpMaskOp{
+		Sigma:     autoSharpenSigma,
+		Amount:    min(autoSharpenPerHalving*math.Log2(ratio), autoSharpenMaxAmount),
+		Threshold: autoSharpenThreshold,
+	}.plan(to)
+	return steps
+}
+
+// gaussianRadius covers three standard deviations
+func gaussianRadius(sigma float64) int {
+	return max(1, int(math.Ceil(3*sigma)))
+}
+
+// blurWeights returns the normalized 1D kernel of 2*radius+1 taps: a
+// Gaussian, or a box when sigma is 0
+func blurWeights(radius int, sigma float32) []float32 {
+	weights := make([]float32, 2*radius+1)
+	var sum float64
+	for i := range weights {
+		w := 1.0
+		if sigma > 0 {
+			d := float64(i - radius)
+			w = math.Exp(-d * d / (2 * float64(sigma) * float64(sigma)))
+		}
+		weights[i] = float32(w)
+		sum += w
+	}
+	for i := range weights {
+		weights[i] = float32(float64(weights[i]) / sum)
+	}
+	return weights
+}
+
+// blurStep convolves the image with a separable Gaussian or box kernel.
+// Colours are weighted by alpha, so transparent pixels do not bleed their
+// colour into their neighbours.
+type blurStep struct {
+	Radius int
+	Sigma  float32 // 0 for a box blur
+}
+
+func (st blurStep) outputSize(in image.Point) image.Point { return in }
+
+func (st blurStep) cpu(img *image.NRGBA) *image.NRGBA {
+	return convolveNRGBA(img, blurWeights(st.Radius, st.Sigma))
+}
+
+func (st blurStep) gpu(s *gpuSession, img deviceImage) (deviceImage, error) {
+	return s.convolve(img, blurWeights(st.Radius, st.Sigma))
+}
+
+// unsharpStep sharpens with an unsharp mask
+type unsharpStep struct {
+	Radius    int
+	Sigma     float32
+	Amount    float32
+	Threshold int
+}
+
+func (st unsharpStep) outputSize(in image.Point) image.Point { return in }
+
+func (st unsharpStep) cpu(img *image.NRGBA) *image.NRGBA {
+	if img.Stride != img.Bounds().Dx()*4 {
+		img = cloneNRGBA(img)
+	}
+	blurred := convolveNRGBA(img, blurWeights(st.Radius, st.Sigma))
+	out := image.NewNRGBA(img.Rect)
+	width := img.Bounds().Dx()
+	parallelRows(img.Bounds().Dy(), func(y int) {
+		for i := y * width * 4; i < (y+1)*width*4; i += 4 {
+			unsharpPixel(img.Pix[i:i+4], blurred.Pix[i:i+4], out.Pix[i:i+4], st.Amount, st.Threshold)
+		}
+	})
+	return out
+}
+
+func (st unsharpStep) gpu(s *gpuSession, img deviceImage) (deviceImage, error) {
+	blurred, err := s.convolve(img, blurWeights(st.Radius, st.Sigma))
+	if err != nil {
+		return deviceImage{}, err
+	}
+	out, err := s.newImage(img.width, img.height, img.count)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	err = s.launch(unsharpKernelV1, img.width, img.height, img.count,
+		img.ptr, blurred.ptr, out.ptr, int32(img.width), int32(img.height),
+		st.Amount, int32(st.Threshold),
+	)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	return out, nil
+}
+
+// convolveNRGBA applies a separable kernel on the CPU
+func convolveNRGBA(img *image.NRGBA, weights []float32) *image.NRGBA {
+	if img.Stride != img.Bounds().Dx()*4 {
+		img = cloneNRGBA(img)
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	temp := make([]float32, width*height*4)
+	parallelRows(height, func(y int) {
+		for x := 0; x < width; x++ {
+			convolveHorizontalPixel(img.Pix, temp, width, weights, x, y)
+		}
+	})
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	parallelRows(height, func(y int) {
+		for x := 0; x < width; x++ {
+			convolveVerticalPixel(temp, out.Pix, width, height, weights, x, y)
+		}
+	})
+	return out
+}
+
+// convolveHorizontalPixel computes pixel (x, y) of the horizontal pass:
+// input is RGBA8 and temp holds alpha-weighted RGB and alpha as float32.
+// Edge pixels are repeated. Shared by the CPU backend and the simulated
+// convolveHorizontal kernel.
+func convolveHorizontalPixel(input []byte, temp []float32, width int, weights []float32, x, y int) {
+	radius := len(weights) / 2
+	var acc [4]float32
+	for i, w := range weights {
+		s := (y*width + clampInt(x+i-radius, 0, width-1)) * 4
+		a := w * float32(input[s+3])
+		acc[0] += a * float32(input[s])
+		acc[1] += a * float32(input[s+1])
+		acc[2] += a * float32(input[s+2])
+		acc[3] += a
+	}
+	copy(temp[(y*width+x)*4:], acc[:])
+}
+
+// convolveVerticalPixel computes pixel (x, y) of the vertical pass and
+// divides the colour by alpha again. Shared by the CPU backend and the
+// simulated convolveVertical kernel.
+func convolveVerticalPixel(temp []float32, output []byte, width, height int, weights []float32, x, y int) {
+	radius := len(weights) / 2
+	var acc [4]float32
+	for i, w := range weights {
+		s := (clampInt(y+i-radius, 0, height-1)*width + x) * 4
+		acc[0] += w * temp[s]
+		acc[1] += w * temp[s+1]
+		acc[2] += w * temp[s+2]
+		acc[3] += w * temp[s+3]
+	}
+	d := (y*width + x) * 4
+	if acc[3] <= 0 {
+		output[d], output[d+1], output[d+2], output[d+3] = 0, 0, 0, 0
+		return
+	}
+	output[d] = clampByte(acc[0] / acc[3])
+	output[d+1] = clampByte(acc[1] / acc[3])
+	output[d+2] = clampByte(acc[2] / acc[3])
+	output[d+3] = clampByte(acc[3])
+}
+
+// unsharpPixel sharpens one RGBA8 pixel against its blurred value; alpha
+// is kept. Shared by the CPU backend and the simulated unsharpKernel.
+func unsharpPixel(src, blurred, dst []byte, amount float32, threshold int) {
+	for c := 0; c < 3; c++ {
+		diff := int(src[c]) - int(blurred[c])
+		if diff < threshold && -diff < threshold {
+			dst[c] = src[c]
+			continue
+		}
+		dst[c] = clampByte(float32(src[c]) + amount*float32(diff))
+	}
+	dst[3] = src[3]
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestBlurWeights(t *testing.T) {
+	for _, sigma := range []float32{0, 0.6, 1.5, 4} {
+		radius := gaussianRadius(float64(max(sigma, 1)))
+		weights := blurWeights(radius, sigma)
+		var sum float64
+		for i, w := range weights {
+			sum += float64(w)
+			if w != weights[len(weights)-1-i] {
+				t.Errorf("sigma %v: weights %v are not symmetric", sigma, weights)
+				break
+			}
+		}
+		if math.Abs(sum-1) > 1e-5 {
+			t.Errorf("sigma %v: weights sum to %v", sigma, sum)
+		}
+	}
+}
+
+// TestBlurKeepsColourNextToTransparency blurs red pixels next to
+// transparent black ones and checks no black bleeds into the red
+func TestBlurKeepsColourNextToTransparency(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	dev := openSimDevice(t, newSimDriver("Simulated GPU"))
+	for _, op := range []Operation{BlurOp{Sigma: 1.5}, BoxBlurOp{Radius: 2}} {
+		cpu, gpu := runBothImplementations(t, dev, src, []Operation{op})
+		for impl, img := range map[string]*image.NRGBA{"CPU": cpu, "device": gpu} {
+			for x := 0; x < 8; x++ {
+				c := img.NRGBAAt(x, 1)
+				if c.A > 0 && (c.R != 255 || c.G != 0 || c.B != 0) {
+					t.Errorf("%T on the %s: pixel %d is %v, want pure red", op, impl, x, c)
+				}
+			}
+			if c := img.NRGBAAt(3, 1); c.A == 255 || c.A == 0 {
+				t.Errorf("%T on the %s: edge pixel has alpha %d, want it blurred", op, impl, c.A)
+			}
+		}
+	}
+}
+
+func TestAutoSharpen(t *testing.T) {
+	tests := []struct {
+		from, to image.Point
+		amount   float32 // 0 when no sharpening is wanted
+	}{
+		{image.Pt(100, 100), image.Pt(100, 100), 0},
+		{image.Pt(100, 100), image.Pt(200, 200), 0},
+		{image.Pt(200, 200), image.Pt(100, 100), autoSharpenPerHalving},
+		{image.Pt(400, 400), image.Pt(100, 100), 2 * autoSharpenPerHalving},
+		{image.Pt(6400, 6400), image.Pt(100, 100), autoSharpenMaxAmount},
+	}
+	for _, tt := range tests {
+		steps := autoSharpen(tt.from, tt.to)
+		if tt.amount == 0 {
+			if steps != nil {
+				t.Errorf("%v to %v: got %v, want no sharpening", tt.from, tt.to, steps)
+			}
+			continue
+		}
+		if len(steps) != 1 {
+			t.Fatalf("%v to %v: got %v, want one unsharp step", tt.from, tt.to, steps)
+		}
+		if st := steps[0].(unsharpStep); math.Abs(float64(st.Amount-tt.amount)) > 1e-6 {
+			t.Errorf("%v to %v: amount %v, want %v", tt.from, tt.to, st.Amount, tt.amount)
+		}
+	}
+}
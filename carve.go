@@ -0,0 +1,392 @@
+package main
+
+import (
+	"context"
+	"image"
+	"math"
+	"slices"
+	"sort"
+)
+
+// carveProtectEnergy is added to the energy of protected pixels. It is
+// larger than any seam through unprotected pixels can cost, so seams only
+// cross a region of interest when every path does.
+const carveProtectEnergy = 1 << 40
+
+// maxCarveSeams caps the seams removed or inserted along each axis. Each
+// seam costs a pass over the image, so a fit that needs more scales the
+// image the rest of the way instead.
+const maxCarveSeams = 128
+
+// carveLength returns the length an axis is scaled to before seams take
+// it from there to out: the scaled length, moved towards out until at most
+// maxCarveSeams seams, and no more than half of out, are left to carve
+func carveLength(scaled, out int) int {
+	limit := min(maxCarveSeams, out/2)
+	return min(max(scaled, out-limit), out+limit)
+}
+
+// carveStep resizes the image to Width x Height without scaling its
+// content, by removing the connected paths of pixels, or seams, that
+// change the least, or by duplicating them to grow the image. Protect
+// holds the regions of interest, in pixels of the input, that seams avoid;
+// it is nil when there are none.
+type carveStep struct {
+	Width, Height int
+	Protect       *focus
+}
+
+func (st carveStep) outputSize(image.Point) image.Point { return image.Pt(st.Width, st.Height) }
+
+func (st carveStep) cpu(img *image.NRGBA) *image.NRGBA {
+	out, _ := st.carve(context.Background(), img)
+	return out
+}
+
+// gpu carves on the CPU, which needs the stack downloaded and the results
+// uploaded again
+func (st carveStep) gpu(s *gpuSession, img deviceImage) (deviceImage, error) {
+	imgs, err := s.download(img)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	errs := make([]error, len(imgs))
+	parallelRows(len(imgs), func(i int) {
+		imgs[i], errs[i] = st.carve(s.ctx, imgs[i])
+	})
+	if i := slices.IndexFunc(errs, func(err error) bool { return err != nil }); i >= 0 {
+		return deviceImage{}, errs[i]
+	}
+	return s.upload(imgs...)
+}
+
+// carve runs the step on img, giving up with the error of ctx between
+// seams once it is done
+func (st carveStep) carve(ctx context.Context, img *image.NRGBA) (*image.NRGBA, error) {
+	c := newCarver(img, st.Protect)
+	if err := c.resizeWidth(ctx, st.Width); err != nil {
+		return nil, err
+	}
+	c = c.transpose()
+	if err := c.resizeWidth(ctx, st.Height); err != nil {
+		return nil, err
+	}
+	return c.transpose().image(), nil
+}
+
+// mapFocus moves the focus with the parts of the image around it. Seams
+// keep out of the regions as long as the regions leave room for them
+// along an axis; they are then assumed to be spread evenly over the rest
+// of the image. Otherwise the regions are cut.
+func (st carveStep) mapFocus(in image.Point, f *focus) *focus {
+	var axes [2]func(float64) float64
+	cut := f.Cut
+	for a, out := range [2]int{st.Width, st.Height} {
+		var kept bool
+		axes[a], kept = carveAxis([2]int{in.X, in.Y}[a], out, f.Regions, a)
+		cut = cut || !kept
+	}
+	out := f.mapRects(func(r focusRect) focusRect {
+		for a, fn := range axes {
+			r.Min[a], r.Max[a] = fn(r.Min[a]), fn(r.Max[a])
+		}
+		return r
+	})
+	out.Cut = cut
+	return out
+}
+
+// carveAxis returns how positions along axis a move when seams change its
+// length from in to out, and whether the regions keep their size
+func carveAxis(in, out int, regions []focusRect, a int) (func(float64) float64, bool) {
+	if in == out {
+		return func(p float64) float64 { return p }, true
+	}
+	// Merge the extents of the regions along the axis
+	var spans [][2]float64
+	for _, r := range regions {
+		spans = append(spans, [2]float64{max(r.Min[a], 0), min(r.Max[a], float64(in))})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	var merged [][2]float64
+	var protected float64
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s[0] <= merged[n-1][1] {
+			merged[n-1][1] = max(merged[n-1][1], s[1])
+			continue
+		}
+		merged = append(merged, s)
+	}
+	for _, s := range merged {
+		protected += s[1] - s[0]
+	}
+	if protected > float64(out) || protected >= float64(in) {
+		scale := float64(out) / float64(in)
+		return func(p float64) float64 { return p * scale }, false
+	}
+
+	// Protected spans keep their length and the gaps between them share
+	// the change
+	scale := (float64(out) - protected) / (float64(in) - protected)
+	return func(p float64) float64 {
+		var kept float64 // Length of the spans before p
+		for _, s := range merged {
+			if p <= s[0] {
+				break
+			}
+			kept += min(p, s[1]) - s[0]
+		}
+		return kept + (p-kept)*scale
+	}, true
+}
+
+// carver holds an image being carved. Rows keep their stride as seams are
+// removed, so closing a gap only moves the rest of the row. Each pixel has
+// an energy: how much it differs from its neighbours, plus
+// carveProtectEnergy inside a region of interest.
+type carver struct {
+	width, height, stride int
+	pix                   []uint8 // RGBA, stride pixels per row
+	protect               []bool  // stride per row
+}
+
+func newCarver(img *image.NRGBA, protect *focus) *carver {
+	if img.Stride != img.Bounds().Dx()*4 {
+		img = cloneNRGBA(img)
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	c := &carver{width: w, height: h, stride: w, pix: slices.Clone(img.Pix), protect: make([]bool, w*h)}
+	if protect != nil {
+		for _, r := range protect.Regions {
+			x0, y0 := max(int(math.Floor(r.Min[0])), 0), max(int(math.Floor(r.Min[1])), 0)
+			x1, y1 := min(int(math.Ceil(r.Max[0])), w), min(int(math.Ceil(r.Max[1])), h)
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					c.protect[y*w+x] = true
+				}
+			}
+		}
+	}
+	return c
+}
+
+// image returns the carved image
+func (c *carver) image() *image.NRGBA {
+	out := image.NewNRGBA(image.Rect(0, 0, c.width, c.height))
+	for y := 0; y < c.height; y++ {
+		copy(out.Pix[y*c.width*4:(y+1)*c.width*4], c.pix[y*c.stride*4:])
+	}
+	return out
+}
+
+// transpose returns the carver with rows and columns swapped, so
+// horizontal seams can be carved as vertical ones
+func (c *carver) transpose() *carver {
+	t := &carver{width: c.height, height: c.width, stride: c.height}
+	t.pix = make([]uint8, c.width*c.height*4)
+	t.protect = make([]bool, c.width*c.height)
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			copy(t.pix[(x*t.stride+y)*4:(x*t.stride+y)*4+4], c.pix[(y*c.stride+x)*4:])
+			t.protect[x*t.stride+y] = c.protect[y*c.stride+x]
+		}
+	}
+	return t
+}
+
+// resizeWidth removes or inserts vertical seams until the image is width
+// pixels wide
+func (c *carver) resizeWidth(ctx context.Context, width int) error {
+	if width < c.width {
+		if _, err := c.removeSeams(ctx, c.width-width, nil); err != nil {
+			return err
+		}
+	}
+	for c.width < width {
+		// Inserting more seams than half the width would duplicate the
+		// same seams over and over, so grow in steps
+		if err := c.insertSeams(ctx, min(width-c.width, max(c.width/2, 1))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// energyAt returns the energy of pixel (x, y): the sum of the differences
+// between its horizontal and its vertical neighbours over every channel
+func (c *carver) energyAt(x, y int) int64 {
+	at := func(x, y int) []uint8 {
+		x, y = min(max(x, 0), c.width-1), min(max(y, 0), c.height-1)
+		i := (y*c.stride + x) * 4
+		return c.pix[i : i+4]
+	}
+	l, r, u, d := at(x-1, y), at(x+1, y), at(x, y-1), at(x, y+1)
+	var e int64
+	for ch := 0; ch < 4; ch++ {
+		e += abs64(int64(l[ch])-int64(r[ch])) + abs64(int64(u[ch])-int64(d[ch]))
+	}
+	if c.protect[y*c.stride+x] {
+		e += carveProtectEnergy
+	}
+	return e
+}
+
+// removeSeams removes n seams of the lowest total energy, one at a time.
+// When index is set, it moves with the pixels, so the caller can tell
+// which columns of the original image each seam went through; the seams
+// are returned in those terms.
+func (c *carver) removeSeams(ctx context.Context, n int, index []int) ([][]int, error) {
+	energy := c.energies()
+	cost := make([]int64, c.stride*c.height)
+	seam := make([]int, c.height)
+	var removed [][]int
+	for ; n > 0; n-- {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		c.findSeam(energy, cost, seam)
+		if index != nil {
+			orig := make([]int, c.height)
+			for y, x := range seam {
+				orig[y] = index[y*c.stride+x]
+			}
+			removed = append(removed, orig)
+		}
+		c.removeSeam(energy, seam, index)
+	}
+	return removed, nil
+}
+
+// energies returns the energy of every pixel, stride per row
+func (c *carver) energies() []int64 {
+	energy := make([]int64, c.stride*c.height)
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			energy[y*c.stride+x] = c.energyAt(x, y)
+		}
+	}
+	return energy
+}
+
+// removeSeam closes the gap seam leaves in every row, moving energy and
+// index with the pixels, and updates the energies that changed
+func (c *carver) removeSeam(energy []int64, seam []int, index []int) {
+	for y, x := range seam {
+		row := y * c.stride
+		copy(c.pix[(row+x)*4:(row+c.width-1)*4], c.pix[(row+x+1)*4:(row+c.width)*4])
+		copy(c.protect[row+x:row+c.width-1], c.protect[row+x+1:row+c.width])
+		copy(energy[row+x:row+c.width-1], energy[row+x+1:row+c.width])
+		if index != nil {
+			copy(index[row+x:row+c.width-1], index[row+x+1:row+c.width])
+		}
+	}
+	c.width--
+
+	// Only pixels next to the seam have new neighbours: those beside it in
+	// its row, and those above and below it where the seam steps sideways.
+	// The latter are also next to the seam in their own row, but refreshing
+	// the rows around each seam pixel does not rely on that.
+	for y, x := range seam {
+		for ny := max(y-1, 0); ny <= min(y+1, c.height-1); ny++ {
+			for nx := max(x-2, 0); nx <= min(x+1, c.width-1); nx++ {
+				energy[ny*c.stride+nx] = c.energyAt(nx, ny)
+			}
+		}
+	}
+}
+
+// findSeam fills seam with the column, for every row, of the connected
+// top to bottom path with the lowest total energy
+func (c *carver) findSeam(energy, cost []int64, seam []int) {
+	w, s := c.width, c.stride
+	copy(cost[:w], energy[:w])
+	for y := 1; y < c.height; y++ {
+		prev := cost[(y-1)*s : (y-1)*s+w]
+		for x := 0; x < w; x++ {
+			best := prev[x]
+			if x > 0 {
+				best = min(best, prev[x-1])
+			}
+			if x < w-1 {
+				best = min(best, prev[x+1])
+			}
+			cost[y*s+x] = energy[y*s+x] + best
+		}
+	}
+
+	// Follow the cheapest path back up, preferring the leftmost on ties so
+	// the result does not depend on anything but the pixels
+	last := (c.height - 1) * s
+	seam[c.height-1] = 0
+	for x := 1; x < w; x++ {
+		if cost[last+x] < cost[last+seam[c.height-1]] {
+			seam[c.height-1] = x
+		}
+	}
+	for y := c.height - 2; y >= 0; y-- {
+		x := seam[y+1]
+		best := x
+		for _, nx := range []int{x - 1, x + 1} {
+			if nx >= 0 && nx < w && (cost[y*s+nx] < cost[y*s+best] || cost[y*s+nx] == cost[y*s+best] && nx < best) {
+				best = nx
+			}
+		}
+		seam[y] = best
+	}
+}
+
+// insertSeams widens the image by n pixels. It finds the n seams that
+// removing would take first, then duplicates each of them, as the average
+// of the seam pixel and its right neighbour, so the new pixels blend in.
+func (c *carver) insertSeams(ctx context.Context, n int) error {
+	// Find the seams on a copy, tracking the original columns
+	trial := &carver{width: c.width, height: c.height, stride: c.stride, pix: slices.Clone(c.pix), protect: slices.Clone(c.protect)}
+	index := make([]int, c.stride*c.height)
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			index[y*c.stride+x] = x
+		}
+	}
+	seams, err := trial.removeSeams(ctx, n, index)
+	if err != nil {
+		return err
+	}
+
+	width := c.width + n
+	pix := make([]uint8, width*c.height*4)
+	protect := make([]bool, width*c.height)
+	dup := make([]int, c.width) // Number of copies of each pixel of a row
+	for y := 0; y < c.height; y++ {
+		clear(dup)
+		for _, seam := range seams {
+			dup[seam[y]]++
+		}
+		src, dst := y*c.stride, y*width
+		for x := 0; x < c.width; x++ {
+			p := c.pix[(src+x)*4 : (src+x)*4+4]
+			copy(pix[dst*4:], p)
+			protect[dst] = c.protect[src+x]
+			dst++
+			if dup[x] == 0 {
+				continue
+			}
+			right := c.pix[(src+min(x+1, c.width-1))*4:]
+			for range dup[x] {
+				for ch := 0; ch < 4; ch++ {
+					pix[dst*4+ch] = uint8((int(p[ch]) + int(right[ch]) + 1) / 2)
+				}
+				protect[dst] = c.protect[src+x]
+				dst++
+			}
+		}
+	}
+	c.width, c.stride, c.pix, c.protect = width, width, pix, protect
+	return nil
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
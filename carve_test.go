@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image"
+	"testing"
+)
+
+// TestSeamCarveBoundsSeams checks that seam carving fits scale the image
+// so that no more than maxCarveSeams seams are left along either axis
+func TestSeamCarveBoundsSeams(t *testing.T) {
+	for _, tc := range []struct {
+		src, box, scaled image.Point
+	}{
+		// Within the bound the image is scaled to cover the box as before
+		{image.Pt(400, 300), image.Pt(400, 250), image.Pt(400, 300)},
+		{image.Pt(800, 600), image.Pt(300, 200), image.Pt(300, 225)},
+		{image.Pt(100, 100), image.Pt(150, 100), image.Pt(100, 100)},
+		// A banner out of a photo is mostly scaled
+		{image.Pt(4000, 3000), image.Pt(4000, 500), image.Pt(4000, 500+maxCarveSeams)},
+		// Growing is mostly scaling too, and never more than half the box
+		// is carved
+		{image.Pt(100, 100), image.Pt(1000, 100), image.Pt(1000-maxCarveSeams, 100)},
+		{image.Pt(300, 20), image.Pt(100, 20), image.Pt(150, 20)},
+	} {
+		l := planFit(tc.src.X, tc.src.Y, tc.box.X, tc.box.Y, FitSeamCarve)
+		if got := image.Pt(l.ScaledWidth, l.ScaledHeight); got != tc.scaled {
+			t.Errorf("%v into %v: scaled to %v, want %v", tc.src, tc.box, got, tc.scaled)
+		}
+		if l.Width != tc.box.X || l.Height != tc.box.Y {
+			t.Errorf("%v into %v: output %dx%d", tc.src, tc.box, l.Width, l.Height)
+		}
+	}
+}
+
+// TestSeamCarveStopsWhenCancelled checks that carving gives up between
+// seams once its context is done, on both backends
+func TestSeamCarveStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	src := resampleNRGBA(goldenImage(), 64, 48, FilterBilinear, false)
+	st := carveStep{Width: 40, Height: 48}
+
+	if _, err := st.carve(ctx, src); !errors.Is(err, context.Canceled) {
+		t.Errorf("carve: %v, want %v", err, context.Canceled)
+	}
+	if _, _, err := runStepsCPU(ctx, src, []step{st}); !errors.Is(err, context.Canceled) {
+		t.Errorf("CPU: %v, want %v", err, context.Canceled)
+	}
+
+	dev := openSimDevice(t, newSimDriver("Simulated GPU"))
+	s, err := newGPUSession(ctx, dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.close()
+	img, err := s.upload(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.gpu(s, img); !errors.Is(err, context.Canceled) {
+		t.Errorf("GPU: %v, want %v", err, context.Canceled)
+	}
+
+	// Carving still runs when the context is live
+	out, err := st.carve(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Rect.Size() != image.Pt(40, 48) {
+		t.Errorf("carved to %v, want 40x48", out.Rect.Size())
+	}
+}
+
+// TestSeamRemovalKeepsEnergies checks that the energies updated after
+// each seam match a full recompute, including around protected pixels
+func TestSeamRemovalKeepsEnergies(t *testing.T) {
+	src := resampleNRGBA(goldenImage(), 48, 32, FilterBilinear, false)
+	protect := &focus{Regions: []focusRect{{Min: [2]float64{10, 8}, Max: [2]float64{20, 16}}}}
+	c := newCarver(src, protect)
+	energy := c.energies()
+	cost := make([]int64, c.stride*c.height)
+	seam := make([]int, c.height)
+	for i := 0; i < 12; i++ {
+		c.findSeam(energy, cost, seam)
+		c.removeSeam(energy, seam, nil)
+		want := c.energies()
+		for y := 0; y < c.height; y++ {
+			for x := 0; x < c.width; x++ {
+				if got := energy[y*c.stride+x]; got != want[y*c.stride+x] {
+					t.Fatalf("after %d seams: energy at (%d, %d) is %d, want %d", i+1, x, y, got, want[y*c.stride+x])
+				}
+			}
+		}
+	}
+}
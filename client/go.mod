@@ -2,12 +2,18 @@ module grpc-resizer-client
 
 go 1.23.1
 
-require google.golang.org/grpc v1.71.0
+require (
+	github.com/jeauchter/go-image-adjuster v0.0.0
+	google.golang.org/grpc v1.71.0
+	google.golang.org/protobuf v1.36.4
+)
 
 require (
 	golang.org/x/net v0.34.0 // indirect
 	golang.org/x/sys v0.29.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250115164207-1a7da9e5054f // indirect
-	google.golang.org/protobuf v1.36.4 // indirect
 )
+
+// The client shares the server's generated proto package
+replace github.com/jeauchter/go-image-adjuster => ../
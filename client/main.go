@@ -1,21 +1,185 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"time"
 
 	pb "github.com/jeauchter/go-image-adjuster/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
 )
 
+// streamThreshold is the image size above which the client streams the
+// upload, staying clear of the 4 MB default gRPC message limit
+const streamThreshold = 3 << 20
+
+// chunkSize is the size of each streamed upload chunk
+const chunkSize = 1 << 20
+
+// resizeStream uploads the request's image in chunks after a header
+// carrying the other parameters
+func resizeStream(ctx context.Context, client pb.ImageResizerClient, req *pb.ResizeImageRequest) (*pb.ResizeImageResponse, error) {
+	stream, err := client.ResizeImageStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	imageData := req.ImageData
+	header := proto.Clone(req).(*pb.ResizeImageRequest)
+	header.ImageData = nil
+	if err := stream.Send(&pb.ResizeImageChunk{Payload: &pb.ResizeImageChunk_Header{Header: header}}); err != nil {
+		return nil, err
+	}
+	for len(imageData) > 0 {
+		n := min(chunkSize, len(imageData))
+		if err := stream.Send(&pb.ResizeImageChunk{Payload: &pb.ResizeImageChunk_Data{Data: imageData[:n]}}); err != nil {
+			// The server ended the stream, CloseAndRecv reports why
+			break
+		}
+		imageData = imageData[n:]
+	}
+	return stream.CloseAndRecv()
+}
+
+// resizeDownload receives the result in chunks and reassembles each image,
+// verifying its size and SHA-256 checksum
+func resizeDownload(ctx context.Context, client pb.ImageResizerClient, req *pb.ResizeImageRequest) (*pb.ResizeImageResponse, error) {
+	stream, err := client.ResizeImageDownload(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var res *pb.ResizeImageResponse
+	var image bytes.Buffer
+	received := 0 // Variant images completed
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil, errors.New("download ended before the checksum")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch payload := chunk.Payload.(type) {
+		case *pb.ResizeImageDownloadChunk_Metadata:
+			res = payload.Metadata
+		case *pb.ResizeImageDownloadChunk_Data:
+			if res == nil {
+				return nil, errors.New("download data arrived before the metadata")
+			}
+			image.Write(payload.Data)
+		case *pb.ResizeImageDownloadChunk_Checksum:
+			if res == nil {
+				return nil, errors.New("download checksum arrived before the metadata")
+			}
+			sum := sha256.Sum256(image.Bytes())
+			if uint64(image.Len()) != payload.Checksum.Size || !bytes.Equal(sum[:], payload.Checksum.Sha256) {
+				return nil, fmt.Errorf("download checksum mismatch: got %d bytes", image.Len())
+			}
+			// The images arrive in order: the main image, or each variant
+			data := bytes.Clone(image.Bytes())
+			image.Reset()
+			if len(res.Variants) == 0 {
+				res.ResizedImage = data
+				return res, nil
+			}
+			res.Variants[received].Image = data
+			received++
+			if received == len(res.Variants) {
+				return res, nil
+			}
+		}
+	}
+}
+
+// resizeDir sends every file in dir through one ResizeBatch stream, using
+// the file names as correlation ids
+func resizeDir(ctx context.Context, client pb.ImageResizerClient, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	stream, err := client.ResizeBatch(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Send while results come back
+	sendErr := make(chan error, 1)
+	go func() {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			imageData, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				sendErr <- err
+				return
+			}
+			req := &pb.ResizeImageRequest{ImageData: imageData, Width: 800, Height: 600, Quality: 90}
+			if err := stream.Send(&pb.BatchItem{Id: entry.Name(), Request: req}); err != nil {
+				sendErr <- err
+				return
+			}
+		}
+		sendErr <- stream.CloseSend()
+	}()
+
+	done, failed := 0, 0
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		done++
+		if res.Code != 0 {
+			failed++
+			log.Printf("%s failed: %s", res.Id, res.ErrorMessage)
+			continue
+		}
+		log.Printf("%s: %d bytes on %s", res.Id, len(res.Response.ResizedImage), res.Response.DeviceName)
+	}
+	if err := <-sendErr; err != nil {
+		return err
+	}
+	log.Printf("Batch done: %d images, %d failed", done, failed)
+	return nil
+}
+
 func main() {
 	// Get the gRPC server address from the environment variable
 	serverAddress := os.Getenv("GRPC_SERVER_ADDRESS")
 	if serverAddress == "" {
 		serverAddress = "localhost:50051"
 	}
+	// IMAGE_DIR resizes every image in a directory in one batch instead
+	imageDir := os.Getenv("IMAGE_DIR")
+	if imageDir != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		conn, err := grpc.DialContext(ctx, serverAddress, grpc.WithInsecure(), grpc.WithBlock())
+		if err != nil {
+			log.Fatalf("did not connect: %v", err)
+		}
+		defer conn.Close()
+		if err := resizeDir(context.Background(), pb.NewImageResizerClient(conn), imageDir); err != nil {
+			log.Fatalf("batch failed: %v", err)
+		}
+		return
+	}
+
 	// Read image from a file inside the container
 	imagePath := "/client/test_image/Test-image.jpeg"
 	// check that image exists
@@ -51,10 +215,16 @@ func main() {
 		Quality:   90,
 	}
 
-	// Send the request
-	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	// Send the request, streaming large uploads and downloading the result
+	// in chunks otherwise
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
-	res, err := client.ResizeImage(ctx, req)
+	var res *pb.ResizeImageResponse
+	if len(imageData) > streamThreshold {
+		res, err = resizeStream(ctx, client, req)
+	} else {
+		res, err = resizeDownload(ctx, client, req)
+	}
 	if err != nil {
 		log.Fatalf("could not resize image: %v", err)
 	}
@@ -62,6 +232,7 @@ func main() {
 	// Handle the response
 	log.Printf("Resized image size: %d bytes", len(res.ResizedImage))
 	log.Printf("Used GPU: %v", res.UsedGpu)
+	log.Printf("Device: %s", res.DeviceName)
 	if res.ErrorMessage != "" {
 		log.Printf("Error: %s", res.ErrorMessage)
 	}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"testing"
+
+	pb "github.com/jeauchter/go-image-adjuster/proto"
+	"google.golang.org/grpc"
+)
+
+// fakeDownloadClient answers ResizeImageDownload with canned messages
+type fakeDownloadClient struct {
+	pb.ImageResizerClient
+	chunks []*pb.ResizeImageDownloadChunk
+}
+
+func (c *fakeDownloadClient) ResizeImageDownload(ctx context.Context, in *pb.ResizeImageRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.ResizeImageDownloadChunk], error) {
+	return &fakeDownloadStream{chunks: c.chunks}, nil
+}
+
+type fakeDownloadStream struct {
+	grpc.ClientStream
+	chunks []*pb.ResizeImageDownloadChunk
+}
+
+func (s *fakeDownloadStream) Recv() (*pb.ResizeImageDownloadChunk, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func TestResizeDownload(t *testing.T) {
+	image := []byte("resized image bytes")
+	sum := sha256.Sum256(image)
+	metadata := &pb.ResizeImageDownloadChunk{Payload: &pb.ResizeImageDownloadChunk_Metadata{Metadata: &pb.ResizeImageResponse{Width: 4, Height: 3}}}
+	data := func(b []byte) *pb.ResizeImageDownloadChunk {
+		return &pb.ResizeImageDownloadChunk{Payload: &pb.ResizeImageDownloadChunk_Data{Data: b}}
+	}
+	checksum := func(size int, sha []byte) *pb.ResizeImageDownloadChunk {
+		return &pb.ResizeImageDownloadChunk{Payload: &pb.ResizeImageDownloadChunk_Checksum{Checksum: &pb.DownloadChecksum{Size: uint64(size), Sha256: sha}}}
+	}
+	corrupted := bytes.Clone(image)
+	corrupted[3] ^= 1
+
+	tests := []struct {
+		name   string
+		chunks []*pb.ResizeImageDownloadChunk
+		ok     bool
+	}{
+		{"whole", []*pb.ResizeImageDownloadChunk{metadata, data(image[:7]), data(image[7:]), checksum(len(image), sum[:])}, true},
+		{"corrupted", []*pb.ResizeImageDownloadChunk{metadata, data(corrupted), checksum(len(image), sum[:])}, false},
+		{"chunk missing", []*pb.ResizeImageDownloadChunk{metadata, data(image[7:]), checksum(len(image), sum[:])}, false},
+		{"wrong size", []*pb.ResizeImageDownloadChunk{metadata, data(image), checksum(len(image)+1, sum[:])}, false},
+		{"no checksum", []*pb.ResizeImageDownloadChunk{metadata, data(image)}, false},
+		{"no metadata", []*pb.ResizeImageDownloadChunk{data(image), checksum(len(image), sum[:])}, false},
+	}
+	for _, tt := range tests {
+		res, err := resizeDownload(context.Background(), &fakeDownloadClient{chunks: tt.chunks}, &pb.ResizeImageRequest{})
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("%s: the download was accepted", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !bytes.Equal(res.ResizedImage, image) || res.Width != 4 || res.Height != 3 {
+			t.Errorf("%s: got %q at %dx%d, want %q at 4x3", tt.name, res.ResizedImage, res.Width, res.Height, image)
+		}
+	}
+}
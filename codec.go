@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+
+	"github.com/jeauchter/go-image-adjuster/jpegenc"
+
+	// Register the decoders for the remaining input formats; gif and png
+	// register theirs through the encoder imports above
+	_ "image/jpeg"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// supportedFormats lists the input formats every backend can decode, by
+// the names image.DecodeConfig reports
+var supportedFormats = []string{"jpeg", "png", "gif", "webp", "bmp", "tiff"}
+
+// UnsupportedFormatError reports an input image in a format that cannot be decoded
+type UnsupportedFormatError struct {
+	Format    string // Detected format, empty when unrecognised
+	Supported []string
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	format := e.Format
+	if format == "" {
+		format = "unrecognised"
+	}
+	return fmt.Sprintf("unsupported image format %s, accepted formats: %s", format, strings.Join(e.Supported, ", "))
+}
+
+// Is makes UnsupportedFormatError match errInvalidRequest
+func (e *UnsupportedFormatError) Is(target error) bool {
+	return target == errInvalidRequest
+}
+
+// maxInputPixels caps the size of a decoded image. Images are checked
+// against it from their header, before their pixels are decoded.
+const maxInputPixels = 1 << 27
+
+// checkInputSize rejects an image whose header gives more than
+// maxInputPixels pixels
+func checkInputSize(cfg image.Config) error {
+	if area := int64(cfg.Width) * int64(cfg.Height); area > maxInputPixels {
+		return fmt.Errorf("%w: image of %dx%d pixels is over the limit of %d pixels", errInvalidRequest, cfg.Width, cfg.Height, maxInputPixels)
+	}
+	return nil
+}
+
+// Decode the image on the CPU, converting it to NRGBA. The detected format
+// is returned alongside the image.
+func decodeToNRGBA(imageData []byte) (*image.NRGBA, string, error) {
+	// check the size of imageData
+	if len(imageData) == 0 {
+		return nil, "", fmt.Errorf("%w: image data is empty", errInvalidRequest)
+	}
+
+	// First, check the format with DecodeConfig
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(imageData))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, "", &UnsupportedFormatError{Supported: supportedFormats}
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image config: %w", err)
+	}
+	if !slices.Contains(supportedFormats, format) {
+		return nil, "", &UnsupportedFormatError{Format: format, Supported: supportedFormats}
+	}
+	if err := checkInputSize(cfg); err != nil {
+		return nil, "", err
+	}
+
+	// Then actually decode the full image bytes; for animated GIFs this is
+	// the first frame
+	img, _, err := image.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode %s image: %w", format, err)
+	}
+
+	return toNRGBA(img), format, nil
+}
+
+// decodeStreamToNRGBA decodes an image as its bytes arrive on r, which lets
+// decoding overlap with a streamed upload. Anything after the image data is
+// read and discarded. The EXIF orientation of JPEG images is read from the
+// head of the stream.
+func decodeStreamToNRGBA(r io.Reader) (*image.NRGBA, string, Orientation, error) {
+	// The header is read once for its size, and again by the decoder
+	counted := &countingReader{r: r}
+	var header bytes.Buffer
+	cfg, format, err := image.DecodeConfig(io.TeeReader(counted, &header))
+	switch {
+	case counted.n == 0:
+		return nil, "", 0, fmt.Errorf("%w: image data is empty", errInvalidRequest)
+	case errors.Is(err, image.ErrFormat):
+		return nil, "", 0, &UnsupportedFormatError{Supported: supportedFormats}
+	case err != nil:
+		return nil, "", 0, fmt.Errorf("failed to read image config: %w", err)
+	case !slices.Contains(supportedFormats, format):
+		return nil, "", 0, &UnsupportedFormatError{Format: format, Supported: supportedFormats}
+	}
+	if err := checkInputSize(cfg); err != nil {
+		return nil, "", 0, err
+	}
+	img, _, err := image.Decode(io.MultiReader(&header, counted))
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to decode %s image: %w", format, err)
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return nil, "", 0, err
+	}
+	orientation := OrientNormal
+	if format == "jpeg" {
+		orientation = jpegOrientation(counted.head)
+	}
+	return toNRGBA(img), format, orientation, nil
+}
+
+// countingReader counts the bytes read through it and keeps the first
+// exifHeadBytes of them
+type countingReader struct {
+	r    io.Reader
+	n    int64
+	head []byte
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if room := exifHeadBytes - len(c.head); room > 0 {
+		c.head = append(c.head, p[:min(n, room)]...)
+	}
+	return n, err
+}
+
+// toNRGBA converts img to a tightly packed NRGBA image at the origin
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok && n.Rect.Min == (image.Point{}) && n.Stride == n.Rect.Dx()*4 {
+		return n
+	}
+	bounds := img.Bounds()
+	nrgbaImg := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(nrgbaImg, nrgbaImg.Bounds(), img, bounds.Min, draw.Src)
+	return nrgbaImg
+}
+
+// OutputFormat selects how a result is encoded. The values match the proto
+// OutputFormat enum.
+type OutputFormat int32
+
+const (
+	OutputSameAsInput OutputFormat = iota // Input format where it can be encoded, PNG otherwise
+	OutputJPEG
+	OutputPNG
+	OutputGIF
+	OutputWebP // Lossless
+)
+
+// String returns the format name as image.DecodeConfig reports it
+func (f OutputFormat) String() string {
+	switch f {
+	case OutputJPEG:
+		return "jpeg"
+	case OutputPNG:
+		return "png"
+	case OutputGIF:
+		return "gif"
+	case OutputWebP:
+		return "webp"
+	}
+	return "same-as-input"
+}
+
+// EncodeOptions controls how a result is encoded
+type EncodeOptions struct {
+	Format          OutputFormat
+	JPEGQuality     int // 1-100, 0 uses jpegenc.DefaultQuality
+	JPEGSubsampling jpegenc.Subsampling
+	PNGCompression  png.CompressionLevel
+	GIFColors       int  // Palette size 2-256, 0 uses 256
+	GIFDither       bool // Floyd-Steinberg error diffusion
+
+	// Accept enables format negotiation: each listed format is tried and
+	// the smallest one meeting MinPSNR is kept, overriding Format
+	Accept  []OutputFormat
+	MinPSNR float64 // Quality floor in dB, 0 uses defaultMinPSNR
+}
+
+// resolveFormat picks the concrete output format for img. Same-as-input
+// keeps the input format when it can be encoded, and switches JPEG to PNG
+// when the result has transparency, such as contain padding.
+func resolveFormat(format OutputFormat, inputFormat string, img *image.NRGBA) OutputFormat {
+	if format != OutputSameAsInput {
+		return format
+	}
+	switch inputFormat {
+	case "jpeg":
+		if img.Opaque() {
+			return OutputJPEG
+		}
+	case "gif":
+		return OutputGIF
+	case "webp":
+		return OutputWebP
+	}
+	return OutputPNG
+}
+
+// encodeImage encodes img according to opts, negotiating the format when
+// opts lists acceptable formats
+func encodeImage(img *image.NRGBA, inputFormat string, opts EncodeOptions) (Output, error) {
+	if len(opts.Accept) > 0 {
+		return negotiateFormat(img, inputFormat, opts)
+	}
+	format := resolveFormat(opts.Format, inputFormat, img)
+	data, err := encodeAs(img, format, opts)
+	if err != nil {
+		return Output{}, err
+	}
+	return Output{Image: data, Format: format, Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}, nil
+}
+
+// encodeAs encodes img in a concrete format
+func encodeAs(img *image.NRGBA, format OutputFormat, opts EncodeOptions) ([]byte, error) {
+	var output bytes.Buffer
+	var err error
+	switch format {
+	case OutputJPEG:
+		quality := opts.JPEGQuality
+		if quality == 0 {
+			quality = jpegenc.DefaultQuality
+		}
+		// JPEG has no alpha channel, so transparent areas become white
+		// rather than black
+		err = jpegenc.Encode(&output, flattenNRGBA(img, color.NRGBA{R: 255, G: 255, B: 255, A: 255}), &jpegenc.Options{
+			Quality:     quality,
+			Subsampling: opts.JPEGSubsampling,
+		})
+	case OutputPNG:
+		encoder := png.Encoder{CompressionLevel: opts.PNGCompression}
+		err = encoder.Encode(&output, img)
+	case OutputGIF:
+		numColors := opts.GIFColors
+		if numColors == 0 {
+			numColors = 256
+		}
+		var drawer draw.Drawer = draw.Src
+		if opts.GIFDither {
+			drawer = draw.FloydSteinberg
+		}
+		err = gif.Encode(&output, img, &gif.Options{NumColors: numColors, Quantizer: medianCut{}, Drawer: drawer})
+	case OutputWebP:
+		err = nativewebp.Encode(&output, img, nil)
+	default:
+		return nil, fmt.Errorf("%w: unknown output format %d", errInvalidRequest, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s image: %w", format, err)
+	}
+	return output.Bytes(), nil
+}
+
+// flattenNRGBA composites img over an opaque background, returning img
+// itself when it is already opaque
+func flattenNRGBA(img *image.NRGBA, background color.NRGBA) *image.NRGBA {
+	if img.Opaque() {
+		return img
+	}
+	flat := image.NewNRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+	return flat
+}
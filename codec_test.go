@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jeauchter/go-image-adjuster/jpegenc"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// webpImage is a 3x2 lossless WebP filled with NRGBA{32, 64, 96, 128}:
+// one single-symbol prefix code per channel and no pixel data
+var webpImage = []byte{
+	0x52, 0x49, 0x46, 0x46, 0x1a, 0x00, 0x00, 0x00, 0x57, 0x45, 0x42, 0x50,
+	0x56, 0x50, 0x38, 0x4c, 0x0d, 0x00, 0x00, 0x00, 0x2f, 0x02, 0x40, 0x00,
+	0x10, 0x28, 0x50, 0x41, 0x0a, 0x56, 0xc0, 0x02, 0x00, 0x00,
+}
+
+func TestDecodeFormats(t *testing.T) {
+	opaque := color.NRGBA{200, 100, 50, 255}
+	translucent := color.NRGBA{32, 64, 96, 128}
+	encodeWith := func(encode func(io.Writer, image.Image) error, c color.NRGBA) []byte {
+		var buf bytes.Buffer
+		if err := encode(&buf, uniformImage(3, 2, c)); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	jpegEncode := func(w io.Writer, img image.Image) error { return jpeg.Encode(w, img, &jpeg.Options{Quality: 100}) }
+	gifEncode := func(w io.Writer, img image.Image) error {
+		// Paletted, so the colour survives exactly
+		p := image.NewPaletted(img.Bounds(), color.Palette{color.Black, opaque})
+		for i := range p.Pix {
+			p.Pix[i] = 1
+		}
+		return gif.Encode(w, p, nil)
+	}
+	tiffEncode := func(w io.Writer, img image.Image) error { return tiff.Encode(w, img, nil) }
+
+	tests := []struct {
+		format    string
+		data      []byte
+		want      color.NRGBA
+		tolerance int
+	}{
+		{"jpeg", encodeWith(jpegEncode, opaque), opaque, 3},
+		{"png", encodeWith(png.Encode, translucent), translucent, 0},
+		{"gif", encodeWith(gifEncode, opaque), opaque, 0},
+		{"webp", webpImage, translucent, 0},
+		{"bmp", encodeWith(bmp.Encode, opaque), opaque, 0},
+		{"tiff", encodeWith(tiffEncode, translucent), translucent, 0},
+	}
+	for _, tt := range tests {
+		img, format, err := decodeToNRGBA(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		if format != tt.format {
+			t.Errorf("%s: detected as %s", tt.format, format)
+		}
+		if err := diffImages(uniformImage(3, 2, tt.want), img, tt.tolerance); err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+		}
+	}
+}
+
+func TestDecodeRejectsUnsupportedInput(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"unrecognised", []byte("not an image at all")},
+		{"truncated png", encodeTestPNG(t)[:20]},
+	}
+	for _, tt := range tests {
+		if _, _, err := decodeToNRGBA(tt.data); err == nil {
+			t.Errorf("%s: decoded", tt.name)
+		}
+	}
+
+	// Unknown formats are the caller's fault and name what is accepted
+	_, _, err := decodeToNRGBA([]byte("not an image at all"))
+	var unsupported *UnsupportedFormatError
+	if !errors.As(err, &unsupported) || !errors.Is(err, errInvalidRequest) {
+		t.Errorf("unrecognised input: got %v, want an invalid request listing the formats", err)
+	}
+	if _, _, err := decodeToNRGBA(nil); !errors.Is(err, errInvalidRequest) {
+		t.Errorf("empty input: got %v, want an invalid request", err)
+	}
+}
+
+// TestDecodeRejectsHugeImages checks that an image whose header is over
+// maxInputPixels is rejected before its pixels are decoded, whole or
+// streamed
+func TestDecodeRejectsHugeImages(t *testing.T) {
+	// Claim 20000x20000 pixels in the IHDR chunk of a small PNG
+	data := encodeTestPNG(t)
+	binary.BigEndian.PutUint32(data[16:], 20000)
+	binary.BigEndian.PutUint32(data[20:], 20000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	if _, _, err := decodeToNRGBA(data); !errors.Is(err, errInvalidRequest) || !strings.Contains(err.Error(), "over the limit") {
+		t.Errorf("decode: got %v, want an invalid request over the limit", err)
+	}
+	if _, _, _, err := decodeStreamToNRGBA(bytes.NewReader(data)); !errors.Is(err, errInvalidRequest) || !strings.Contains(err.Error(), "over the limit") {
+		t.Errorf("streamed decode: got %v, want an invalid request over the limit", err)
+	}
+
+	// The header read for the check is decoded again with the rest
+	img, _, _, err := decodeStreamToNRGBA(bytes.NewReader(encodeTestPNG(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := diffImages(gradientImage(8, 8), img, 0); err != nil {
+		t.Error(err)
+	}
+}
+
+// encodeTestPNG returns a small PNG image
+func encodeTestPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, gradientImage(8, 8)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestResolveFormat checks which format same-as-input picks
+func TestResolveFormat(t *testing.T) {
+	opaque := uniformImage(2, 2, color.NRGBA{1, 2, 3, 255})
+	translucent := uniformImage(2, 2, color.NRGBA{1, 2, 3, 128})
+	tests := []struct {
+		format OutputFormat
+		input  string
+		img    *image.NRGBA
+		want   OutputFormat
+	}{
+		{OutputSameAsInput, "jpeg", opaque, OutputJPEG},
+		{OutputSameAsInput, "jpeg", translucent, OutputPNG},
+		{OutputSameAsInput, "png", opaque, OutputPNG},
+		{OutputSameAsInput, "gif", translucent, OutputGIF},
+		{OutputSameAsInput, "webp", opaque, OutputWebP},
+		{OutputSameAsInput, "bmp", opaque, OutputPNG},
+		{OutputSameAsInput, "tiff", opaque, OutputPNG},
+		{OutputJPEG, "png", translucent, OutputJPEG},
+		{OutputWebP, "jpeg", opaque, OutputWebP},
+	}
+	for _, tt := range tests {
+		if got := resolveFormat(tt.format, tt.input, tt.img); got != tt.want {
+			t.Errorf("%v from %s (opaque %v) resolved to %v, want %v", tt.format, tt.input, tt.img.Opaque(), got, tt.want)
+		}
+	}
+}
+
+// TestEncodeOptions encodes with each format's options and decodes the
+// result to check they took effect
+func TestEncodeOptions(t *testing.T) {
+	src := gradientImage(48, 32)
+	// Smooth, so JPEG keeps it close
+	opaque := image.NewNRGBA(image.Rect(0, 0, 48, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 48; x++ {
+			opaque.SetNRGBA(x, y, color.NRGBA{uint8(x * 5), uint8(y * 7), 128, 255})
+		}
+	}
+	encode := func(img *image.NRGBA, opts EncodeOptions) []byte {
+		t.Helper()
+		enc, err := encodeImage(img, "png", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if enc.Format != opts.Format {
+			t.Fatalf("encoded %v as %v", opts.Format, enc.Format)
+		}
+		return enc.Image
+	}
+	decode := func(data []byte) *image.NRGBA {
+		t.Helper()
+		img, _, err := decodeToNRGBA(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return img
+	}
+
+	t.Run("jpeg quality", func(t *testing.T) {
+		low := encode(opaque, EncodeOptions{Format: OutputJPEG, JPEGQuality: 10})
+		high := encode(opaque, EncodeOptions{Format: OutputJPEG, JPEGQuality: 95})
+		if len(low) >= len(high) {
+			t.Errorf("quality 10 is %d bytes, quality 95 is %d", len(low), len(high))
+		}
+		if err := diffImages(opaque, decode(high), 8); err != nil {
+			t.Errorf("quality 95: %v", err)
+		}
+	})
+
+	t.Run("jpeg subsampling", func(t *testing.T) {
+		for sub, want := range map[jpegenc.Subsampling]image.YCbCrSubsampleRatio{
+			jpegenc.Subsample420: image.YCbCrSubsampleRatio420,
+			jpegenc.Subsample422: image.YCbCrSubsampleRatio422,
+			jpegenc.Subsample444: image.YCbCrSubsampleRatio444,
+		} {
+			data := encode(opaque, EncodeOptions{Format: OutputJPEG, JPEGSubsampling: sub})
+			img, err := jpeg.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := img.(*image.YCbCr).SubsampleRatio; got != want {
+				t.Errorf("subsampling %d decoded as %v, want %v", sub, got, want)
+			}
+		}
+	})
+
+	t.Run("jpeg flattens onto white", func(t *testing.T) {
+		clear := uniformImage(8, 8, color.NRGBA{})
+		if err := diffImages(uniformImage(8, 8, color.NRGBA{255, 255, 255, 255}), decode(encode(clear, EncodeOptions{Format: OutputJPEG})), 1); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("png compression", func(t *testing.T) {
+		none := encode(src, EncodeOptions{Format: OutputPNG, PNGCompression: png.NoCompression})
+		best := encode(src, EncodeOptions{Format: OutputPNG, PNGCompression: png.BestCompression})
+		if len(best) >= len(none) {
+			t.Errorf("best compression is %d bytes, none is %d", len(best), len(none))
+		}
+		for _, data := range [][]byte{none, best} {
+			if err := diffImages(src, decode(data), 0); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("gif colours", func(t *testing.T) {
+		for _, colors := range []int{2, 16, 0} {
+			img, err := gif.Decode(bytes.NewReader(encode(opaque, EncodeOptions{Format: OutputGIF, GIFColors: colors})))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := colors
+			if want == 0 {
+				want = 256
+			}
+			if n := len(img.(*image.Paletted).Palette); n > want {
+				t.Errorf("%d colours asked for, palette has %d", colors, n)
+			}
+		}
+	})
+
+	t.Run("gif dither", func(t *testing.T) {
+		plain := encode(opaque, EncodeOptions{Format: OutputGIF, GIFColors: 4})
+		dithered := encode(opaque, EncodeOptions{Format: OutputGIF, GIFColors: 4, GIFDither: true})
+		if bytes.Equal(plain, dithered) {
+			t.Error("dithering made no difference to a 4 colour gradient")
+		}
+	})
+
+	t.Run("webp is lossless", func(t *testing.T) {
+		if err := diffImages(src, decode(encode(src, EncodeOptions{Format: OutputWebP})), 0); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if _, err := encodeImage(src, "png", EncodeOptions{Format: OutputFormat(42)}); !errors.Is(err, errInvalidRequest) {
+			t.Errorf("got %v, want an invalid request", err)
+		}
+	})
+}
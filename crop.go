@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// Gravity anchors a region smaller than the image. The values match the
+// proto Gravity enum.
+type Gravity int32
+
+const (
+	GravityCenter Gravity = iota
+	GravityNorth
+	GravityNorthEast
+	GravityEast
+	GravitySouthEast
+	GravitySouth
+	GravitySouthWest
+	GravityWest
+	GravityNorthWest
+	GravitySmart // The most interesting region, for crops only
+)
+
+// anchor returns the top-left corner of a region of the given size placed
+// in an image of size bounds according to g
+func (g Gravity) anchor(bounds, region image.Point) image.Point {
+	p := image.Pt((bounds.X-region.X)/2, (bounds.Y-region.Y)/2)
+	switch g {
+	case GravityNorthWest, GravityWest, GravitySouthWest:
+		p.X = 0
+	case GravityNorthEast, GravityEast, GravitySouthEast:
+		p.X = bounds.X - region.X
+	}
+	switch g {
+	case GravityNorthWest, GravityNorth, GravityNorthEast:
+		p.Y = 0
+	case GravitySouthWest, GravitySouth, GravitySouthEast:
+		p.Y = bounds.Y - region.Y
+	}
+	return p
+}
+
+// CropOp cuts a rectangle given in pixels out of the image
+type CropOp struct {
+	Rect image.Rectangle
+}
+
+func (op CropOp) plan(size image.Point) ([]step, error) {
+	return cropSteps(size, op.Rect)
+}
+
+// CropPercentOp cuts a rectangle given in percent of the image size out of
+// the image
+type CropPercentOp struct {
+	X, Y, Width, Height float64 // 0-100
+}
+
+func (op CropPercentOp) plan(size image.Point) ([]step, error) {
+	scale := func(percent float64, size int) int {
+		return int(math.Round(percent * float64(size) / 100))
+	}
+	return cropSteps(size, image.Rect(
+		scale(op.X, size.X), scale(op.Y, size.Y),
+		scale(op.X+op.Width, size.X), scale(op.Y+op.Height, size.Y),
+	))
+}
+
+// CropGravityOp cuts a Width x Height region anchored by Gravity out of
+// the image. A zero or too large dimension keeps the image's. GravitySmart
+// picks the region from the content when the crop runs.
+type CropGravityOp struct {
+	Width, Height int
+	Gravity       Gravity
+}
+
+func (op CropGravityOp) plan(size image.Point) ([]step, error) {
+	return op.planFocused(size, nil)
+}
+
+// planFocused moves the region from its gravity to keep the focus in
+// frame. With a focus, smart gravity starts from the centre instead of
+// scoring the content.
+func (op CropGravityOp) planFocused(size image.Point, f *focus) ([]step, error) {
+	region := size
+	if op.Width > 0 {
+		region.X = min(op.Width, size.X)
+	}
+	if op.Height > 0 {
+		region.Y = min(op.Height, size.Y)
+	}
+	if op.Gravity == GravitySmart && f == nil {
+		return []step{smartCropStep{Width: region.X, Height: region.Y}}, nil
+	}
+	corner := f.place(op.Gravity.anchor(size, region), region, size)
+	return cropSteps(size, image.Rectangle{Min: corner, Max: corner.Add(region)})
+}
+
+// cropSteps clips r to an image of the given size and returns the step
+// that cuts it out
+func cropSteps(size image.Point, r image.Rectangle) ([]step, error) {
+	r = r.Intersect(image.Rectangle{Max: size})
+	if r.Empty() {
+		return nil, fmt.Errorf("%w: crop region is outside the %dx%d image", errInvalidRequest, size.X, size.Y)
+	}
+	return []step{placeStep{Layout: cropLayout(size, r)}}, nil
+}
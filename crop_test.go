@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/draw"
+	"slices"
+	"testing"
+)
+
+func TestGravityAnchor(t *testing.T) {
+	bounds, region := image.Pt(10, 8), image.Pt(4, 2)
+	tests := []struct {
+		gravity Gravity
+		want    image.Point
+	}{
+		{GravityCenter, image.Pt(3, 3)},
+		{GravityNorth, image.Pt(3, 0)},
+		{GravityNorthEast, image.Pt(6, 0)},
+		{GravityEast, image.Pt(6, 3)},
+		{GravitySouthEast, image.Pt(6, 6)},
+		{GravitySouth, image.Pt(3, 6)},
+		{GravitySouthWest, image.Pt(0, 6)},
+		{GravityWest, image.Pt(0, 3)},
+		{GravityNorthWest, image.Pt(0, 0)},
+	}
+	for _, tt := range tests {
+		if got := tt.gravity.anchor(bounds, region); got != tt.want {
+			t.Errorf("gravity %d: got %v, want %v", tt.gravity, got, tt.want)
+		}
+	}
+}
+
+// TestCrop plans each kind of crop on a 40x30 image and checks the region
+// cut out on both backends against the same region of the source
+func TestCrop(t *testing.T) {
+	tests := []struct {
+		name string
+		op   Operation
+		want image.Rectangle // Region of the source, empty when rejected
+	}{
+		{"rect", CropOp{Rect: image.Rect(5, 6, 25, 16)}, image.Rect(5, 6, 25, 16)},
+		{"rect clipped", CropOp{Rect: image.Rect(30, 20, 60, 50)}, image.Rect(30, 20, 40, 30)},
+		{"rect whole image", CropOp{Rect: image.Rect(0, 0, 40, 30)}, image.Rect(0, 0, 40, 30)},
+		{"rect outside", CropOp{Rect: image.Rect(40, 0, 50, 10)}, image.Rectangle{}},
+		{"percent", CropPercentOp{X: 25, Y: 10, Width: 50, Height: 50}, image.Rect(10, 3, 30, 18)},
+		{"percent rounded", CropPercentOp{X: 1, Y: 1, Width: 33.3, Height: 33.3}, image.Rect(0, 0, 14, 10)},
+		{"percent clipped", CropPercentOp{X: 50, Y: 50, Width: 100, Height: 100}, image.Rect(20, 15, 40, 30)},
+		{"gravity centre", CropGravityOp{Width: 20, Height: 10}, image.Rect(10, 10, 30, 20)},
+		{"gravity south east", CropGravityOp{Width: 20, Height: 10, Gravity: GravitySouthEast}, image.Rect(20, 20, 40, 30)},
+		{"gravity west", CropGravityOp{Width: 15, Height: 10, Gravity: GravityWest}, image.Rect(0, 10, 15, 20)},
+		{"gravity keeps height", CropGravityOp{Width: 10, Gravity: GravityNorth}, image.Rect(15, 0, 25, 30)},
+		{"gravity too large", CropGravityOp{Width: 100, Height: 100, Gravity: GravityNorthWest}, image.Rect(0, 0, 40, 30)},
+	}
+
+	src := gradientImage(40, 30)
+	dev := openSimDevice(t, newSimDriver("Simulated GPU"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, _, err := planPipeline([]Operation{tt.op}, src.Bounds().Size(), nil)
+			if tt.want.Empty() {
+				if !errors.Is(err, errInvalidRequest) {
+					t.Fatalf("got %v, want an invalid request error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := image.NewNRGBA(image.Rectangle{Max: tt.want.Size()})
+			draw.Draw(want, want.Bounds(), src, tt.want.Min, draw.Src)
+			cpu, _, _ := runStepsCPU(context.Background(), src, steps)
+			if err := diffImages(want, cpu, 0); err != nil {
+				t.Errorf("on the CPU: %v", err)
+			}
+			gpu, _, err := runBatchGPU(context.Background(), dev, []*image.NRGBA{src}, [][]step{steps})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := diffImages(want, gpu[0][0], 0); err != nil {
+				t.Errorf("on the simulated device: %v", err)
+			}
+		})
+	}
+}
+
+// TestCropAfterResize checks that a crop is planned against the size the
+// resize produces
+func TestCropAfterResize(t *testing.T) {
+	steps, _, err := planPipeline([]Operation{
+		ResizeOp{Width: 20, Filter: FilterNearest},
+		CropGravityOp{Width: 10, Height: 10, Gravity: GravitySouthEast},
+	}, image.Pt(40, 30), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []step{
+		resampleStep{Width: 20, Height: 15, Filter: FilterNearest},
+		placeStep{Layout: fitLayout{ScaledWidth: 20, ScaledHeight: 15, Width: 10, Height: 10, Offset: image.Pt(10, 5)}},
+	}
+	if !slices.Equal(steps, want) {
+		t.Errorf("got %v, want %v", steps, want)
+	}
+}
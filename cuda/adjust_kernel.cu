@@ -0,0 +1,40 @@
+// Colour adjustment kernel. The pixel maths mirror adjust.go so the CPU and
+// GPU backends agree; keep the two in sync.
+//
+// Like the resampling kernels, every kernel works on a stack of equally
+// sized images and blockIdx.z selects the image.
+
+__device__ unsigned char clampByte(float v) {
+    return (unsigned char)fminf(fmaxf(v + 0.5f, 0.0f), 255.0f);
+}
+
+// Per channel v = clamp(v * scale + offset)^invGamma on 0-1 values, then
+// the row-major colour matrix m on RGB; alpha is copied
+extern "C" __device__ int adjustKernel_version = 1;
+
+extern "C" __global__
+void adjustKernel(const unsigned char* input, unsigned char* output, int width, int height,
+                  float scale, float offset, float invGamma,
+                  float m0, float m1, float m2, float m3, float m4, float m5, float m6, float m7, float m8) {
+    int x = blockIdx.x * blockDim.x + threadIdx.x;
+    int y = blockIdx.y * blockDim.y + threadIdx.y;
+    if (x >= width || y >= height) {
+        return;
+    }
+    size_t offsetBytes = ((size_t)blockIdx.z * width * height + y * width + x) * 4;
+    const unsigned char* src = input + offsetBytes;
+    unsigned char* dst = output + offsetBytes;
+
+    float v[3];
+    for (int c = 0; c < 3; c++) {
+        float f = fminf(fmaxf((float)src[c] / 255.0f * scale + offset, 0.0f), 1.0f);
+        if (invGamma != 1.0f) {
+            f = powf(f, invGamma);
+        }
+        v[c] = f;
+    }
+    dst[0] = clampByte((m0 * v[0] + m1 * v[1] + m2 * v[2]) * 255.0f);
+    dst[1] = clampByte((m3 * v[0] + m4 * v[1] + m5 * v[2]) * 255.0f);
+    dst[2] = clampByte((m6 * v[0] + m7 * v[1] + m8 * v[2]) * 255.0f);
+    dst[3] = src[3];
+}
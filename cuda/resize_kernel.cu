@@ -1,17 +1,260 @@
+// Separable resampling kernels. The filter maths mirror resample.go so the
+// CPU and GPU backends agree; keep the two in sync.
+//
+// Every kernel works on a stack of equally sized images laid out one after
+// another in memory; blockIdx.z selects the image, so a batch of compatible
+// jobs shares each launch.
+
+// Checked against the Go kernel registry at startup; bump together with
+// the versions in kernels.go whenever a signature or semantics change
+extern "C" __device__ int resampleHorizontal_version = 4;
+extern "C" __device__ int resampleVertical_version = 4;
+
+#define FILTER_NEAREST  1
+#define FILTER_BILINEAR 2
+#define FILTER_BICUBIC  3
+#define FILTER_MITCHELL 4
+#define FILTER_LANCZOS2 5
+#define FILTER_LANCZOS3 6
+#define FILTER_BOX      7
+
+#define PI 3.14159265358979323846f
+
+__device__ float filterRadius(int filter) {
+    switch (filter) {
+    case FILTER_BILINEAR:
+        return 1.0f;
+    case FILTER_BICUBIC:
+    case FILTER_MITCHELL:
+    case FILTER_LANCZOS2:
+        return 2.0f;
+    case FILTER_LANCZOS3:
+        return 3.0f;
+    default:
+        return 0.5f;
+    }
+}
+
+__device__ float sinc(float x) {
+    if (x == 0.0f) {
+        return 1.0f;
+    }
+    x *= PI;
+    return sinf(x) / x;
+}
+
+__device__ float filterWeight(int filter, float t) {
+    t = fabsf(t);
+    switch (filter) {
+    case FILTER_BILINEAR:
+        if (t < 1.0f) return 1.0f - t;
+        break;
+    case FILTER_BICUBIC:
+        if (t < 1.0f) return (1.5f * t - 2.5f) * t * t + 1.0f;
+        if (t < 2.0f) return ((-0.5f * t + 2.5f) * t - 4.0f) * t + 2.0f;
+        break;
+    case FILTER_MITCHELL:
+        if (t < 1.0f) return ((7.0f * t - 12.0f) * t * t + 16.0f / 3.0f) / 6.0f;
+        if (t < 2.0f) return (((-7.0f / 3.0f * t + 12.0f) * t - 20.0f) * t + 32.0f / 3.0f) / 6.0f;
+        break;
+    case FILTER_LANCZOS2:
+        if (t < 2.0f) return sinc(t) * sinc(t / 2.0f);
+        break;
+    case FILTER_LANCZOS3:
+        if (t < 3.0f) return sinc(t) * sinc(t / 3.0f);
+        break;
+    default:
+        if (t < 0.5f) return 1.0f;
+        break;
+    }
+    return 0.0f;
+}
+
+__device__ unsigned char clampByte(float v) {
+    return (unsigned char)fminf(fmaxf(v + 0.5f, 0.0f), 255.0f);
+}
+
+// sRGB byte to linear light in 0-1
+__device__ float srgbToLinear(unsigned char b) {
+    float v = (float)b / 255.0f;
+    return v <= 0.04045f ? v / 12.92f : powf((v + 0.055f) / 1.055f, 2.4f);
+}
+
+// Linear light in 0-1 to sRGB in 0-1
+__device__ float linearToSRGB(float v) {
+    return v <= 0.0031308f ? v * 12.92f : 1.055f * powf(v, 1.0f / 2.4f) - 0.055f;
+}
+
+// Loads an RGBA8 pixel as floats in 0-255; in linear mode the colour is
+// converted to linear light and premultiplied by alpha
+__device__ void loadPixel(const unsigned char* p, int linear, float* v) {
+    if (!linear) {
+        for (int c = 0; c < 4; c++) {
+            v[c] = (float)p[c];
+        }
+        return;
+    }
+    float a = (float)p[3] / 255.0f;
+    for (int c = 0; c < 3; c++) {
+        v[c] = srgbToLinear(p[c]) * 255.0f * a;
+    }
+    v[3] = (float)p[3];
+}
+
+// Reverses loadPixel
+__device__ void storePixel(unsigned char* dst, const float* v, int linear) {
+    if (!linear) {
+        for (int c = 0; c < 4; c++) {
+            dst[c] = clampByte(v[c]);
+        }
+        return;
+    }
+    if (v[3] <= 0.0f) {
+        dst[0] = dst[1] = dst[2] = dst[3] = 0;
+        return;
+    }
+    for (int c = 0; c < 3; c++) {
+        dst[c] = clampByte(linearToSRGB(fminf(fmaxf(v[c] / v[3], 0.0f), 1.0f)) * 255.0f);
+    }
+    dst[3] = clampByte(v[3]);
+}
+
+// Horizontal pass: inWidth x inHeight RGBA8 -> outWidth x inHeight RGBA
+// float, premultiplied linear light when linear is set
 extern "C" __global__
-void resizeKernel(unsigned char* input, int inWidth, int inHeight, unsigned char* output, int outWidth, int outHeight) {
+void resampleHorizontal(const unsigned char* input, int inWidth, int inHeight, float* temp, int outWidth, int filter, int linear) {
     int x = blockIdx.x * blockDim.x + threadIdx.x;
     int y = blockIdx.y * blockDim.y + threadIdx.y;
+    if (x >= outWidth || y >= inHeight) {
+        return;
+    }
+    input += (size_t)blockIdx.z * inWidth * inHeight * 4;
+    temp += (size_t)blockIdx.z * outWidth * inHeight * 4;
 
-    if (x < outWidth && y < outHeight) {
-        int srcX = (x * inWidth) / outWidth;
-        int srcY = (y * inHeight) / outHeight;
-        int srcIdx = (srcY * inWidth + srcX) * 4; // Assuming RGBA
-        int dstIdx = (y * outWidth + x) * 4;
+    const unsigned char* row = input + y * inWidth * 4;
+    float* dst = temp + (y * outWidth + x) * 4;
+
+    float scale = (float)inWidth / (float)outWidth;
+    float center = ((float)x + 0.5f) * scale;
+    float filterScale = fmaxf(scale, 1.0f);
+    float support = filterRadius(filter) * filterScale;
+
+    if (filter == FILTER_NEAREST) {
+        int src = min(max((int)center, 0), inWidth - 1) * 4;
+        for (int c = 0; c < 4; c++) {
+            dst[c] = (float)row[src + c];
+        }
+        return;
+    }
+
+    int lo = (int)floorf(center - support);
+    int hi = (int)ceilf(center + support);
+    float acc[4] = {0.0f, 0.0f, 0.0f, 0.0f};
+    float sum = 0.0f;
+    for (int i = lo; i < hi; i++) {
+        float w = filterWeight(filter, ((float)i + 0.5f - center) / filterScale);
+        if (w == 0.0f) {
+            continue;
+        }
+        float px[4];
+        loadPixel(row + min(max(i, 0), inWidth - 1) * 4, linear, px);
+        for (int c = 0; c < 4; c++) {
+            acc[c] += w * px[c];
+        }
+        sum += w;
+    }
+    if (sum == 0.0f) {
+        // An upscaling box can fall exactly between two source pixels;
+        // take the nearest one
+        loadPixel(row + min(max((int)center, 0), inWidth - 1) * 4, linear, dst);
+        return;
+    }
+    for (int c = 0; c < 4; c++) {
+        dst[c] = acc[c] / sum;
+    }
+}
+
+// Vertical pass: width x inHeight RGBA float -> width x outHeight RGBA8.
+// Nearest neighbour copies pixels, so only the other filters use linear.
+extern "C" __global__
+void resampleVertical(const float* temp, int width, int inHeight, unsigned char* output, int outHeight, int filter, int linear) {
+    int x = blockIdx.x * blockDim.x + threadIdx.x;
+    int y = blockIdx.y * blockDim.y + threadIdx.y;
+    if (x >= width || y >= outHeight) {
+        return;
+    }
+    temp += (size_t)blockIdx.z * width * inHeight * 4;
+    output += (size_t)blockIdx.z * width * outHeight * 4;
+
+    unsigned char* dst = output + (y * width + x) * 4;
+
+    float scale = (float)inHeight / (float)outHeight;
+    float center = ((float)y + 0.5f) * scale;
+    float filterScale = fmaxf(scale, 1.0f);
+    float support = filterRadius(filter) * filterScale;
+
+    if (filter == FILTER_NEAREST) {
+        int src = (min(max((int)center, 0), inHeight - 1) * width + x) * 4;
+        for (int c = 0; c < 4; c++) {
+            dst[c] = clampByte(temp[src + c]);
+        }
+        return;
+    }
+
+    int lo = (int)floorf(center - support);
+    int hi = (int)ceilf(center + support);
+    float acc[4] = {0.0f, 0.0f, 0.0f, 0.0f};
+    float sum = 0.0f;
+    for (int i = lo; i < hi; i++) {
+        float w = filterWeight(filter, ((float)i + 0.5f - center) / filterScale);
+        if (w == 0.0f) {
+            continue;
+        }
+        int src = (min(max(i, 0), inHeight - 1) * width + x) * 4;
+        for (int c = 0; c < 4; c++) {
+            acc[c] += w * temp[src + c];
+        }
+        sum += w;
+    }
+    if (sum == 0.0f) {
+        // An upscaling box can fall exactly between two source pixels;
+        // take the nearest one
+        storePixel(dst, temp + (min(max((int)center, 0), inHeight - 1) * width + x) * 4, linear);
+        return;
+    }
+    for (int c = 0; c < 4; c++) {
+        acc[c] /= sum;
+    }
+    storePixel(dst, acc, linear);
+}
+
+// Crop/pad step of a fit layout: output pixel (x, y) copies input pixel
+// (x + dx, y + dy), or the background (packed 0xRRGGBBAA) outside the input
+extern "C" __device__ int placeKernel_version = 2;
+
+extern "C" __global__
+void placeKernel(const unsigned char* input, int inWidth, int inHeight, unsigned char* output, int outWidth, int outHeight, int dx, int dy, unsigned int background) {
+    int x = blockIdx.x * blockDim.x + threadIdx.x;
+    int y = blockIdx.y * blockDim.y + threadIdx.y;
+    if (x >= outWidth || y >= outHeight) {
+        return;
+    }
+    input += (size_t)blockIdx.z * inWidth * inHeight * 4;
+    output += (size_t)blockIdx.z * outWidth * outHeight * 4;
 
-        output[dstIdx] = input[srcIdx];      // R
-        output[dstIdx + 1] = input[srcIdx + 1];  // G
-        output[dstIdx + 2] = input[srcIdx + 2];  // B
-        output[dstIdx + 3] = input[srcIdx + 3];  // A
+    unsigned char* dst = output + (y * outWidth + x) * 4;
+    int sx = x + dx;
+    int sy = y + dy;
+    if (sx < 0 || sy < 0 || sx >= inWidth || sy >= inHeight) {
+        dst[0] = (background >> 24) & 0xff;
+        dst[1] = (background >> 16) & 0xff;
+        dst[2] = (background >> 8) & 0xff;
+        dst[3] = background & 0xff;
+        return;
     }
+    const unsigned char* src = input + (sy * inWidth + sx) * 4;
+    dst[0] = src[0];
+    dst[1] = src[1];
+    dst[2] = src[2];
+    dst[3] = src[3];
 }
@@ -0,0 +1,114 @@
+// Orientation and rotation kernels. The pixel maths mirror transform.go so
+// the CPU and GPU backends agree; keep the two in sync.
+//
+// Like the resampling kernels, every kernel works on a stack of equally
+// sized images and blockIdx.z selects the image.
+
+#define ORIENT_FLIP_H     2
+#define ORIENT_ROTATE_180 3
+#define ORIENT_FLIP_V     4
+#define ORIENT_TRANSPOSE  5
+#define ORIENT_ROTATE_90  6
+#define ORIENT_TRANSVERSE 7
+#define ORIENT_ROTATE_270 8
+
+__device__ unsigned char clampByte(float v) {
+    return (unsigned char)fminf(fmaxf(v + 0.5f, 0.0f), 255.0f);
+}
+
+// Flip or quarter turn, numbered like the EXIF Orientation tag: output
+// pixel (x, y) shows input pixel (u, v), where (u, v) is (x, y) or, for
+// the orientations that swap the axes, (y, x), then mirrored as needed
+extern "C" __device__ int orientKernel_version = 1;
+
+extern "C" __global__
+void orientKernel(const unsigned char* input, int inWidth, int inHeight, unsigned char* output, int outWidth, int outHeight, int orientation) {
+    int x = blockIdx.x * blockDim.x + threadIdx.x;
+    int y = blockIdx.y * blockDim.y + threadIdx.y;
+    if (x >= outWidth || y >= outHeight) {
+        return;
+    }
+    input += (size_t)blockIdx.z * inWidth * inHeight * 4;
+    output += (size_t)blockIdx.z * outWidth * outHeight * 4;
+
+    bool swap = orientation >= ORIENT_TRANSPOSE;
+    bool flipX = orientation == ORIENT_FLIP_H || orientation == ORIENT_ROTATE_180 ||
+                 orientation == ORIENT_TRANSVERSE || orientation == ORIENT_ROTATE_270;
+    bool flipY = orientation == ORIENT_ROTATE_180 || orientation == ORIENT_FLIP_V ||
+                 orientation == ORIENT_ROTATE_90 || orientation == ORIENT_TRANSVERSE;
+
+    int u = swap ? y : x;
+    int v = swap ? x : y;
+    if (flipX) u = inWidth - 1 - u;
+    if (flipY) v = inHeight - 1 - v;
+
+    const unsigned char* src = input + (v * inWidth + u) * 4;
+    unsigned char* dst = output + (y * outWidth + x) * 4;
+    dst[0] = src[0];
+    dst[1] = src[1];
+    dst[2] = src[2];
+    dst[3] = src[3];
+}
+
+// Arbitrary rotation: the output pixel centre is turned back onto the
+// input by the clockwise angle's cos and sin and sampled bilinearly, with
+// the background (packed 0xRRGGBBAA) outside the input. The samples are
+// mixed premultiplied, so transparent pixels do not lend their colour to
+// the edges.
+extern "C" __device__ int rotateKernel_version = 2;
+
+__device__ void rotateAdd(const unsigned char* input, int inWidth, int inHeight, int x, int y, float w, const unsigned char* background, float* v) {
+    const unsigned char* p = background;
+    if (x >= 0 && y >= 0 && x < inWidth && y < inHeight) {
+        p = input + (y * inWidth + x) * 4;
+    }
+    float a = (float)p[3];
+    for (int c = 0; c < 3; c++) {
+        v[c] += w * (float)p[c] * a / 255.0f;
+    }
+    v[3] += w * a;
+}
+
+extern "C" __global__
+void rotateKernel(const unsigned char* input, int inWidth, int inHeight, unsigned char* output, int outWidth, int outHeight, float cosA, float sinA, unsigned int background) {
+    int x = blockIdx.x * blockDim.x + threadIdx.x;
+    int y = blockIdx.y * blockDim.y + threadIdx.y;
+    if (x >= outWidth || y >= outHeight) {
+        return;
+    }
+    input += (size_t)blockIdx.z * inWidth * inHeight * 4;
+    output += (size_t)blockIdx.z * outWidth * outHeight * 4;
+
+    float dx = (float)x + 0.5f - (float)outWidth * 0.5f;
+    float dy = (float)y + 0.5f - (float)outHeight * 0.5f;
+    float fx = cosA * dx + sinA * dy + (float)inWidth * 0.5f - 0.5f;
+    float fy = cosA * dy - sinA * dx + (float)inHeight * 0.5f - 0.5f;
+
+    int x0 = (int)floorf(fx);
+    int y0 = (int)floorf(fy);
+    float wx = fx - (float)x0;
+    float wy = fy - (float)y0;
+    unsigned char bg[4] = {
+        (unsigned char)((background >> 24) & 0xff),
+        (unsigned char)((background >> 16) & 0xff),
+        (unsigned char)((background >> 8) & 0xff),
+        (unsigned char)(background & 0xff),
+    };
+
+    float v[4] = {0.0f, 0.0f, 0.0f, 0.0f};
+    rotateAdd(input, inWidth, inHeight, x0, y0, (1.0f - wx) * (1.0f - wy), bg, v);
+    rotateAdd(input, inWidth, inHeight, x0 + 1, y0, wx * (1.0f - wy), bg, v);
+    rotateAdd(input, inWidth, inHeight, x0, y0 + 1, (1.0f - wx) * wy, bg, v);
+    rotateAdd(input, inWidth, inHeight, x0 + 1, y0 + 1, wx * wy, bg, v);
+
+    unsigned char* dst = output + (y * outWidth + x) * 4;
+    unsigned char a = clampByte(v[3]);
+    if (a == 0) {
+        dst[0] = dst[1] = dst[2] = dst[3] = 0;
+        return;
+    }
+    for (int c = 0; c < 3; c++) {
+        dst[c] = clampByte(v[c] * 255.0f / v[3]);
+    }
+    dst[3] = a;
+}
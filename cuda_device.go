@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// cudaDevice is a long-lived context on one device together with the
+// kernel modules loaded into it. It is created once and shared by all
+// requests; callers must lock their OS thread and call bind before use.
+type cudaDevice struct {
+	ordinal int
+	name    string
+	driver  Driver
+	ctx     Context
+	kernels *kernelRegistry
+	modules map[string]Module
+
+	mu        sync.Mutex
+	functions map[kernelKey]Function
+}
+
+// openCUDADevice creates a context on the device and loads every kernel module into it
+func openCUDADevice(driver Driver, ordinal int, kernels *kernelRegistry) (*cudaDevice, error) {
+	name, err := driver.DeviceName(ordinal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query device %d: %w", ordinal, err)
+	}
+
+	ctx, err := driver.CtxCreate(ordinal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CUDA context on device %d: %w", ordinal, err)
+	}
+
+	modules := make(map[string]Module)
+	for _, file := range kernels.Modules() {
+		ptx, err := kernels.Load(file)
+		if err == nil {
+			modules[file], err = driver.ModuleLoadData(ptx)
+		}
+		if err != nil {
+			driver.CtxDestroy(ctx)
+			return nil, fmt.Errorf("failed to load module %s on device %d: %w", file, ordinal, err)
+		}
+	}
+
+	return &cudaDevice{
+		ordinal:   ordinal,
+		name:      name,
+		driver:    driver,
+		ctx:       ctx,
+		kernels:   kernels,
+		modules:   modules,
+		functions: make(map[kernelKey]Function),
+	}, nil
+}
+
+// bind makes the device context current on the calling OS thread
+func (d *cudaDevice) bind() error {
+	return d.driver.CtxSetCurrent(d.ctx)
+}
+
+// function returns a loaded kernel, looking it up only once
+func (d *cudaDevice) function(key kernelKey) (Function, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if fn, ok := d.functions[key]; ok {
+		return fn, nil
+	}
+	spec, ok := d.kernels.Lookup(key)
+	if !ok {
+		return 0, fmt.Errorf("kernel %s is not registered", key)
+	}
+	fn, err := d.driver.ModuleGetFunction(d.modules[spec.Module], key.Name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find kernel %s: %w", key, err)
+	}
+	d.functions[key] = fn
+	return fn, nil
+}
@@ -0,0 +1,163 @@
+//go:build cuda
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"unsafe"
+
+	"github.com/barnex/cuda5/cu"
+)
+
+// cuDriver implements Driver on top of the real CUDA driver API
+type cuDriver struct{}
+
+// catchCUDA turns the panics raised by the cu package into errors
+func catchCUDA(err *error) {
+	if r := recover(); r != nil {
+		res, ok := r.(cu.Result)
+		if !ok {
+			panic(r)
+		}
+		*err = fmt.Errorf("cuda: %v", res)
+	}
+}
+
+func (cuDriver) Init() (err error) {
+	defer catchCUDA(&err)
+	cu.Init(0)
+	return nil
+}
+
+func (cuDriver) DeviceCount() (n int, err error) {
+	defer catchCUDA(&err)
+	return cu.DeviceGetCount(), nil
+}
+
+func (cuDriver) DeviceName(dev int) (name string, err error) {
+	defer catchCUDA(&err)
+	return cu.Device(dev).Name(), nil
+}
+
+func (cuDriver) CtxCreate(dev int) (ctx Context, err error) {
+	defer catchCUDA(&err)
+	return Context(cu.CtxCreate(0, cu.Device(dev))), nil
+}
+
+func (cuDriver) CtxSetCurrent(ctx Context) (err error) {
+	defer catchCUDA(&err)
+	cu.CtxSetCurrent(cu.Context(ctx))
+	return nil
+}
+
+func (cuDriver) CtxDestroy(ctx Context) (err error) {
+	defer catchCUDA(&err)
+	c := cu.Context(ctx)
+	c.Destroy()
+	return nil
+}
+
+func (cuDriver) MemAlloc(bytes int64) (ptr DevicePtr, err error) {
+	defer catchCUDA(&err)
+	return DevicePtr(cu.MemAlloc(bytes)), nil
+}
+
+func (cuDriver) MemFree(ptr DevicePtr) (err error) {
+	defer catchCUDA(&err)
+	cu.MemFree(cu.DevicePtr(ptr))
+	return nil
+}
+
+func (cuDriver) MemcpyHtoD(dst DevicePtr, src []byte) (err error) {
+	defer catchCUDA(&err)
+	if len(src) > 0 {
+		cu.MemcpyHtoD(cu.DevicePtr(dst), unsafe.Pointer(&src[0]), int64(len(src)))
+	}
+	return nil
+}
+
+func (cuDriver) MemcpyDtoH(dst []byte, src DevicePtr) (err error) {
+	defer catchCUDA(&err)
+	if len(dst) > 0 {
+		cu.MemcpyDtoH(unsafe.Pointer(&dst[0]), cu.DevicePtr(src), int64(len(dst)))
+	}
+	return nil
+}
+
+func (cuDriver) ModuleLoadData(image []byte) (mod Module, err error) {
+	defer catchCUDA(&err)
+	return Module(cu.ModuleLoadData(string(image))), nil
+}
+
+func (cuDriver) ModuleGetFunction(mod Module, name string) (fn Function, err error) {
+	defer catchCUDA(&err)
+	return Function(cu.Module(mod).GetFunction(name)), nil
+}
+
+func (cuDriver) LaunchKernel(fn Function, grid, block Dim3, sharedMemBytes int, args ...any) (err error) {
+	defer catchCUDA(&err)
+	if err := checkKernelArgs(args); err != nil {
+		return err
+	}
+
+	// cu.LaunchKernel copies 8 bytes from every parameter, so each value
+	// gets its own 64-bit slot
+	slots := make([]uint64, len(args))
+	params := make([]unsafe.Pointer, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case DevicePtr:
+			slots[i] = uint64(v)
+		case int32:
+			slots[i] = uint64(uint32(v))
+		case float32:
+			slots[i] = uint64(math.Float32bits(v))
+		}
+		params[i] = unsafe.Pointer(&slots[i])
+	}
+
+	cu.LaunchKernel(
+		cu.Function(fn),
+		grid.X, grid.Y, grid.Z, // Grid dimensions
+		block.X, block.Y, block.Z, // Block dimensions
+		sharedMemBytes, cu.Stream(0), // Shared memory and stream
+		params, // Kernel parameters
+	)
+	return nil
+}
+
+func (cuDriver) Synchronize() (err error) {
+	defer catchCUDA(&err)
+	cu.CtxSynchronize()
+	return nil
+}
+
+// registerGPUBackends adds the CUDA backend to the registry, opening the
+// device context and kernel module up front
+func registerGPUBackends(registry *Registry, maxPerDevice int) error {
+	kernels := newKernelRegistry(embeddedKernelSource, "embedded kernels", kernelSpecs...)
+	// KERNEL_DIR overrides the embedded kernels with PTX files from a directory
+	if dir := os.Getenv("KERNEL_DIR"); dir != "" {
+		kernels = newKernelRegistry(dirKernelSource(dir), "kernel directory "+dir, kernelSpecs...)
+	}
+	if err := kernels.SelfCheck(); err != nil {
+		log.Printf("Kernel self-check failed: %v", err)
+	} else {
+		log.Printf("Kernel self-check passed for %s", kernels.origin)
+	}
+
+	backend := newCUDABackend("cuda", cuDriver{}, kernels, maxPerDevice)
+	_, err := backend.open()
+	gpuAvailable := err == nil
+	fmt.Println("GPU Available:", gpuAvailable)
+
+	if gpuAvailable {
+		fmt.Println("Available GPUs:", backend.Devices())
+	} else {
+		log.Printf("CUDA backend unavailable: %v", err)
+	}
+	return registry.Register(backend, 100)
+}
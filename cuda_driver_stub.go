@@ -0,0 +1,11 @@
+//go:build !cuda
+
+package main
+
+import "log"
+
+// registerGPUBackends is a no-op when built without the cuda tag
+func registerGPUBackends(registry *Registry, maxPerDevice int) error {
+	log.Println("Built without CUDA support, GPU backends disabled")
+	return nil
+}
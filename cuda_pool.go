@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// poolDevice is one device in the pool with its concurrency bookkeeping
+type poolDevice struct {
+	*cudaDevice
+	slots chan struct{}
+
+	// guarded by devicePool.mu
+	inflight  int
+	peak      int
+	completed int
+}
+
+// devicePool schedules jobs over the opened devices, honouring an explicit
+// device choice or picking the least-loaded one, and caps the number of
+// jobs running on each device at once
+type devicePool struct {
+	mu      sync.Mutex
+	devices []*poolDevice
+}
+
+// poolDeviceStats reports the load on one pool device
+type poolDeviceStats struct {
+	Ordinal   int
+	Name      string
+	Inflight  int
+	Peak      int
+	Completed int
+}
+
+// newDevicePool wraps the opened devices; maxPerDevice below 1 means 1
+func newDevicePool(devices []*cudaDevice, maxPerDevice int) *devicePool {
+	if maxPerDevice < 1 {
+		maxPerDevice = 1
+	}
+	pool := &devicePool{}
+	for _, dev := range devices {
+		pool.devices = append(pool.devices, &poolDevice{
+			cudaDevice: dev,
+			slots:      make(chan struct{}, maxPerDevice),
+		})
+	}
+	return pool
+}
+
+// pick chooses the device for a job, counting it as in flight
+func (p *devicePool) pick(requested *int) (*poolDevice, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var chosen *poolDevice
+	if requested != nil {
+		for _, dev := range p.devices {
+			if dev.ordinal == *requested {
+				chosen = dev
+				break
+			}
+		}
+		if chosen == nil {
+			return nil, fmt.Errorf("%w: gpu_id %d is not available, have %d devices", errInvalidRequest, *requested, len(p.devices))
+		}
+	} else {
+		// Ties go to the device that has done the least work so far
+		for _, dev := range p.devices {
+			if chosen == nil || dev.inflight < chosen.inflight ||
+				(dev.inflight == chosen.inflight && dev.completed < chosen.completed) {
+				chosen = dev
+			}
+		}
+		if chosen == nil {
+			return nil, fmt.Errorf("no CUDA device available")
+		}
+	}
+	chosen.inflight++
+	return chosen, nil
+}
+
+// acquire waits for a free slot on the chosen device. The returned release
+// function must be called when the job is done.
+func (p *devicePool) acquire(ctx context.Context, requested *int) (*poolDevice, func(), error) {
+	dev, err := p.pick(requested)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	select {
+	case dev.slots <- struct{}{}:
+	case <-ctx.Done():
+		p.mu.Lock()
+		dev.inflight--
+		p.mu.Unlock()
+		return nil, nil, ctx.Err()
+	}
+
+	p.mu.Lock()
+	dev.peak = max(dev.peak, len(dev.slots))
+	p.mu.Unlock()
+
+	release := func() {
+		<-dev.slots
+		p.mu.Lock()
+		dev.inflight--
+		dev.completed++
+		p.mu.Unlock()
+	}
+	return dev, release, nil
+}
+
+// Stats returns the current load of every device
+func (p *devicePool) Stats() []poolDeviceStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]poolDeviceStats, len(p.devices))
+	for i, dev := range p.devices {
+		stats[i] = poolDeviceStats{
+			Ordinal:   dev.ordinal,
+			Name:      dev.name,
+			Inflight:  dev.inflight,
+			Peak:      dev.peak,
+			Completed: dev.completed,
+		}
+	}
+	return stats
+}
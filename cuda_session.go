@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"runtime"
+)
+
+// deviceImage is a stack of count tightly packed RGBA8 images of the same
+// size, one after another in device memory. Kernels process every image in
+// the stack in a single launch.
+type deviceImage struct {
+	ptr           DevicePtr
+	width, height int
+	count         int
+}
+
+func (img deviceImage) bytes() int64 { return int64(img.width * img.height * 4 * img.count) }
+
+// gpuSession runs the steps of one job on a device. It keeps the calling
+// goroutine on its OS thread with the device context bound, and frees every
+// buffer it allocated when closed. Steps that run long stop once ctx is
+// done.
+type gpuSession struct {
+	ctx     context.Context
+	dev     *cudaDevice
+	buffers []DevicePtr
+}
+
+// newGPUSession binds the device context to the current OS thread; the
+// caller must close the session
+func newGPUSession(ctx context.Context, dev *cudaDevice) (*gpuSession, error) {
+	// Contexts are bound to the calling OS thread
+	runtime.LockOSThread()
+	if err := dev.bind(); err != nil {
+		runtime.UnlockOSThread()
+		return nil, fmt.Errorf("failed to bind CUDA context: %w", err)
+	}
+	return &gpuSession{ctx: ctx, dev: dev}, nil
+}
+
+// close frees the session's buffers and releases the OS thread
+func (s *gpuSession) close() {
+	for _, ptr := range s.buffers {
+		s.dev.driver.MemFree(ptr)
+	}
+	s.buffers = nil
+	runtime.UnlockOSThread()
+}
+
+// alloc allocates device memory owned by the session
+func (s *gpuSession) alloc(bytes int64) (DevicePtr, error) {
+	ptr, err := s.dev.driver.MemAlloc(bytes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate %d bytes: %w", bytes, err)
+	}
+	s.buffers = append(s.buffers, ptr)
+	return ptr, nil
+}
+
+// newImage allocates an uninitialized stack of count device images
+func (s *gpuSession) newImage(width, height, count int) (deviceImage, error) {
+	img := deviceImage{width: width, height: height, count: count}
+	ptr, err := s.alloc(img.bytes())
+	if err != nil {
+		return deviceImage{}, err
+	}
+	img.ptr = ptr
+	return img, nil
+}
+
+// launch runs a kernel over a width x height grid of threads for each of
+// count stacked images
+func (s *gpuSession) launch(key kernelKey, width, height, count int, args ...any) error {
+	fn, err := s.dev.function(key)
+	if err != nil {
+		return err
+	}
+	grid, block := launchGrid(width, height, count)
+	if err := s.dev.driver.LaunchKernel(fn, grid, block, 0, args...); err != nil {
+		return fmt.Errorf("failed to launch %s: %w", key, err)
+	}
+	return nil
+}
+
+// upload copies host images of the same size to a device stack in a
+// single transfer
+func (s *gpuSession) upload(cpuImgs ...*image.NRGBA) (deviceImage, error) {
+	width, height := cpuImgs[0].Bounds().Dx(), cpuImgs[0].Bounds().Dy()
+	img, err := s.newImage(width, height, len(cpuImgs))
+	if err != nil {
+		return deviceImage{}, err
+	}
+
+	pix := cpuImgs[0].Pix
+	if len(cpuImgs) > 1 || cpuImgs[0].Stride != width*4 {
+		pix = make([]byte, 0, img.bytes())
+		for _, cpuImg := range cpuImgs {
+			if cpuImg.Bounds().Dx() != width || cpuImg.Bounds().Dy() != height {
+				return deviceImage{}, fmt.Errorf("cannot stack a %v image with %dx%d images", cpuImg.Bounds().Size(), width, height)
+			}
+			if cpuImg.Stride != width*4 {
+				cpuImg = cloneNRGBA(cpuImg)
+			}
+			pix = append(pix, cpuImg.Pix...)
+		}
+	}
+	if err := s.dev.driver.MemcpyHtoD(img.ptr, pix); err != nil {
+		return deviceImage{}, fmt.Errorf("failed to copy image to device: %w", err)
+	}
+	return img, nil
+}
+
+// download waits for pending kernels and copies a device stack to the
+// host, one image per stacked image
+func (s *gpuSession) download(img deviceImage) ([]*image.NRGBA, error) {
+	if err := s.dev.driver.Synchronize(); err != nil {
+		return nil, fmt.Errorf("failed to synchronize device: %w", err)
+	}
+	pix := make([]byte, img.bytes())
+	if err := s.dev.driver.MemcpyDtoH(pix, img.ptr); err != nil {
+		return nil, fmt.Errorf("failed to copy image from device: %w", err)
+	}
+
+	size := img.width * img.height * 4
+	out := make([]*image.NRGBA, img.count)
+	for i := range out {
+		out[i] = &image.NRGBA{
+			Pix:    pix[i*size : (i+1)*size : (i+1)*size],
+			Stride: img.width * 4,
+			Rect:   image.Rect(0, 0, img.width, img.height),
+		}
+	}
+	return out, nil
+}
+
+// resample scales src to width x height with the separable resample
+// kernels, in premultiplied linear light when linear is set
+func (s *gpuSession) resample(src deviceImage, width, height int, filter Filter, linear bool) (deviceImage, error) {
+	temp, err := s.alloc(int64(width * src.height * 4 * 4 * src.count)) // 4 float32 per pixel
+	if err != nil {
+		return deviceImage{}, err
+	}
+	out, err := s.newImage(width, height, src.count)
+	if err != nil {
+		return deviceImage{}, err
+	}
+
+	// Arguments follow the kernel signatures in cuda/resize_kernel.cu
+	var flag int32
+	if linear {
+		flag = 1
+	}
+	err = s.launch(resampleHorizontalV4, width, src.height, src.count,
+		src.ptr, int32(src.width), int32(src.height),
+		temp, int32(width), int32(filter), flag,
+	)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	err = s.launch(resampleVerticalV4, width, height, src.count,
+		temp, int32(width), int32(src.height),
+		out.ptr, int32(height), int32(filter), flag,
+	)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	return out, nil
+}
+
+// place applies the crop/pad step of a fit layout
+func (s *gpuSession) place(src deviceImage, l fitLayout, background color.NRGBA) (deviceImage, error) {
+	if !l.placed() {
+		return src, nil
+	}
+	out, err := s.newImage(l.Width, l.Height, src.count)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	err = s.launch(placeKernelV2, l.Width, l.Height, src.count,
+		src.ptr, int32(src.width), int32(src.height),
+		out.ptr, int32(l.Width), int32(l.Height),
+		int32(l.Offset.X), int32(l.Offset.Y), int32(packColor(background)),
+	)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	return out, nil
+}
+
+// convolve applies a separable kernel of odd length with the convolve
+// kernels; the weights are copied to the device first
+func (s *gpuSession) convolve(src deviceImage, weights []float32) (deviceImage, error) {
+	w, err := s.alloc(int64(len(weights) * 4))
+	if err != nil {
+		return deviceImage{}, err
+	}
+	wbytes := make([]byte, len(weights)*4)
+	for i, v := range weights {
+		binary.LittleEndian.PutUint32(wbytes[i*4:], math.Float32bits(v))
+	}
+	if err := s.dev.driver.MemcpyHtoD(w, wbytes); err != nil {
+		return deviceImage{}, fmt.Errorf("failed to copy weights to device: %w", err)
+	}
+	temp, err := s.alloc(src.bytes() * 4) // 4 float32 per pixel
+	if err != nil {
+		return deviceImage{}, err
+	}
+	out, err := s.newImage(src.width, src.height, src.count)
+	if err != nil {
+		return deviceImage{}, err
+	}
+
+	// Arguments follow the kernel signatures in cuda/filter_kernel.cu
+	radius := int32(len(weights) / 2)
+	err = s.launch(convolveHorizontalV1, src.width, src.height, src.count,
+		src.ptr, temp, int32(src.width), int32(src.height), w, radius,
+	)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	err = s.launch(convolveVerticalV1, src.width, src.height, src.count,
+		temp, out.ptr, int32(src.width), int32(src.height), w, radius,
+	)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	return out, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// deviceCheckTolerance is the largest per-channel difference allowed
+// between the CPU and device results, which may round floats differently
+const deviceCheckTolerance = 1
+
+// deviceCheckPipelines launch every kernel at least once, so a device that
+// fails to run one or disagrees with the CPU code is caught at startup.
+// They run on a tiny image to keep the check cheap; golden_test.go covers
+// the operations in depth.
+var deviceCheckPipelines = [][]Operation{
+	{ResizeOp{Width: 5, Height: 3, Filter: FilterLanczos3, LinearLight: true}},
+	{ResizeOp{Width: 12, Height: 6, Fit: FitContain, Background: color.NRGBA{R: 255, A: 255}}},
+	{OrientTransverse},
+	{RotateOp{Angle: 30, Background: color.NRGBA{B: 255, A: 255}}},
+	{AdjustOp{Contrast: 0.5, Gamma: 2.2, Hue: 120, Sepia: true}},
+	{UnsharpMaskOp{Sigma: 1, Amount: 1.5, Threshold: 3}},
+	{OverlayOp{Image: checkerboard(color.NRGBA{0, 0, 255, 255}, color.NRGBA{255, 255, 0, 96}), Offset: image.Pt(-3, 2), Opacity: 0.8, Blend: BlendScreen}},
+}
+
+// checkerboard returns an 8x8 image of alternating pixels
+func checkerboard(a, b color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if (x+y)%2 == 0 {
+				img.SetNRGBA(x, y, a)
+			} else {
+				img.SetNRGBA(x, y, b)
+			}
+		}
+	}
+	return img
+}
+
+// deviceCheckImage is a small image of varied colours and transparency
+func deviceCheckImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 36), uint8(y * 36), uint8(255 - x*y*4), uint8(255 - x*16)})
+		}
+	}
+	return img
+}
+
+// checkDevice runs the check pipelines on the device and compares the
+// results with the CPU implementation, so a device whose kernels disagree
+// is never used
+func checkDevice(dev *cudaDevice) error {
+	src := deviceCheckImage()
+	pipelines := make([][]step, len(deviceCheckPipelines))
+	for i, ops := range deviceCheckPipelines {
+		var err error
+		if pipelines[i], _, err = planPipeline(ops, src.Bounds().Size(), nil); err != nil {
+			return err
+		}
+	}
+	gpu, _, err := runBatchGPU(context.Background(), dev, []*image.NRGBA{src}, pipelines)
+	if err != nil {
+		return fmt.Errorf("device check: %w", err)
+	}
+	for i, steps := range pipelines {
+		want, _, _ := runStepsCPU(context.Background(), src, steps)
+		got := gpu[i][0]
+		if want.Rect != got.Rect {
+			return fmt.Errorf("device check: %v gave a %v image, want %v", deviceCheckPipelines[i], got.Rect.Size(), want.Rect.Size())
+		}
+		for p := range want.Pix {
+			if d := int(want.Pix[p]) - int(got.Pix[p]); d > deviceCheckTolerance || d < -deviceCheckTolerance {
+				return fmt.Errorf("device check: %v differs from the CPU by %d at byte %d", deviceCheckPipelines[i], d, p)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import "fmt"
+
+// DevicePtr is an address in device memory
+type DevicePtr uintptr
+
+// Context is a handle to a device context
+type Context uintptr
+
+// Module is a handle to a loaded kernel module
+type Module uintptr
+
+// Function is a handle to a kernel inside a loaded module
+type Function uintptr
+
+// Dim3 holds grid or block dimensions for a kernel launch
+type Dim3 struct {
+	X, Y, Z int
+}
+
+// Driver is the subset of the CUDA driver API used by the GPU pipeline.
+// Kernel arguments are passed as DevicePtr, int32 or float32 values, in the
+// same order as the kernel's C signature.
+type Driver interface {
+	Init() error
+	DeviceCount() (int, error)
+	DeviceName(dev int) (string, error)
+
+	CtxCreate(dev int) (Context, error)
+	CtxSetCurrent(ctx Context) error
+	CtxDestroy(ctx Context) error
+
+	MemAlloc(bytes int64) (DevicePtr, error)
+	MemFree(ptr DevicePtr) error
+	MemcpyHtoD(dst DevicePtr, src []byte) error
+	MemcpyDtoH(dst []byte, src DevicePtr) error
+
+	ModuleLoadData(image []byte) (Module, error)
+	ModuleGetFunction(mod Module, name string) (Function, error)
+	LaunchKernel(fn Function, grid, block Dim3, sharedMemBytes int, args ...any) error
+	Synchronize() error
+}
+
+// checkKernelArgs rejects argument types the drivers cannot marshal
+func checkKernelArgs(args []any) error {
+	for i, arg := range args {
+		switch arg.(type) {
+		case DevicePtr, int32, float32:
+		default:
+			return fmt.Errorf("kernel argument %d has unsupported type %T", i, arg)
+		}
+	}
+	return nil
+}
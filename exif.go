@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// exifHeadBytes is how much of a streamed upload is kept for reading EXIF
+// metadata: room for an APP0 and a full APP1 segment
+const exifHeadBytes = 128 << 10
+
+// exifOrientationTag is the TIFF tag holding the EXIF orientation
+const exifOrientationTag = 0x0112
+
+// jpegOrientation reads the EXIF orientation from the APP1 segment of a
+// JPEG image, which may be truncated after the metadata. Images without a
+// valid orientation report OrientNormal.
+func jpegOrientation(data []byte) Orientation {
+	if len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+		return OrientNormal
+	}
+	data = data[2:]
+	for len(data) >= 4 && data[0] == 0xff {
+		marker := data[1]
+		if marker == 0xff {
+			// A fill byte before the marker
+			data = data[1:]
+			continue
+		}
+		if marker == 0xd8 || marker >= 0xd0 && marker <= 0xd7 || marker == 0x01 {
+			// Standalone markers carry no length
+			data = data[2:]
+			continue
+		}
+		if marker == 0xda || marker == 0xd9 {
+			// The metadata ends where the image data starts
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[2:4]))
+		if length < 2 || len(data) < 2+length {
+			break
+		}
+		segment := data[4 : 2+length]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		data = data[2+length:]
+	}
+	return OrientNormal
+}
+
+// exifOrientation finds the orientation tag in the first IFD of a TIFF
+// structure
+func exifOrientation(tiff []byte) Orientation {
+	if len(tiff) < 8 {
+		return OrientNormal
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return OrientNormal
+	}
+	if order.Uint16(tiff[2:4]) != 42 {
+		return OrientNormal
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return OrientNormal
+	}
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:entry+2]) != exifOrientationTag {
+			continue
+		}
+		// A SHORT value is stored in the first two bytes of the value field
+		if order.Uint16(tiff[entry+2:entry+4]) != 3 {
+			break
+		}
+		o := Orientation(order.Uint16(tiff[entry+8 : entry+10]))
+		if o < OrientNormal || o > OrientRotate270 {
+			break
+		}
+		return o
+	}
+	return OrientNormal
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	pb "github.com/jeauchter/go-image-adjuster/proto"
+)
+
+// exifTIFF returns a TIFF structure in the given byte order whose first
+// IFD holds an unrelated tag and then the orientation tag with type typ
+// and value v
+func exifTIFF(order binary.AppendByteOrder, typ, v uint16) []byte {
+	b := []byte("II")
+	if order == binary.BigEndian {
+		b = []byte("MM")
+	}
+	b = order.AppendUint16(b, 42)
+	b = order.AppendUint32(b, 8)
+	b = order.AppendUint16(b, 2)
+	for _, tag := range [][2]uint16{{0x010f, 2}, {exifOrientationTag, typ}} {
+		b = order.AppendUint16(b, tag[0])
+		b = order.AppendUint16(b, tag[1])
+		b = order.AppendUint32(b, 1)
+		b = order.AppendUint16(b, v)
+		b = order.AppendUint16(b, 0)
+	}
+	return order.AppendUint32(b, 0)
+}
+
+// jpegSegment returns a marker segment holding payload
+func jpegSegment(marker byte, payload []byte) []byte {
+	return append(binary.BigEndian.AppendUint16([]byte{0xff, marker}, uint16(len(payload)+2)), payload...)
+}
+
+// exifSegment returns an APP1 segment holding tiff
+func exifSegment(tiff []byte) []byte {
+	return jpegSegment(0xe1, append([]byte("Exif\x00\x00"), tiff...))
+}
+
+// withSegments returns the JPEG image jpg with segments inserted after
+// its start of image marker
+func withSegments(jpg []byte, segments ...[]byte) []byte {
+	out := []byte{0xff, 0xd8}
+	for _, s := range segments {
+		out = append(out, s...)
+	}
+	return append(out, jpg[2:]...)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	soi := []byte{0xff, 0xd8}
+	app0 := jpegSegment(0xe0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))
+	for _, order := range []binary.AppendByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for o := OrientNormal; o <= OrientRotate270; o++ {
+			data := withSegments(soi, exifSegment(exifTIFF(order, 3, uint16(o))))
+			if got := jpegOrientation(data); got != o {
+				t.Errorf("%v orientation %d: got %d", order, o, got)
+			}
+		}
+	}
+
+	rotated := exifSegment(exifTIFF(binary.BigEndian, 3, 6))
+	noTag := exifTIFF(binary.LittleEndian, 3, 6)
+	binary.LittleEndian.PutUint16(noTag[22:], 0x0110)
+	pastEnd := exifTIFF(binary.BigEndian, 3, 6)
+	binary.BigEndian.PutUint32(pastEnd[4:], uint32(len(pastEnd)))
+	tests := []struct {
+		name string
+		data []byte
+		want Orientation
+	}{
+		{"no metadata", soi, OrientNormal},
+		{"not a JPEG", []byte("\x89PNG\r\n\x1a\n"), OrientNormal},
+		{"after APP0", withSegments(soi, app0, rotated), 6},
+		{"after a standalone marker", withSegments(soi, []byte{0xff, 0xd0}, rotated), 6},
+		{"after fill bytes", withSegments(soi, []byte{0xff, 0xff}, rotated), 6},
+		{"after the image data", withSegments(soi, []byte{0xff, 0xda, 0x00, 0x02}, rotated), OrientNormal},
+		{"missing tag", withSegments(soi, exifSegment(noTag)), OrientNormal},
+		{"LONG tag", withSegments(soi, exifSegment(exifTIFF(binary.BigEndian, 4, 6))), OrientNormal},
+		{"zero", withSegments(soi, exifSegment(exifTIFF(binary.BigEndian, 3, 0))), OrientNormal},
+		{"out of range", withSegments(soi, exifSegment(exifTIFF(binary.BigEndian, 3, 9))), OrientNormal},
+		{"truncated segment", withSegments(soi, rotated[:len(rotated)-4]), OrientNormal},
+		{"truncated IFD", withSegments(soi, exifSegment(exifTIFF(binary.BigEndian, 3, 6)[:24])), OrientNormal},
+		{"IFD past the end", withSegments(soi, exifSegment(pastEnd)), OrientNormal},
+		{"bad byte order", withSegments(soi, exifSegment(append([]byte("XX"), noTag[2:]...))), OrientNormal},
+	}
+	for _, tt := range tests {
+		if got := jpegOrientation(tt.data); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestAutoOrient resizes a 40x20 JPEG stored on its side, which is
+// upright at 20x40 unless auto_orient is false
+func TestAutoOrient(t *testing.T) {
+	jpg := withSegments(encodeTestJPEG(t, 40, 20), exifSegment(exifTIFF(binary.LittleEndian, 3, 6)))
+	r := NewRegistry()
+	if err := r.Register(cpuBackend{}, 0); err != nil {
+		t.Fatal(err)
+	}
+	s := &server{backends: r, policy: PreferGPU, maxUploadBytes: 1 << 20}
+	off := false
+	for _, tt := range []struct {
+		name       string
+		autoOrient *bool
+		want       [2]uint32
+	}{
+		{"by default", nil, [2]uint32{10, 20}},
+		{"turned off", &off, [2]uint32{10, 5}},
+	} {
+		resp, err := s.ResizeImage(context.Background(), &pb.ResizeImageRequest{ImageData: jpg, Width: 10, AutoOrient: tt.autoOrient})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := [2]uint32{resp.Width, resp.Height}; got != tt.want {
+			t.Errorf("%s: resized to %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// A streamed upload keeps only the head of the image for its metadata,
+	// which holds an APP1 segment right after the start but not one behind
+	// other segments filling the head
+	padding := jpegSegment(0xe2, make([]byte, 60000))
+	for _, tt := range []struct {
+		name string
+		data []byte
+		want [2]uint32
+	}{
+		{"streamed", jpg, [2]uint32{10, 20}},
+		{"streamed past the head", withSegments(jpg, padding, padding, padding), [2]uint32{10, 5}},
+	} {
+		stream := &fakeUploadStream{chunks: uploadChunks(&pb.ResizeImageRequest{Width: 10}, tt.data, 0, 4096)}
+		if err := s.ResizeImageStream(stream); err != nil {
+			t.Fatal(err)
+		}
+		if got := [2]uint32{stream.resp.Width, stream.resp.Height}; got != tt.want {
+			t.Errorf("%s: resized to %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
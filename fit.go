@@ -0,0 +1,126 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Fit controls how the source aspect ratio is mapped onto the requested box
+type Fit int
+
+const (
+	FitFill      Fit = iota // Stretch to exactly width x height
+	FitContain              // Scale to fit inside the box and pad with the background
+	FitCover                // Scale to cover the box and crop the overflow
+	FitInside               // Scale to fit inside the box, no padding
+	FitOutside              // Scale to cover the box, no cropping
+	FitSeamCarve            // Scale down to cover the box, then remove or insert seams to fill it
+)
+
+// fitLayout describes how the source is scaled and placed on the output canvas
+type fitLayout struct {
+	ScaledWidth, ScaledHeight int // Size the source is resampled to
+	Width, Height             int // Final output size
+	// Offset maps output coordinates to scaled coordinates: output pixel
+	// (x, y) shows scaled pixel (x+Offset.X, y+Offset.Y), or the background
+	// when that falls outside the scaled image
+	Offset image.Point
+}
+
+// planFit computes the layout for resizing srcWidth x srcHeight into a
+// width x height box. A zero width or height is derived from the aspect
+// ratio, in which case every fit mode scales to the given dimension.
+func planFit(srcWidth, srcHeight, width, height int, fit Fit) fitLayout {
+	if width == 0 || height == 0 {
+		width, height = scaledSize(srcWidth, srcHeight, width, height)
+		return fitLayout{ScaledWidth: width, ScaledHeight: height, Width: width, Height: height}
+	}
+
+	scaleX := float64(width) / float64(srcWidth)
+	scaleY := float64(height) / float64(srcHeight)
+	scaleTo := func(scale float64) (int, int) {
+		return max(1, int(math.Round(float64(srcWidth)*scale))), max(1, int(math.Round(float64(srcHeight)*scale)))
+	}
+
+	var l fitLayout
+	switch fit {
+	case FitContain:
+		l.ScaledWidth, l.ScaledHeight = scaleTo(min(scaleX, scaleY))
+		l.Width, l.Height = width, height
+		l.Offset = image.Pt(-(width-l.ScaledWidth)/2, -(height-l.ScaledHeight)/2)
+	case FitCover:
+		l.ScaledWidth, l.ScaledHeight = scaleTo(max(scaleX, scaleY))
+		l.Width, l.Height = width, height
+		l.Offset = image.Pt((l.ScaledWidth-width)/2, (l.ScaledHeight-height)/2)
+	case FitInside:
+		l.ScaledWidth, l.ScaledHeight = scaleTo(min(scaleX, scaleY))
+		l.Width, l.Height = l.ScaledWidth, l.ScaledHeight
+	case FitOutside:
+		l.ScaledWidth, l.ScaledHeight = scaleTo(max(scaleX, scaleY))
+		l.Width, l.Height = l.ScaledWidth, l.ScaledHeight
+	case FitSeamCarve:
+		// Seams are removed from the overflow and inserted to grow the
+		// image, which is only scaled up, or out of its aspect ratio, where
+		// that would take too many seams
+		l.ScaledWidth, l.ScaledHeight = scaleTo(min(max(scaleX, scaleY), 1))
+		l.ScaledWidth, l.ScaledHeight = carveLength(l.ScaledWidth, width), carveLength(l.ScaledHeight, height)
+		l.Width, l.Height = width, height
+	default:
+		l.ScaledWidth, l.ScaledHeight = width, height
+		l.Width, l.Height = width, height
+	}
+	return l
+}
+
+// placed reports whether the layout needs a crop or padding step after scaling
+func (l fitLayout) placed() bool {
+	return l.Offset != (image.Point{}) || l.Width != l.ScaledWidth || l.Height != l.ScaledHeight
+}
+
+// inside reports whether the output lies within the scaled image, so the
+// layout only crops and never pads
+func (l fitLayout) inside() bool {
+	return l.Offset.X >= 0 && l.Offset.Y >= 0 &&
+		l.Offset.X+l.Width <= l.ScaledWidth && l.Offset.Y+l.Height <= l.ScaledHeight
+}
+
+// packColor packs a colour as 0xRRGGBBAA for kernel arguments
+func packColor(c color.NRGBA) uint32 {
+	return uint32(c.R)<<24 | uint32(c.G)<<16 | uint32(c.B)<<8 | uint32(c.A)
+}
+
+// placePixel computes output pixel (x, y) of a crop/pad step: it copies
+// src pixel (x+dx, y+dy) or writes the packed background colour when that
+// falls outside src. Shared by the CPU backend and the simulated placeKernel.
+func placePixel(src []byte, srcWidth, srcHeight int, dst []byte, dstWidth int, dx, dy int, background uint32, x, y int) {
+	d := (y*dstWidth + x) * 4
+	sx, sy := x+dx, y+dy
+	if sx < 0 || sy < 0 || sx >= srcWidth || sy >= srcHeight {
+		dst[d] = uint8(background >> 24)
+		dst[d+1] = uint8(background >> 16)
+		dst[d+2] = uint8(background >> 8)
+		dst[d+3] = uint8(background)
+		return
+	}
+	s := (sy*srcWidth + sx) * 4
+	copy(dst[d:d+4], src[s:s+4])
+}
+
+// placeNRGBA applies the crop/pad step of a layout on the CPU
+func placeNRGBA(scaled *image.NRGBA, l fitLayout, background color.NRGBA) *image.NRGBA {
+	if !l.placed() {
+		return scaled
+	}
+	if scaled.Stride != scaled.Bounds().Dx()*4 {
+		scaled = cloneNRGBA(scaled)
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, l.Width, l.Height))
+	bg := packColor(background)
+	parallelRows(l.Height, func(y int) {
+		for x := 0; x < l.Width; x++ {
+			placePixel(scaled.Pix, l.ScaledWidth, l.ScaledHeight, out.Pix, l.Width, l.Offset.X, l.Offset.Y, bg, x, y)
+		}
+	})
+	return out
+}
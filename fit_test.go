@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPlanFit(t *testing.T) {
+	tests := []struct {
+		name                      string
+		srcWidth, srcHeight       int
+		width, height             int
+		fit                       Fit
+		scaledWidth, scaledHeight int
+		outWidth, outHeight       int
+		offset                    image.Point
+	}{
+		{"fill", 400, 200, 100, 100, FitFill, 100, 100, 100, 100, image.Point{}},
+		{"contain", 400, 200, 100, 100, FitContain, 100, 50, 100, 100, image.Pt(0, -25)},
+		{"contain tall", 200, 400, 100, 100, FitContain, 50, 100, 100, 100, image.Pt(-25, 0)},
+		{"contain odd padding", 3, 1, 4, 4, FitContain, 4, 1, 4, 4, image.Pt(0, -1)},
+		{"cover", 400, 200, 100, 100, FitCover, 200, 100, 100, 100, image.Pt(50, 0)},
+		{"cover tall", 200, 400, 100, 100, FitCover, 100, 200, 100, 100, image.Pt(0, 50)},
+		{"inside", 400, 200, 100, 100, FitInside, 100, 50, 100, 50, image.Point{}},
+		{"outside", 400, 200, 100, 100, FitOutside, 200, 100, 200, 100, image.Point{}},
+		{"upscale contain", 40, 20, 100, 100, FitContain, 100, 50, 100, 100, image.Pt(0, -25)},
+		{"width only", 400, 200, 100, 0, FitContain, 100, 50, 100, 50, image.Point{}},
+		{"height only", 400, 200, 0, 100, FitCover, 200, 100, 200, 100, image.Point{}},
+		{"neither", 400, 200, 0, 0, FitFill, 400, 200, 400, 200, image.Point{}},
+		{"never empty", 1000, 1, 10, 10, FitContain, 10, 1, 10, 10, image.Pt(0, -4)},
+	}
+	for _, tt := range tests {
+		l := planFit(tt.srcWidth, tt.srcHeight, tt.width, tt.height, tt.fit)
+		want := fitLayout{
+			ScaledWidth: tt.scaledWidth, ScaledHeight: tt.scaledHeight,
+			Width: tt.outWidth, Height: tt.outHeight,
+			Offset: tt.offset,
+		}
+		if l != want {
+			t.Errorf("%s: planFit(%dx%d into %dx%d) = %+v, want %+v", tt.name, tt.srcWidth, tt.srcHeight, tt.width, tt.height, l, want)
+		}
+	}
+}
+
+// TestFitPlacement checks the padding and cropping of fitted images,
+// pixel by pixel, on the CPU and on a simulated device
+func TestFitPlacement(t *testing.T) {
+	bg := color.NRGBA{10, 20, 30, 40}
+	red := color.NRGBA{255, 0, 0, 255}
+	levels := rowImage(0, 80, 160, 240)
+
+	tests := []struct {
+		name   string
+		src    *image.NRGBA
+		width  int
+		height int
+		fit    Fit
+		want   [][]color.NRGBA
+	}{
+		{
+			// Scaled to 4x1 and centred, with the odd row of padding below
+			name: "contain", src: uniformImage(3, 1, red), width: 4, height: 4, fit: FitContain,
+			want: [][]color.NRGBA{
+				{bg, bg, bg, bg},
+				{red, red, red, red},
+				{bg, bg, bg, bg},
+				{bg, bg, bg, bg},
+			},
+		},
+		{
+			// Already the right height, so only the middle columns are kept
+			name: "cover", src: levels, width: 2, height: 1, fit: FitCover,
+			want: [][]color.NRGBA{{grey(80), grey(160)}},
+		},
+		{
+			name: "inside", src: levels, width: 2, height: 4, fit: FitInside,
+			want: [][]color.NRGBA{{grey(80), grey(240)}},
+		},
+	}
+
+	dev := openSimDevice(t, newSimDriver("Simulated GPU"))
+	for _, tt := range tests {
+		l := planFit(tt.src.Rect.Dx(), tt.src.Rect.Dy(), tt.width, tt.height, tt.fit)
+		want := image.NewNRGBA(image.Rect(0, 0, len(tt.want[0]), len(tt.want)))
+		for y, row := range tt.want {
+			for x, c := range row {
+				want.SetNRGBA(x, y, c)
+			}
+		}
+
+		cpu := placeNRGBA(resampleNRGBA(tt.src, l.ScaledWidth, l.ScaledHeight, FilterNearest, false), l, bg)
+		if err := diffImages(want, cpu, 0); err != nil {
+			t.Errorf("%s on the CPU: %v", tt.name, err)
+		}
+		gpu, _, err := runBatchGPU(context.Background(), dev, []*image.NRGBA{tt.src}, [][]step{{
+			resampleStep{Width: l.ScaledWidth, Height: l.ScaledHeight, Filter: FilterNearest},
+			placeStep{Layout: l, Background: bg},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := diffImages(want, gpu[0][0], 0); err != nil {
+			t.Errorf("%s on the simulated device: %v", tt.name, err)
+		}
+	}
+}
+
+// uniformImage returns a w x h image filled with c
+func uniformImage(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// grey returns an opaque grey level
+func grey(v uint8) color.NRGBA { return color.NRGBA{v, v, v, 255} }
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"image"
+	"math"
+)
+
+// Focus marks what crops should keep in frame, in fractions (0-1) of the
+// width and height of the upright source image
+type Focus struct {
+	Point   *FocalPoint   // Centred by cover and gravity crops where the image allows, nil for none
+	Regions []FocusRegion // Kept whole by cover and gravity crops where they fit
+}
+
+// FocalPoint is a point of a Focus
+type FocalPoint struct {
+	X, Y float64
+}
+
+// FocusRegion is a region of interest of a Focus, such as a face
+type FocusRegion struct {
+	X, Y, Width, Height float64
+}
+
+// FocusLoss reports the parts of a focus that an output could not keep in
+// frame
+type FocusLoss struct {
+	Point   bool  // The focal point is outside the output
+	Regions []int // Indices of the regions not wholly inside the output
+}
+
+// pixels returns the focus in pixels of a stored image of the given size,
+// which the orientation turns upright, or nil when the focus is empty
+func (f Focus) pixels(size image.Point, o Orientation) *focus {
+	if f.Point == nil && len(f.Regions) == 0 {
+		return nil
+	}
+	upright := o.outputSize(size)
+	w, h := float64(upright.X), float64(upright.Y)
+	pf := &focus{}
+	if f.Point != nil {
+		p := [2]float64{f.Point.X * w, f.Point.Y * h}
+		pf.Point = &focusRect{Min: p, Max: p}
+	}
+	for _, r := range f.Regions {
+		pf.Regions = append(pf.Regions, focusRect{
+			Min: [2]float64{r.X * w, r.Y * h},
+			Max: [2]float64{(r.X + r.Width) * w, (r.Y + r.Height) * h},
+		})
+	}
+	// Pipelines start with the orientation, so map the focus back onto the
+	// stored image
+	return orientStep{Orientation: o.inverse()}.mapFocus(upright, pf)
+}
+
+// focusRect is a rectangle in pixels, indexed by axis: 0 for x, 1 for y.
+// A focal point is an empty focusRect.
+type focusRect struct {
+	Min, Max [2]float64
+}
+
+// focus is a Focus in pixels of the image at some point of a pipeline
+type focus struct {
+	Point   *focusRect
+	Regions []focusRect
+	Cut     bool // A step distorted the regions, wherever they are now
+}
+
+// mapRects returns the focus with fn applied to every rectangle
+func (f *focus) mapRects(fn func(r focusRect) focusRect) *focus {
+	out := &focus{Regions: make([]focusRect, len(f.Regions)), Cut: f.Cut}
+	if f.Point != nil {
+		p := fn(*f.Point)
+		out.Point = &p
+	}
+	for i, r := range f.Regions {
+		out.Regions[i] = fn(r)
+	}
+	return out
+}
+
+// place moves the top-left corner of a window into an image of the given
+// size so the window keeps every region whole if they fit together, and
+// otherwise is centred on them, then centres the focal point as far as
+// that allows. A nil focus keeps the corner.
+func (f *focus) place(corner, window, size image.Point) image.Point {
+	if f == nil {
+		return corner
+	}
+	offset := [2]int{corner.X, corner.Y}
+	windows, lengths := [2]int{window.X, window.Y}, [2]int{size.X, size.Y}
+	for a := range offset {
+		lo, hi := 0, lengths[a]-windows[a]
+		if len(f.Regions) > 0 {
+			start, end := math.Inf(1), math.Inf(-1)
+			for _, r := range f.Regions {
+				start, end = min(start, r.Min[a]), max(end, r.Max[a])
+			}
+			if end-start <= float64(windows[a]) {
+				lo = max(lo, int(math.Round(end))-windows[a])
+				hi = min(hi, int(math.Round(start)))
+			} else {
+				// Too far apart to keep whole, so centre on them
+				centred := int(math.Round((start + end - float64(windows[a])) / 2))
+				lo, hi = centred, centred
+			}
+		}
+		if f.Point != nil {
+			offset[a] = int(math.Round(f.Point.Min[a] - float64(windows[a])/2))
+		}
+		offset[a] = min(max(offset[a], lo), hi)
+		offset[a] = min(max(offset[a], 0), lengths[a]-windows[a])
+	}
+	return image.Pt(offset[0], offset[1])
+}
+
+// loss reports the parts of the focus outside an output of the given size
+func (f *focus) loss(size image.Point) FocusLoss {
+	var loss FocusLoss
+	if f == nil {
+		return loss
+	}
+	// Crops cut between pixels, so a region only needs to be kept to the
+	// nearest one
+	const slack = 0.5
+	inside := func(r focusRect) bool {
+		return r.Min[0] >= -slack && r.Min[1] >= -slack &&
+			r.Max[0] <= float64(size.X)+slack && r.Max[1] <= float64(size.Y)+slack
+	}
+	loss.Point = f.Point != nil && !inside(*f.Point)
+	for i, r := range f.Regions {
+		if f.Cut || !inside(r) {
+			loss.Regions = append(loss.Regions, i)
+		}
+	}
+	return loss
+}
+
+// focusMapper is a step that moves or scales the image, which the focus
+// must follow. Steps that keep every pixel in place do not implement it.
+type focusMapper interface {
+	// mapFocus returns f, given for an input of size in, in pixels of the
+	// step's output
+	mapFocus(in image.Point, f *focus) *focus
+}
+
+func (st resampleStep) mapFocus(in image.Point, f *focus) *focus {
+	scale := [2]float64{float64(st.Width) / float64(in.X), float64(st.Height) / float64(in.Y)}
+	return f.mapRects(func(r focusRect) focusRect {
+		for a := range scale {
+			r.Min[a] *= scale[a]
+			r.Max[a] *= scale[a]
+		}
+		return r
+	})
+}
+
+func (st placeStep) mapFocus(in image.Point, f *focus) *focus {
+	offset := [2]float64{float64(st.Layout.Offset.X), float64(st.Layout.Offset.Y)}
+	return f.mapRects(func(r focusRect) focusRect {
+		for a := range offset {
+			r.Min[a] -= offset[a]
+			r.Max[a] -= offset[a]
+		}
+		return r
+	})
+}
+
+func (st orientStep) mapFocus(in image.Point, f *focus) *focus {
+	swap, flipX, flipY := st.Orientation.transform()
+	flip := [2]bool{flipX, flipY}
+	return f.mapRects(func(r focusRect) focusRect {
+		// Undo the flips, which apply to the input, then swap the axes
+		length := [2]float64{float64(in.X), float64(in.Y)}
+		for a := range flip {
+			if flip[a] {
+				r.Min[a], r.Max[a] = length[a]-r.Max[a], length[a]-r.Min[a]
+			}
+		}
+		if swap {
+			r.Min[0], r.Min[1] = r.Min[1], r.Min[0]
+			r.Max[0], r.Max[1] = r.Max[1], r.Max[0]
+		}
+		return r
+	})
+}
+
+// mapFocus turns each rectangle with the image and takes the bounds of its
+// corners, so rotated regions stay covered
+func (st rotateStep) mapFocus(in image.Point, f *focus) *focus {
+	cos, sin := float64(st.Cos), float64(st.Sin)
+	return f.mapRects(func(r focusRect) focusRect {
+		out := focusRect{
+			Min: [2]float64{math.Inf(1), math.Inf(1)},
+			Max: [2]float64{math.Inf(-1), math.Inf(-1)},
+		}
+		for _, u := range []float64{r.Min[0], r.Max[0]} {
+			for _, v := range []float64{r.Min[1], r.Max[1]} {
+				du, dv := u-float64(in.X)/2, v-float64(in.Y)/2
+				p := [2]float64{
+					cos*du - sin*dv + float64(st.Width)/2,
+					sin*du + cos*dv + float64(st.Height)/2,
+				}
+				for a := range p {
+					out.Min[a], out.Max[a] = min(out.Min[a], p[a]), max(out.Max[a], p[a])
+				}
+			}
+		}
+		return out
+	})
+}
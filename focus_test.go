@@ -0,0 +1,88 @@
+package main
+
+import (
+	"image"
+	"math"
+	"slices"
+	"testing"
+)
+
+// TestFocusPlace moves a centred 10x10 window in a 40x20 image to keep
+// each focus in frame
+func TestFocusPlace(t *testing.T) {
+	point := func(x, y float64) *focusRect { return &focusRect{Min: [2]float64{x, y}, Max: [2]float64{x, y}} }
+	rect := func(x0, y0, x1, y1 float64) focusRect {
+		return focusRect{Min: [2]float64{x0, y0}, Max: [2]float64{x1, y1}}
+	}
+	tests := []struct {
+		name  string
+		focus *focus
+		want  image.Point
+	}{
+		{"none", nil, image.Pt(15, 5)},
+		{"point near the edge", &focus{Point: point(35, 10)}, image.Pt(30, 5)},
+		{"point in the corner", &focus{Point: point(2, 2)}, image.Pt(0, 0)},
+		{"region", &focus{Regions: []focusRect{rect(20, 0, 28, 5)}}, image.Pt(18, 0)},
+		// Too far apart to fit, so the window is centred between them
+		{"regions apart", &focus{Regions: []focusRect{rect(0, 0, 4, 5), rect(30, 0, 36, 5)}}, image.Pt(13, 0)},
+		// The point is centred only as far as the region stays whole
+		{"region and point", &focus{Point: point(35, 10), Regions: []focusRect{rect(20, 0, 28, 5)}}, image.Pt(20, 0)},
+	}
+	for _, tt := range tests {
+		if got := tt.focus.place(image.Pt(15, 5), image.Pt(10, 10), image.Pt(40, 20)); got != tt.want {
+			t.Errorf("%s: placed at %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestFocusLoss crops a 40x20 image too small to keep two regions whole
+// and checks that both are reported while the focal point is kept
+func TestFocusLoss(t *testing.T) {
+	size := image.Pt(40, 20)
+	f := Focus{
+		Point:   &FocalPoint{X: 0.5, Y: 0.5},
+		Regions: []FocusRegion{{X: 0, Y: 0, Width: 0.1, Height: 0.25}, {X: 0.75, Y: 0, Width: 0.15, Height: 0.25}},
+	}
+	ops := []Operation{CropGravityOp{Width: 10, Height: 10}}
+	_, loss, err := planPipeline(ops, size, f.pixels(size, OrientNormal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loss.Point || !slices.Equal(loss.Regions, []int{0, 1}) {
+		t.Errorf("lost %+v, want regions 0 and 1 and not the point", loss)
+	}
+
+	// A crop holding everything loses nothing
+	ops = []Operation{CropGravityOp{Width: 40, Height: 10}}
+	if _, loss, err = planPipeline(ops, size, f.pixels(size, OrientNormal)); err != nil {
+		t.Fatal(err)
+	}
+	if loss.Point || len(loss.Regions) != 0 {
+		t.Errorf("lost %+v from a crop holding the whole focus", loss)
+	}
+}
+
+// TestFocusFollowsOrientation checks that a focus given for the upright
+// image lands back on the same pixels when the stored image is turned
+// upright
+func TestFocusFollowsOrientation(t *testing.T) {
+	stored := image.Pt(20, 10)
+	f := Focus{Point: &FocalPoint{X: 0.1, Y: 0.3}, Regions: []FocusRegion{{X: 0.2, Y: 0.5, Width: 0.5, Height: 0.25}}}
+	for o := OrientNormal; o <= OrientRotate270; o++ {
+		upright := o.outputSize(stored)
+		want := f.pixels(upright, OrientNormal)
+		got := orientStep{Orientation: o}.mapFocus(stored, f.pixels(stored, o))
+		if !nearRect(*got.Point, *want.Point) || !nearRect(got.Regions[0], want.Regions[0]) {
+			t.Errorf("orientation %d: focus %+v, want %+v", o, got, want)
+		}
+	}
+}
+
+func nearRect(a, b focusRect) bool {
+	for i := range a.Min {
+		if math.Abs(a.Min[i]-b.Min[i]) > 1e-9 || math.Abs(a.Max[i]-b.Max[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
@@ -3,8 +3,9 @@ module github.com/jeauchter/go-image-adjuster
 go 1.23
 
 require (
-	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
+	github.com/HugoSmits86/nativewebp v0.9.3
 	gocv.io/x/gocv v0.40.0
+	golang.org/x/image v0.23.0
 	google.golang.org/grpc v1.71.0
 	google.golang.org/protobuf v1.36.4
 )
@@ -0,0 +1,39 @@
+//go:build cuda
+
+package main
+
+import "testing"
+
+// openHardwareDevice opens the first CUDA device with the embedded
+// kernels, skipping the test on machines without one
+func openHardwareDevice(t *testing.T) *cudaDevice {
+	t.Helper()
+	d := cuDriver{}
+	if err := d.Init(); err != nil {
+		t.Skipf("no CUDA driver: %v", err)
+	}
+	if n, err := d.DeviceCount(); err != nil || n == 0 {
+		t.Skip("no CUDA device")
+	}
+	kernels := newKernelRegistry(embeddedKernelSource, "embedded kernels", kernelSpecs...)
+	dev, err := openCUDADevice(d, 0, kernels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.CtxDestroy(dev.ctx) })
+	return dev
+}
+
+// The golden suite again, against the compiled PTX on real hardware
+
+func TestGoldenPipelinesOnHardware(t *testing.T) {
+	checkGoldenPipelines(t, openHardwareDevice(t))
+}
+
+func TestAdjustValuesOnHardware(t *testing.T) {
+	checkAdjustValues(t, openHardwareDevice(t))
+}
+
+func TestGoldenReferencesOnHardware(t *testing.T) {
+	checkGoldenReferences(t, openHardwareDevice(t))
+}
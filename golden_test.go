@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"slices"
+	"testing"
+)
+
+// goldenTolerance is the largest per-channel difference allowed between the
+// CPU and GPU results, which may round floats differently
+const goldenTolerance = 1
+
+// goldenPipelines are run on the golden image by both implementations
+var goldenPipelines = [][]Operation{
+	{AdjustOp{Brightness: 0.2}},
+	{AdjustOp{Brightness: -0.3}},
+	{AdjustOp{Contrast: 0.5}},
+	{AdjustOp{Contrast: -0.5}},
+	{AdjustOp{Gamma: 2.2}},
+	{AdjustOp{Gamma: 0.45}},
+	{AdjustOp{Saturation: 0.8}},
+	{AdjustOp{Saturation: -1}},
+	{AdjustOp{Hue: 120}},
+	{AdjustOp{Grayscale: true}},
+	{AdjustOp{Sepia: true}},
+	{AdjustOp{Brightness: 0.1, Contrast: 0.2, Gamma: 1.2, Saturation: 0.3, Hue: -45, Sepia: true}},
+	{ResizeOp{Width: 13, Height: 7, Filter: FilterLanczos3}},
+	{RotateOp{Angle: 30, Background: color.NRGBA{R: 255, A: 255}}},
+	{OrientTransverse},
+	{BlurOp{Sigma: 1.5}},
+	{BoxBlurOp{Radius: 2}},
+	{UnsharpMaskOp{Sigma: 1, Amount: 1.5, Threshold: 3}},
+	{ResizeOp{Width: 11, Height: 6, Filter: FilterLanczos3, AutoSharpen: true}},
+	{ResizeOp{Width: 13, Height: 7, Filter: FilterLanczos3, LinearLight: true}},
+	{ResizeOp{Width: 48, Height: 24, Filter: FilterMitchell, LinearLight: true}},
+	{OverlayOp{Image: checkerboard(color.NRGBA{0, 0, 255, 255}, color.NRGBA{255, 255, 0, 96}), Gravity: GravitySouthEast, Offset: image.Pt(3, 2), Opacity: 0.8}},
+	{OverlayOp{Image: checkerboard(color.NRGBA{40, 200, 90, 255}, color.NRGBA{}), Scale: 0.5, Opacity: 1, Blend: BlendMultiply}},
+	{OverlayOp{Image: checkerboard(color.NRGBA{200, 40, 90, 160}, color.NRGBA{20, 20, 20, 255}), Gravity: GravityNorthWest, Offset: image.Pt(-5, 3), Opacity: 0.6, Tile: true, Blend: BlendScreen}},
+	{CropGravityOp{Width: 12, Height: 10, Gravity: GravitySmart}},
+	{ResizeOp{Width: 24, Height: 20, Fit: FitSeamCarve}},
+	{TextOp{Text: "Ag", Font: builtinFonts["bold"], Size: 12, Color: color.NRGBA{255, 255, 255, 255}, Stroke: 1, StrokeColor: color.NRGBA{A: 255}, Angle: 15}},
+}
+
+// goldenReference is a known problem image and a check of the correct
+// result of a pipeline on it, which both implementations must pass
+type goldenReference struct {
+	name  string
+	image func() *image.NRGBA
+	ops   []Operation
+	check func(x, y int, c color.NRGBA) bool
+}
+
+// goldenReferences catch resampling that works on sRGB values or
+// unpremultiplied alpha, and a smart crop that misses the detail
+var goldenReferences = []goldenReference{
+	{
+		// Black and white average to half the light, which is 188 in sRGB;
+		// averaging the sRGB values gives a too dark 128
+		name:  "checkerboard",
+		image: func() *image.NRGBA { return checkerboard(color.NRGBA{A: 255}, color.NRGBA{255, 255, 255, 255}) },
+		ops:   []Operation{ResizeOp{Width: 4, Height: 4, Filter: FilterBox, LinearLight: true}},
+		check: func(x, y int, c color.NRGBA) bool { return c == color.NRGBA{188, 188, 188, 255} },
+	},
+	{
+		// Transparent pixels carry no colour, so white stays white instead of
+		// getting a dark halo from the transparent black
+		name:  "transparent checkerboard",
+		image: func() *image.NRGBA { return checkerboard(color.NRGBA{}, color.NRGBA{255, 255, 255, 255}) },
+		ops:   []Operation{ResizeOp{Width: 4, Height: 4, Filter: FilterBox, LinearLight: true}},
+		check: func(x, y int, c color.NRGBA) bool { return c == color.NRGBA{255, 255, 255, 128} },
+	},
+	{
+		// Black stripes between a ramp of greys: each pair averages to half
+		// the light of its grey, not to half its sRGB level
+		name: "grey gradient",
+		image: func() *image.NRGBA {
+			img := image.NewNRGBA(image.Rect(0, 0, 128, 2))
+			for x := 1; x < 128; x += 2 {
+				v := uint8(2 * x)
+				img.SetNRGBA(x, 0, color.NRGBA{v, v, v, 255})
+				img.SetNRGBA(x, 1, color.NRGBA{v, v, v, 255})
+				img.SetNRGBA(x-1, 0, color.NRGBA{A: 255})
+				img.SetNRGBA(x-1, 1, color.NRGBA{A: 255})
+			}
+			return img
+		},
+		ops: []Operation{ResizeOp{Width: 64, Height: 1, Filter: FilterBox, LinearLight: true}},
+		check: func(x, y int, c color.NRGBA) bool {
+			want := int(clampByte(linearToSRGB(srgbToLinear[2*(2*x+1)]/2) * 255))
+			d := int(c.R) - want
+			return c.R == c.G && c.G == c.B && d >= -goldenTolerance && d <= goldenTolerance && c.A == 255
+		},
+	},
+	{
+		// A colour fading out between transparent stripes keeps its hue,
+		// even through a filter with negative lobes, instead of darkening
+		// towards the transparent black
+		name: "alpha gradient",
+		image: func() *image.NRGBA {
+			img := image.NewNRGBA(image.Rect(0, 0, 64, 4))
+			for y := 0; y < 4; y++ {
+				for x := 0; x < 64; x += 2 {
+					img.SetNRGBA(x, y, color.NRGBA{R: 255, G: 64, A: uint8(32 + x*3)})
+				}
+			}
+			return img
+		},
+		ops: []Operation{ResizeOp{Width: 24, Height: 2, Filter: FilterLanczos3, LinearLight: true}},
+		check: func(x, y int, c color.NRGBA) bool {
+			return c.R >= 254 && c.G >= 63 && c.G <= 65 && c.B == 0 && c.A > 0
+		},
+	},
+	{
+		// A square holding every luma level equally often, off-centre in a
+		// flat grey image, is all that is left, with no grey at its sides
+		name: "smart crop",
+		image: func() *image.NRGBA {
+			img := image.NewNRGBA(image.Rect(0, 0, 48, 16))
+			draw.Draw(img, img.Rect, image.NewUniform(color.NRGBA{128, 128, 128, 255}), image.Point{}, draw.Src)
+			for y := 0; y < 16; y++ {
+				for x := 0; x < 16; x++ {
+					v := uint8((x*5+y*3)%16*16 + 8)
+					img.SetNRGBA(27+x, y, color.NRGBA{v, v, v, 255})
+				}
+			}
+			return img
+		},
+		ops: []Operation{CropGravityOp{Width: 16, Height: 16, Gravity: GravitySmart}},
+		check: func(x, y int, c color.NRGBA) bool {
+			return c != color.NRGBA{128, 128, 128, 255}
+		},
+	},
+}
+
+// goldenImage is a small image covering the hue circle, greys and partial
+// transparency
+func goldenImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 32, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 32; x++ {
+			// Hue along x, fading to grey and darkening along y
+			h := float64(x) / 32 * 6
+			sector, f := int(h), h-float64(int(h))
+			rgb := [6][3]float64{{1, f, 0}, {1 - f, 1, 0}, {0, 1, f}, {0, 1 - f, 1}, {f, 0, 1}, {1, 0, 1 - f}}[sector]
+			grey, value := float64(y%8)/8, 1-float64(y/8)*0.5
+			c := make([]uint8, 3)
+			for i := range c {
+				c[i] = uint8(255 * value * (rgb[i]*(1-grey) + 0.5*grey))
+			}
+			img.SetNRGBA(x, y, color.NRGBA{R: c[0], G: c[1], B: c[2], A: uint8(255 - x*4)})
+		}
+	}
+	return img
+}
+
+// runBothImplementations runs ops on src on the CPU and on dev, and checks that their smart crops chose the same windows
+func runBothImplementations(t *testing.T, dev *cudaDevice, src *image.NRGBA, ops []Operation) (cpu, gpu *image.NRGBA) {
+	t.Helper()
+	steps, _, err := planPipeline(ops, src.Bounds().Size(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, crops, err := runBatchGPU(context.Background(), dev, []*image.NRGBA{src}, [][]step{steps})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu, cpuCrops, err := runStepsCPU(context.Background(), src, steps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cpuCrops, crops[0][0]) {
+		t.Errorf("%v: smart crops chose %v on the GPU, want %v", ops, crops[0][0], cpuCrops)
+	}
+	return cpu, out[0][0]
+}
+
+// adjustValues are single colours and their adjustments worked out by hand
+// from the CSS filter definitions, independently of adjustPixel
+var adjustValues = []struct {
+	op      AdjustOp
+	in, out color.NRGBA
+}{
+	{AdjustOp{Brightness: 0.2}, color.NRGBA{100, 0, 250, 77}, color.NRGBA{151, 51, 255, 77}},
+	{AdjustOp{Brightness: -0.3}, color.NRGBA{100, 200, 250, 255}, color.NRGBA{24, 124, 174, 255}},
+	{AdjustOp{Contrast: 0.5}, color.NRGBA{200, 128, 55, 255}, color.NRGBA{236, 128, 19, 255}},
+	{AdjustOp{Contrast: -1}, color.NRGBA{200, 10, 55, 255}, color.NRGBA{128, 128, 128, 255}},
+	{AdjustOp{Gamma: 2.2}, color.NRGBA{128, 0, 255, 255}, color.NRGBA{uint8(math.Round(255 * math.Pow(128.0/255, 1/2.2))), 0, 255, 255}},
+	{AdjustOp{Grayscale: true}, color.NRGBA{255, 0, 0, 255}, color.NRGBA{54, 54, 54, 255}},
+	{AdjustOp{Saturation: -1}, color.NRGBA{0, 255, 0, 255}, color.NRGBA{182, 182, 182, 255}},
+	// saturate(0.5): rows (0.6065, 0.3575, 0.036), (0.1065, 0.8575, 0.036)
+	// and (0.1065, 0.3575, 0.536)
+	{AdjustOp{Saturation: -0.5}, color.NRGBA{200, 100, 50, 255}, color.NRGBA{159, 109, 84, 255}},
+	// hue-rotate(180deg): rows (-0.574, 1.43, 0.144), (0.426, 0.43, 0.144)
+	// and (0.426, 1.43, -0.856)
+	{AdjustOp{Hue: 180}, color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 109, 109, 255}},
+	{AdjustOp{Hue: 360}, color.NRGBA{10, 200, 30, 255}, color.NRGBA{10, 200, 30, 255}},
+	{AdjustOp{Sepia: true}, color.NRGBA{255, 255, 255, 255}, color.NRGBA{255, 255, 239, 255}},
+}
+
+// The golden suite runs against the simulated device here, and against a
+// real one in golden_cuda_test.go. The simulated kernels share their
+// pixel code with the CPU backend, so on the simulator only the checks
+// against hand-worked values and references test the maths; on hardware
+// every check tests the kernels in cuda/.
+
+func TestGoldenPipelines(t *testing.T) {
+	checkGoldenPipelines(t, openSimDevice(t, newSimDriver("Simulated GPU")))
+}
+
+func TestAdjustValues(t *testing.T) {
+	checkAdjustValues(t, openSimDevice(t, newSimDriver("Simulated GPU")))
+}
+
+func TestGoldenReferences(t *testing.T) {
+	checkGoldenReferences(t, openSimDevice(t, newSimDriver("Simulated GPU")))
+}
+
+// checkGoldenPipelines checks that the GPU steps, run on dev, match the
+// CPU implementation
+func checkGoldenPipelines(t *testing.T, dev *cudaDevice) {
+	src := goldenImage()
+	for _, ops := range goldenPipelines {
+		t.Run(fmt.Sprintf("%T", ops[0]), func(t *testing.T) {
+			cpu, gpu := runBothImplementations(t, dev, src, ops)
+			if err := diffImages(cpu, gpu, goldenTolerance); err != nil {
+				t.Errorf("%v: %v", ops, err)
+			}
+		})
+	}
+}
+
+// checkAdjustValues checks both implementations against adjustValues
+func checkAdjustValues(t *testing.T, dev *cudaDevice) {
+	for _, tt := range adjustValues {
+		src := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+		src.SetNRGBA(0, 0, tt.in)
+		want := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+		want.SetNRGBA(0, 0, tt.out)
+		cpu, gpu := runBothImplementations(t, dev, src, []Operation{tt.op})
+		for impl, got := range map[string]*image.NRGBA{"CPU": cpu, "device": gpu} {
+			if err := diffImages(want, got, goldenTolerance); err != nil {
+				t.Errorf("%+v of %v on the %s: %v", tt.op, tt.in, impl, err)
+			}
+		}
+	}
+}
+
+// TestDeviceCheckCoversKernels checks that the startup check launches every
+// registered kernel and passes on a simulated device
+func TestDeviceCheckCoversKernels(t *testing.T) {
+	d := newSimDriver("Simulated GPU")
+	d.record = true
+	if err := checkDevice(openSimDevice(t, d)); err != nil {
+		t.Fatal(err)
+	}
+	launched := make(map[string]bool)
+	for _, l := range d.Launches {
+		launched[l.Kernel] = true
+	}
+	for _, spec := range kernelSpecs {
+		if !launched[spec.Name] {
+			t.Errorf("the device check never launches %s", spec.Name)
+		}
+	}
+}
+
+// checkGoldenReferences checks both implementations against the known
+// results for the reference images
+func checkGoldenReferences(t *testing.T, dev *cudaDevice) {
+	for _, ref := range goldenReferences {
+		t.Run(ref.name, func(t *testing.T) {
+			cpu, gpu := runBothImplementations(t, dev, ref.image(), ref.ops)
+			for impl, img := range map[string]*image.NRGBA{"CPU": cpu, "device": gpu} {
+				if err := ref.verify(img); err != nil {
+					t.Errorf("%s: %v", impl, err)
+				}
+			}
+		})
+	}
+}
+
+// TestGoldenReferencesCatchNaiveResampling checks that the resampling
+// references fail when resampling works on sRGB values and unpremultiplied
+// alpha, as it does without LinearLight
+func TestGoldenReferencesCatchNaiveResampling(t *testing.T) {
+	for _, ref := range goldenReferences {
+		resize, ok := ref.ops[0].(ResizeOp)
+		if !ok || !resize.LinearLight {
+			continue
+		}
+		resize.LinearLight = false
+		src := ref.image()
+		steps, _, err := planPipeline([]Operation{resize}, src.Bounds().Size(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out, _, _ := runStepsCPU(context.Background(), src, steps); ref.verify(out) == nil {
+			t.Errorf("%s passes without linear light", ref.name)
+		}
+	}
+}
+
+// verify checks every pixel of a result
+func (ref goldenReference) verify(img *image.NRGBA) error {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if c := img.NRGBAAt(x, y); !ref.check(x-b.Min.X, y-b.Min.Y, c) {
+				return fmt.Errorf("unexpected %v at (%d, %d)", c, x, y)
+			}
+		}
+	}
+	return nil
+}
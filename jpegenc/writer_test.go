@@ -0,0 +1,103 @@
+package jpegenc
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// testImage returns a w x h image with smooth colour gradients
+func testImage(w, h int) *image.RGBA {
+	m := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			m.Set(x, y, color.RGBA{uint8(x * 255 / w), uint8(y * 255 / h), uint8(128 + (x-y)*64/w), 255})
+		}
+	}
+	return m
+}
+
+// averageDelta returns the average difference between the channels of two images
+func averageDelta(m0, m1 image.Image) int64 {
+	b := m0.Bounds()
+	var sum, n int64
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r0, g0, b0, _ := m0.At(x, y).RGBA()
+			r1, g1, b1, _ := m1.At(x, y).RGBA()
+			for _, d := range []int64{int64(r0) - int64(r1), int64(g0) - int64(g1), int64(b0) - int64(b1)} {
+				sum += max(d, -d) >> 8
+				n++
+			}
+		}
+	}
+	return sum / n
+}
+
+// TestSubsampling encodes with every subsampling ratio and decodes the
+// result with the standard library, which reports the ratio it read from
+// the frame header
+func TestSubsampling(t *testing.T) {
+	tests := []struct {
+		subsampling Subsampling
+		want        image.YCbCrSubsampleRatio
+	}{
+		{Subsample420, image.YCbCrSubsampleRatio420},
+		{Subsample422, image.YCbCrSubsampleRatio422},
+		{Subsample444, image.YCbCrSubsampleRatio444},
+	}
+	src := testImage(37, 29) // Not a multiple of the block size
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Encode(&buf, src, &Options{Quality: 90, Subsampling: tt.subsampling}); err != nil {
+			t.Fatalf("subsampling %d: %v", tt.subsampling, err)
+		}
+		m, err := jpeg.Decode(&buf)
+		if err != nil {
+			t.Fatalf("subsampling %d: %v", tt.subsampling, err)
+		}
+		ycbcr, ok := m.(*image.YCbCr)
+		if !ok {
+			t.Fatalf("subsampling %d: decoded a %T", tt.subsampling, m)
+		}
+		if ycbcr.SubsampleRatio != tt.want {
+			t.Errorf("subsampling %d: decoded as %v, want %v", tt.subsampling, ycbcr.SubsampleRatio, tt.want)
+		}
+		if m.Bounds() != src.Bounds() {
+			t.Errorf("subsampling %d: decoded bounds %v, want %v", tt.subsampling, m.Bounds(), src.Bounds())
+		}
+		if d := averageDelta(src, m); d > 3 {
+			t.Errorf("subsampling %d: average delta is %d, want at most 3", tt.subsampling, d)
+		}
+	}
+}
+
+// TestMatchesStandardEncoder checks that the default 4:2:0 output is the
+// same as the standard library's, which this package was copied from
+func TestMatchesStandardEncoder(t *testing.T) {
+	src := testImage(40, 24)
+	for _, quality := range []int{1, 50, 75, 100} {
+		var got, want bytes.Buffer
+		if err := Encode(&got, src, &Options{Quality: quality}); err != nil {
+			t.Fatal(err)
+		}
+		if err := jpeg.Encode(&want, src, &jpeg.Options{Quality: quality}); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Bytes(), want.Bytes()) {
+			t.Errorf("quality %d: output differs from image/jpeg", quality)
+		}
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, testImage(8, 8), &Options{Quality: 75, Subsampling: Subsampling(9)}); err == nil {
+		t.Error("unknown subsampling was accepted")
+	}
+	if err := Encode(&buf, image.NewGray(image.Rect(0, 0, 1<<16, 1)), nil); err == nil {
+		t.Error("an image too large for a JPEG header was accepted")
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// kernelKey identifies a kernel by entry point and version. The version is
+// bumped whenever the kernel's signature or semantics change, so launch
+// code always gets the kernel it was written against.
+type kernelKey struct {
+	Name    string
+	Version int
+}
+
+func (k kernelKey) String() string { return fmt.Sprintf("%s@v%d", k.Name, k.Version) }
+
+// kernelSpec describes where a kernel lives
+type kernelSpec struct {
+	kernelKey
+	Module string // PTX file holding the kernel
+}
+
+// Kernels used by the GPU pipeline
+var (
+	resampleHorizontalV4 = kernelKey{Name: "resampleHorizontal", Version: 4}
+	resampleVerticalV4   = kernelKey{Name: "resampleVertical", Version: 4}
+	placeKernelV2        = kernelKey{Name: "placeKernel", Version: 2}
+	orientKernelV1       = kernelKey{Name: "orientKernel", Version: 1}
+	rotateKernelV2       = kernelKey{Name: "rotateKernel", Version: 2}
+	adjustKernelV1       = kernelKey{Name: "adjustKernel", Version: 1}
+	convolveHorizontalV1 = kernelKey{Name: "convolveHorizontal", Version: 1}
+	convolveVerticalV1   = kernelKey{Name: "convolveVertical", Version: 1}
+	unsharpKernelV1      = kernelKey{Name: "unsharpKernel", Version: 1}
+	overlayKernelV1      = kernelKey{Name: "overlayKernel", Version: 1}
+)
+
+var kernelSpecs = []kernelSpec{
+	{kernelKey: resampleHorizontalV4, Module: "resize_kernel.ptx"},
+	{kernelKey: resampleVerticalV4, Module: "resize_kernel.ptx"},
+	{kernelKey: placeKernelV2, Module: "resize_kernel.ptx"},
+	{kernelKey: orientKernelV1, Module: "transform_kernel.ptx"},
+	{kernelKey: rotateKernelV2, Module: "transform_kernel.ptx"},
+	{kernelKey: adjustKernelV1, Module: "adjust_kernel.ptx"},
+	{kernelKey: convolveHorizontalV1, Module: "filter_kernel.ptx"},
+	{kernelKey: convolveVerticalV1, Module: "filter_kernel.ptx"},
+	{kernelKey: unsharpKernelV1, Module: "filter_kernel.ptx"},
+	{kernelKey: overlayKernelV1, Module: "composite_kernel.ptx"},
+}
+
+// kernelSource reads a compiled module by file name
+type kernelSource func(module string) ([]byte, error)
+
+// dirKernelSource reads modules from a directory, used to try out
+// freshly compiled kernels without rebuilding the binary
+func dirKernelSource(dir string) kernelSource {
+	return func(module string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(dir, module))
+	}
+}
+
+// kernelRegistry maps kernel keys to the modules that provide them
+type kernelRegistry struct {
+	specs  map[kernelKey]kernelSpec
+	source kernelSource
+	origin string
+}
+
+// newKernelRegistry returns a registry loading modules from source;
+// origin describes the source in logs and errors
+func newKernelRegistry(source kernelSource, origin string, specs ...kernelSpec) *kernelRegistry {
+	r := &kernelRegistry{
+		specs:  make(map[kernelKey]kernelSpec, len(specs)),
+		source: source,
+		origin: origin,
+	}
+	for _, spec := range specs {
+		r.specs[spec.kernelKey] = spec
+	}
+	return r
+}
+
+// Lookup returns the spec for a kernel
+func (r *kernelRegistry) Lookup(key kernelKey) (kernelSpec, bool) {
+	spec, ok := r.specs[key]
+	return spec, ok
+}
+
+// Modules lists the module files referenced by the registry
+func (r *kernelRegistry) Modules() []string {
+	seen := make(map[string]bool)
+	var modules []string
+	for _, spec := range r.specs {
+		if !seen[spec.Module] {
+			seen[spec.Module] = true
+			modules = append(modules, spec.Module)
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// Load reads a module from the registry's source
+func (r *kernelRegistry) Load(module string) ([]byte, error) {
+	data, err := r.source(module)
+	if err != nil {
+		return nil, fmt.Errorf("kernel module %s not found in %s: %w", module, r.origin, err)
+	}
+	return data, nil
+}
+
+// SelfCheck verifies every registered kernel is present, looks like valid
+// PTX and carries the expected version marker
+func (r *kernelRegistry) SelfCheck() error {
+	var errs []error
+	modules := make(map[string][]byte)
+	for _, module := range r.Modules() {
+		data, err := r.Load(module)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !bytes.Contains(data, []byte(".version")) || !bytes.Contains(data, []byte(".target")) {
+			errs = append(errs, fmt.Errorf("kernel module %s in %s is not valid PTX", module, r.origin))
+			continue
+		}
+		modules[module] = data
+	}
+
+	keys := make([]kernelKey, 0, len(r.specs))
+	for key := range r.specs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	for _, key := range keys {
+		ptx, ok := modules[r.specs[key].Module]
+		if !ok {
+			continue
+		}
+		if err := checkKernelPTX(ptx, key); err != nil {
+			errs = append(errs, fmt.Errorf("%s in %s: %w", r.specs[key].Module, r.origin, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkKernelPTX looks for the kernel entry point and its version marker,
+// a device global named <kernel>_version
+func checkKernelPTX(ptx []byte, key kernelKey) error {
+	entry := regexp.MustCompile(`\.entry\s+` + regexp.QuoteMeta(key.Name) + `\s*\(`)
+	if !entry.Match(ptx) {
+		return fmt.Errorf("kernel %s is missing", key.Name)
+	}
+
+	marker := regexp.MustCompile(`\.[us]32\s+` + regexp.QuoteMeta(key.Name) + `_version\s*=\s*(\d+)`)
+	m := marker.FindSubmatch(ptx)
+	if m == nil {
+		return fmt.Errorf("kernel %s has no version marker", key.Name)
+	}
+	version, _ := strconv.Atoi(string(m[1]))
+	if version != key.Version {
+		return fmt.Errorf("kernel %s is version %d, want %d", key.Name, version, key.Version)
+	}
+	return nil
+}
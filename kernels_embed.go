@@ -0,0 +1,18 @@
+//go:build cuda
+
+package main
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// The PTX modules are compiled by nvcc before the Go build
+//
+//go:embed cuda/*.ptx
+var embeddedKernels embed.FS
+
+// embeddedKernelSource reads modules compiled into the binary
+func embeddedKernelSource(module string) ([]byte, error) {
+	return fs.ReadFile(embeddedKernels, "cuda/"+module)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestKernelSelfCheck feeds the registry modules that are wrong in one way
+// each and checks that the self-check names the problem
+func TestKernelSelfCheck(t *testing.T) {
+	const header = ".version 8.0\n.target sm_52\n.address_size 64\n"
+	const entry = ".visible .entry resizeKernel(\n)\n{\n\tret;\n}\n"
+	tests := []struct {
+		name string
+		ptx  string // Empty for a missing module
+		want string // Empty when the check passes
+	}{
+		{"matching", header + ".visible .global .align 4 .u32 resizeKernel_version = 1;\n" + entry, ""},
+		{"signed marker", header + ".visible .global .align 4 .s32 resizeKernel_version = 1;\n" + entry, ""},
+		{"older version", header + ".visible .global .align 4 .u32 resizeKernel_version = 0;\n" + entry, "is version 0, want 1"},
+		{"newer version", header + ".visible .global .align 4 .u32 resizeKernel_version = 2;\n" + entry, "is version 2, want 1"},
+		{"no marker", header + entry, "has no version marker"},
+		{"no entry", header + ".visible .global .align 4 .u32 resizeKernel_version = 1;\n", "resizeKernel is missing"},
+		{"not PTX", "resizeKernel_version = 1", "is not valid PTX"},
+		{"missing module", "", "not found in test modules"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := func(module string) ([]byte, error) {
+				if tt.ptx == "" {
+					return nil, errors.New("no such file")
+				}
+				return []byte(tt.ptx), nil
+			}
+			err := newKernelRegistry(source, "test modules", kernelSpec{kernelKey: kernelKey{Name: "resizeKernel", Version: 1}, Module: "resize_kernel.ptx"}).SelfCheck()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("self-check failed: %v", err)
+			case tt.want != "" && err == nil:
+				t.Errorf("self-check passed, want an error containing %q", tt.want)
+			case tt.want != "" && !strings.Contains(err.Error(), tt.want):
+				t.Errorf("self-check error %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestSimKernelsPassSelfCheck checks that the simulator's stand-in PTX
+// declares every registered kernel at its expected version
+func TestSimKernelsPassSelfCheck(t *testing.T) {
+	if err := newKernelRegistry(simKernelSource(kernelSpecs), "simulated kernels", kernelSpecs...).SelfCheck(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,246 +1,79 @@
 package main
 
 import (
-	"bytes"
-	"context"
 	"fmt"
-	"image"
-	"image/draw"
-	"image/jpeg"
-	_ "image/jpeg"
 	"log"
 	"net"
 	"os"
-	"unsafe"
+	"runtime"
+	"strconv"
 
-	"github.com/barnex/cuda5/cu"
-	"github.com/nfnt/resize"
 	"google.golang.org/grpc"
 
 	pb "github.com/jeauchter/go-image-adjuster/proto"
 )
 
-// checkGPUAvailability checks if an NVIDIA GPU is available
-func checkGPUAvailability() bool {
-	// Initialize CUDA
-	cu.Init(0)
-	deviceCount := cu.DeviceGetCount()
-	return deviceCount > 0
-}
-
-// getGPUDevices lists available GPU devices
-func getGPUDevices() []string {
-	// Initialize CUDA
-	cu.Init(0)
-	deviceCount := cu.DeviceGetCount()
-
-	// Get device properties
-	var devices []string
-	for i := 0; i < deviceCount; i++ {
-		device := cu.Device(i)
-		name := device.Name()
-		devices = append(devices, name)
-	}
-
-	return devices
-}
-
-// resizeImageCPU resizes an image using a CPU-based method
-func resizeImageCPU(imageData []byte, width, height uint, quality int) ([]byte, error) {
-	// Decode image
-	img, _, err := image.Decode(bytes.NewReader(imageData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode image: %w", err)
-	}
-
-	// Resize using CPU
-	resizedImg := resize.Resize(width, height, img, resize.Lanczos3)
-
-	// Encode resized image as JPEG
-	var output bytes.Buffer
-	err = jpeg.Encode(&output, resizedImg, &jpeg.Options{Quality: quality})
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode resized image: %w", err)
-	}
-
-	return output.Bytes(), nil
-}
-
-// Decode the image on the CPU, converting it to NRGBA
-func decodeToNRGBA(imageData []byte) (*image.NRGBA, error) {
-	// check the size of imageData
-	if len(imageData) == 0 {
-		return nil, fmt.Errorf("image data is empty")
+// envInt reads a positive integer from the environment
+func envInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
 	}
-
-	// First, check the format with DecodeConfig (optional but helpful)
-	_, format, err := image.DecodeConfig(bytes.NewReader(imageData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to read image config: %w", err)
-	}
-	if format != "jpeg" {
-		return nil, fmt.Errorf("unsupported image format: %s", format)
-	}
-
-	// Then actually decode the full image bytes
-	img, _, err := image.Decode(bytes.NewReader(imageData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode image on CPU: %w", err)
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		log.Fatalf("Invalid %s: %q", name, value)
 	}
-
-	// Convert to NRGBA
-	bounds := img.Bounds()
-	nrgbaImg := image.NewNRGBA(bounds)
-	draw.Draw(nrgbaImg, bounds, img, bounds.Min, draw.Src)
-	return nrgbaImg, nil
+	return n
 }
 
-// resizeImageGPU resizes the image using the GPU
-func resizeImageGPU(imageData []byte, newWidth, newHeight, quality int) ([]byte, error) {
-	// 1. Decode the image on the CPU
-	cpuImg, err := decodeToNRGBA(imageData)
-	if err != nil {
-		return nil, err
-	}
-
-	// 2. Get pixel data in RGBA format
-	oldWidth := cpuImg.Bounds().Dx()
-	oldHeight := cpuImg.Bounds().Dy()
-	rgbaBytes := cpuImg.Pix
-	// Initialize CUDA
-	cu.Init(0)
-
-	// Create a device and context
-	dev := cu.Device(0)
-	ctx := cu.CtxCreate(0, dev)
-	defer ctx.Destroy()
-
-	// Allocate GPU memory for input and output images
-	deviceInputImage := cu.MemAlloc(int64(len(rgbaBytes)))
-	defer cu.MemFree(deviceInputImage)
-
-	deviceOutputImage := cu.MemAlloc(int64(newWidth * newHeight * 4)) // Assuming 4 bytes per pixel (RGBA)
-	defer cu.MemFree(deviceOutputImage)
-
-	// Copy input image data to GPU
-	cu.MemcpyHtoD(deviceInputImage, unsafe.Pointer(&rgbaBytes[0]), int64(len(rgbaBytes)))
-
-	err = launchResizeKernel(deviceInputImage, oldWidth, oldHeight, newWidth, newHeight)
-	if err != nil {
-		return nil, fmt.Errorf("failed to launch resize kernel: %w", err)
-	}
-
-	// Copy resized image data back to host
-	resizedImageData := make([]byte, newWidth*newHeight*4)
-	cu.MemcpyDtoH(unsafe.Pointer(&resizedImageData[0]), deviceOutputImage, int64(len(resizedImageData)))
-
-	// Convert raw image data to an image.NRGBA to satisfy jpeg.Encode
-	img := &image.NRGBA{
-		Pix:    resizedImageData,
-		Stride: newWidth * 4,
-		Rect:   image.Rect(0, 0, newWidth, newHeight),
-	}
-
-	// Encode resized image as JPEG
-	var output bytes.Buffer
-	err = jpeg.Encode(&output, img, &jpeg.Options{Quality: quality})
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode resized image: %w", err)
-	}
-
-	return output.Bytes(), nil
-}
-
-// gRPC server implementation
-type server struct {
-	pb.UnimplementedImageResizerServer
-}
-
-func (s *server) ResizeImage(ctx context.Context, req *pb.ResizeImageRequest) (*pb.ResizeImageResponse, error) {
-	// Check if the context is canceled before doing expensive work
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-		// proceed
-	}
-	log.Println("Received resize request")
-
-	// Check for GPU availability
-	if checkGPUAvailability() {
-		log.Println("Using GPU for resizing")
-		resizedData, err := resizeImageGPU(req.GetImageData(), int(req.GetWidth()), int(req.GetHeight()), int(req.GetQuality()))
-		if err == nil {
-			log.Println("GPU resizing successful")
-			return &pb.ResizeImageResponse{ResizedImage: resizedData, UsedGpu: true}, nil
+func main() {
+	// GPU_MAX_CONCURRENCY caps the number of jobs running on each GPU at once
+	maxPerDevice := envInt("GPU_MAX_CONCURRENCY", 2)
+
+	// Register the available backends
+	registry := NewRegistry()
+	if err := registerGPUBackends(registry, maxPerDevice); err != nil {
+		log.Fatalf("Failed to register GPU backends: %v", err)
+	}
+	// CUDA_SIMULATOR runs the GPU pipeline on that many simulated devices,
+	// for development without hardware
+	if os.Getenv("CUDA_SIMULATOR") != "" {
+		var names []string
+		for i := range envInt("CUDA_SIMULATOR", 1) {
+			names = append(names, fmt.Sprintf("Simulated GPU %d", i))
+		}
+		if err := registry.Register(newSimCUDABackend("cuda-sim", newSimDriver(names...), maxPerDevice), 50); err != nil {
+			log.Fatalf("Failed to register simulated CUDA backend: %v", err)
 		}
-		log.Printf("GPU resizing failed, falling back to CPU: %v", err)
 	}
-
-	// Fallback to CPU if GPU is unavailable or fails
-	resizedData, err := resizeImageCPU(req.GetImageData(), uint(req.GetWidth()), uint(req.GetHeight()), int(req.GetQuality()))
-	if err != nil {
-		log.Printf("CPU resize failed: %v", err)
-		return nil, fmt.Errorf("CPU resize failed: %w", err)
+	if err := registry.Register(cpuBackend{}, 0); err != nil {
+		log.Fatalf("Failed to register CPU backend: %v", err)
 	}
-	log.Println("CPU resizing successful")
-	return &pb.ResizeImageResponse{ResizedImage: resizedData, UsedGpu: false}, nil
-}
-
-// loadPTX loads the precompiled CUDA kernel
-func loadPTX() ([]byte, error) {
-	ptxFile := "./cuda/resize_kernel.ptx"
-	return os.ReadFile(ptxFile)
-}
 
-func launchResizeKernel(deviceImage cu.DevicePtr, oldWidth, oldHeight, newWidth, newHeight int) error {
-	// Load PTX file
-	ptx, err := loadPTX()
+	// IMAGE_BACKEND selects a single backend by name, or "auto" to prefer GPUs
+	policy, err := policyFromName(os.Getenv("IMAGE_BACKEND"), registry)
 	if err != nil {
-		return fmt.Errorf("failed to load PTX file: %w", err)
+		log.Fatalf("Invalid IMAGE_BACKEND: %v", err)
 	}
 
-	// Load the CUDA module
-	module := cu.ModuleLoadData(string(ptx))
+	// MAX_UPLOAD_BYTES limits the size of a streamed upload
+	maxUploadBytes := envInt("MAX_UPLOAD_BYTES", 64<<20)
 
-	// Get the kernel function from the module
-	kernel := module.GetFunction("resizeKernel")
+	// BATCH_CONCURRENCY is the number of workers processing each batch stream
+	batchWorkers := envInt("BATCH_CONCURRENCY", runtime.NumCPU())
 
-	// Set up kernel parameters
-	params := []unsafe.Pointer{
-		unsafe.Pointer(&deviceImage),
-		unsafe.Pointer(&oldWidth),
-		unsafe.Pointer(&oldHeight),
-		unsafe.Pointer(&newWidth),
-		unsafe.Pointer(&newHeight),
+	// ASSET_DIR holds overlay images and FONT_DIR holds TrueType or
+	// OpenType fonts that requests can name
+	assets := &assetStore{}
+	if dir := os.Getenv("ASSET_DIR"); dir != "" {
+		if err := assets.loadImages(dir); err != nil {
+			log.Fatalf("Failed to load assets: %v", err)
+		}
 	}
-
-	// Define grid and block dimensions
-	blockDimX, blockDimY := 16, 16
-	gridDimX := (newWidth + blockDimX - 1) / blockDimX
-	gridDimY := (newHeight + blockDimY - 1) / blockDimY
-
-	// Launch the kernel
-	cu.LaunchKernel(
-		kernel,
-		gridDimX, gridDimY, 1, // Grid dimensions
-		blockDimX, blockDimY, 1, // Block dimensions
-		0, cu.Stream(0), // Shared memory and stream
-		params, // Kernel parameters
-	)
-
-	return nil
-
-}
-
-func main() {
-	// Check if a GPU is available
-	gpuAvailable := checkGPUAvailability()
-	fmt.Println("GPU Available:", gpuAvailable)
-
-	if gpuAvailable {
-		gpus := getGPUDevices()
-		fmt.Println("Available GPUs:", gpus)
+	if dir := os.Getenv("FONT_DIR"); dir != "" {
+		if err := assets.loadFonts(dir); err != nil {
+			log.Fatalf("Failed to load fonts: %v", err)
+		}
 	}
 
 	// Start gRPC server
@@ -249,7 +82,13 @@ func main() {
 		log.Fatalf("Failed to listen: %v", err)
 	}
 	s := grpc.NewServer()
-	pb.RegisterImageResizerServer(s, &server{})
+	pb.RegisterImageResizerServer(s, &server{
+		backends:       registry,
+		policy:         policy,
+		maxUploadBytes: maxUploadBytes,
+		batchWorkers:   batchWorkers,
+		assets:         assets,
+	})
 	fmt.Println("gRPC server is running on port 50051")
 	if err := s.Serve(listener); err != nil {
 		log.Fatalf("Failed to serve: %v", err)
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"slices"
+)
+
+// defaultMinPSNR is the quality floor used when a negotiation sets none.
+// Around 35 dB differences are hard to spot at normal viewing size.
+const defaultMinPSNR = 35
+
+// FormatCandidate reports one encoding tried during format negotiation
+type FormatCandidate struct {
+	Format     OutputFormat
+	Size       int     // Encoded size in bytes
+	PSNR       float64 // Peak signal-to-noise ratio against the unencoded result, +Inf when lossless
+	Acceptable bool    // PSNR meets the quality floor
+}
+
+// negotiateFormat encodes img in every accepted format and keeps the
+// smallest one that meets the quality floor. When none does, the candidate
+// with the highest PSNR is kept instead.
+func negotiateFormat(img *image.NRGBA, inputFormat string, opts EncodeOptions) (Output, error) {
+	minPSNR := opts.MinPSNR
+	if minPSNR == 0 {
+		minPSNR = defaultMinPSNR
+	}
+
+	var formats []OutputFormat
+	for _, format := range opts.Accept {
+		format = resolveFormat(format, inputFormat, img)
+		if !slices.Contains(formats, format) {
+			formats = append(formats, format)
+		}
+	}
+
+	best := Output{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	var bestCandidate FormatCandidate
+	candidates := make([]FormatCandidate, 0, len(formats))
+	for _, format := range formats {
+		data, err := encodeAs(img, format, opts)
+		if err != nil {
+			return Output{}, err
+		}
+		psnr, err := encodedPSNR(img, data)
+		if err != nil {
+			return Output{}, fmt.Errorf("failed to measure %s candidate: %w", format, err)
+		}
+		candidate := FormatCandidate{Format: format, Size: len(data), PSNR: psnr, Acceptable: psnr >= minPSNR}
+		candidates = append(candidates, candidate)
+
+		if best.Image == nil || betterCandidate(candidate, bestCandidate) {
+			best.Image, best.Format = data, format
+			bestCandidate = candidate
+		}
+	}
+	best.Candidates = candidates
+	return best, nil
+}
+
+// betterCandidate orders candidates: acceptable before unacceptable, then
+// smaller acceptable ones, or higher quality unacceptable ones
+func betterCandidate(c, than FormatCandidate) bool {
+	switch {
+	case c.Acceptable != than.Acceptable:
+		return c.Acceptable
+	case c.Acceptable:
+		return c.Size < than.Size
+	case c.PSNR != than.PSNR:
+		return c.PSNR > than.PSNR
+	}
+	return c.Size < than.Size
+}
+
+// encodedPSNR decodes data and compares it with img. Pixels are compared
+// premultiplied, so colour hidden under full transparency does not count.
+func encodedPSNR(img *image.NRGBA, data []byte) (float64, error) {
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	got := toNRGBA(decoded)
+	if got.Bounds().Size() != img.Bounds().Size() {
+		return 0, fmt.Errorf("decoded size %v differs from %v", got.Bounds().Size(), img.Bounds().Size())
+	}
+
+	var sum float64
+	for i := 0; i < len(img.Pix); i += 4 {
+		want, have := img.Pix[i:i+4:i+4], got.Pix[i:i+4:i+4]
+		for c := 0; c < 4; c++ {
+			d := premultiplied(want, c) - premultiplied(have, c)
+			sum += d * d
+		}
+	}
+	if sum == 0 {
+		return math.Inf(1), nil
+	}
+	mse := sum / float64(len(img.Pix))
+	return 10 * math.Log10(255*255/mse), nil
+}
+
+// premultiplied returns channel c of an NRGBA pixel multiplied by its alpha
+func premultiplied(px []uint8, c int) float64 {
+	if c == 3 {
+		return float64(px[3])
+	}
+	return float64(px[c]) * float64(px[3]) / 255
+}
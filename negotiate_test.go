@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+)
+
+func TestBetterCandidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		c, than FormatCandidate
+		want    bool
+	}{
+		{"acceptable beats larger acceptable", FormatCandidate{Size: 10, PSNR: 36, Acceptable: true}, FormatCandidate{Size: 20, PSNR: 50, Acceptable: true}, true},
+		{"larger acceptable loses", FormatCandidate{Size: 20, PSNR: 50, Acceptable: true}, FormatCandidate{Size: 10, PSNR: 36, Acceptable: true}, false},
+		{"acceptable beats smaller unacceptable", FormatCandidate{Size: 90, PSNR: 40, Acceptable: true}, FormatCandidate{Size: 5, PSNR: 20}, true},
+		{"unacceptable loses to acceptable", FormatCandidate{Size: 5, PSNR: 20}, FormatCandidate{Size: 90, PSNR: 40, Acceptable: true}, false},
+		{"higher quality unacceptable wins", FormatCandidate{Size: 90, PSNR: 30}, FormatCandidate{Size: 5, PSNR: 20}, true},
+		{"equal quality unacceptable by size", FormatCandidate{Size: 5, PSNR: 20}, FormatCandidate{Size: 9, PSNR: 20}, true},
+	}
+	for _, tt := range tests {
+		if got := betterCandidate(tt.c, tt.than); got != tt.want {
+			t.Errorf("%s: betterCandidate = %v", tt.name, got)
+		}
+	}
+}
+
+func TestEncodedPSNR(t *testing.T) {
+	img := uniformImage(2, 1, color.NRGBA{100, 100, 100, 255})
+	encode := func(m *image.NRGBA) []byte {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, m); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	// One channel of one pixel off by 10: MSE is 100 over 8 channels
+	off := cloneNRGBA(img)
+	off.Pix[0] += 10
+	psnr, err := encodedPSNR(img, encode(off))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 10 * math.Log10(255*255/12.5); math.Abs(psnr-want) > 1e-9 {
+		t.Errorf("PSNR = %v, want %v", psnr, want)
+	}
+
+	// Colour under full transparency does not count
+	clear := uniformImage(2, 1, color.NRGBA{10, 20, 30, 0})
+	hidden := uniformImage(2, 1, color.NRGBA{200, 0, 90, 0})
+	if psnr, err := encodedPSNR(clear, encode(hidden)); err != nil || !math.IsInf(psnr, 1) {
+		t.Errorf("PSNR of hidden colour changes = %v, %v, want +Inf", psnr, err)
+	}
+
+	if _, err := encodedPSNR(img, encode(uniformImage(3, 1, color.NRGBA{}))); err == nil {
+		t.Error("a decoded image of another size was compared")
+	}
+}
+
+// TestNegotiateFormat checks that negotiation keeps the smallest candidate
+// meeting the floor, or the best looking one when none does
+func TestNegotiateFormat(t *testing.T) {
+	// A gradient with grain, like a photo: lossless formats can't
+	// squeeze the noise but JPEG can
+	photo := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	seed := uint32(1)
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			seed = seed*1664525 + 1013904223
+			grain := int(seed>>28) - 8
+			photo.SetNRGBA(x, y, color.NRGBA{uint8(x*3 + 20 + grain), uint8(y*4 + 20 + grain), uint8(200 - x*2 + grain), 255})
+		}
+	}
+	// A flat image, where lossless formats are tiny
+	flat := uniformImage(64, 48, color.NRGBA{30, 60, 90, 255})
+
+	all := []OutputFormat{OutputJPEG, OutputPNG, OutputGIF, OutputWebP}
+	tests := []struct {
+		name    string
+		img     *image.NRGBA
+		accept  []OutputFormat
+		minPSNR float64
+		want    OutputFormat
+	}{
+		{"photo", photo, all, 30, OutputJPEG},
+		{"photo, lossless floor", photo, []OutputFormat{OutputJPEG, OutputPNG}, math.Inf(1), OutputPNG},
+		{"flat", flat, all, 0, OutputGIF},
+		{"same as input folds into jpeg", photo, []OutputFormat{OutputSameAsInput, OutputJPEG}, 0, OutputJPEG},
+		{"none acceptable", photo, []OutputFormat{OutputJPEG, OutputGIF}, 200, OutputJPEG},
+	}
+	for _, tt := range tests {
+		enc, err := encodeImage(tt.img, "jpeg", EncodeOptions{Accept: tt.accept, MinPSNR: tt.minPSNR, JPEGQuality: 90})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if enc.Format != tt.want {
+			t.Errorf("%s: chose %v, want %v; candidates %+v", tt.name, enc.Format, tt.want, enc.Candidates)
+		}
+
+		// The choice is consistent with what was measured
+		var chosen FormatCandidate
+		formats := make(map[OutputFormat]bool)
+		for _, c := range enc.Candidates {
+			if formats[c.Format] {
+				t.Errorf("%s: %v tried twice", tt.name, c.Format)
+			}
+			formats[c.Format] = true
+			if c.Format == enc.Format {
+				chosen = c
+			}
+		}
+		if chosen.Size != len(enc.Image) {
+			t.Errorf("%s: chosen candidate is %d bytes, result is %d", tt.name, chosen.Size, len(enc.Image))
+		}
+		for _, c := range enc.Candidates {
+			if betterCandidate(c, chosen) {
+				t.Errorf("%s: %+v is better than the chosen %+v", tt.name, c, chosen)
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"image"
+	"math"
+)
+
+// BlendMode selects how overlay colours combine with the base image. The
+// values match the proto BlendMode enum.
+type BlendMode int32
+
+const (
+	BlendNormal   BlendMode = iota // The overlay colour
+	BlendMultiply                  // Darkens: base x overlay
+	BlendScreen                    // Lightens: inverse of multiplying the inverses
+)
+
+// OverlayOp composites a second image, such as a watermark, onto the image
+type OverlayOp struct {
+	Image   *image.NRGBA
+	Gravity Gravity
+	Offset  image.Point // Moves the overlay inwards from the edges named by Gravity
+	Opacity float64     // 0-1, multiplies the overlay alpha
+	Scale   float64     // Overlay width as a fraction of the image width, 0 for its own size
+	Tile    bool        // Repeat the overlay across the image from its position
+	Blend   BlendMode
+}
+
+func (op OverlayOp) plan(size image.Point) ([]step, error) {
+	scaled := op.Image.Rect.Size()
+	if op.Scale > 0 {
+		width := max(1, int(math.Round(op.Scale*float64(size.X))))
+		height := max(1, int(math.Round(float64(width)*float64(scaled.Y)/float64(scaled.X))))
+		scaled = image.Pt(width, height)
+	}
+
+	pos := op.Gravity.anchor(size, scaled)
+	switch op.Gravity {
+	case GravityNorthEast, GravityEast, GravitySouthEast:
+		pos.X -= op.Offset.X
+	default:
+		pos.X += op.Offset.X
+	}
+	switch op.Gravity {
+	case GravitySouthWest, GravitySouth, GravitySouthEast:
+		pos.Y -= op.Offset.Y
+	default:
+		pos.Y += op.Offset.Y
+	}
+	return []step{overlayStep{
+		Image:   op.Image,
+		Size:    scaled,
+		Pos:     pos,
+		Opacity: float32(op.Opacity),
+		Tile:    op.Tile,
+		Blend:   op.Blend,
+	}}, nil
+}
+
+// overlayStep composites Image, scaled to Size, with its top-left corner
+// at Pos. The overlay is scaled when the step runs rather than when it is
+// planned, so steps sharing an overlay and size compare equal, since Image
+// is a pointer, and a batch scales it once.
+type overlayStep struct {
+	Image   *image.NRGBA
+	Size    image.Point // Zero for the size of Image
+	Pos     image.Point
+	Opacity float32
+	Tile    bool
+	Blend   BlendMode
+}
+
+func (st overlayStep) outputSize(in image.Point) image.Point { return in }
+
+func (st overlayStep) cpu(img *image.NRGBA) *image.NRGBA {
+	if img.Stride != img.Bounds().Dx()*4 {
+		img = cloneNRGBA(img)
+	}
+	overlay := st.Image
+	if st.scaled() {
+		overlay = resampleNRGBA(overlay, st.Size.X, st.Size.Y, FilterLanczos3, true)
+	}
+	if overlay.Stride != overlay.Rect.Dx()*4 {
+		overlay = cloneNRGBA(overlay)
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	parallelRows(height, func(y int) {
+		for x := 0; x < width; x++ {
+			overlayPixel(img.Pix, out.Pix, width, overlay.Pix, overlay.Rect.Dx(), overlay.Rect.Dy(),
+				st.Pos.X, st.Pos.Y, st.Tile, st.Opacity, st.Blend, x, y)
+		}
+	})
+	return out
+}
+
+func (st overlayStep) gpu(s *gpuSession, img deviceImage) (deviceImage, error) {
+	overlay, err := s.upload(st.Image)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	if st.scaled() {
+		if overlay, err = s.resample(overlay, st.Size.X, st.Size.Y, FilterLanczos3, true); err != nil {
+			return deviceImage{}, err
+		}
+	}
+	out, err := s.newImage(img.width, img.height, img.count)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	var tile int32
+	if st.Tile {
+		tile = 1
+	}
+	err = s.launch(overlayKernelV1, img.width, img.height, img.count,
+		img.ptr, out.ptr, int32(img.width), int32(img.height),
+		overlay.ptr, int32(overlay.width), int32(overlay.height),
+		int32(st.Pos.X), int32(st.Pos.Y), tile, st.Opacity, int32(st.Blend),
+	)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	return out, nil
+}
+
+// scaled reports whether the overlay is drawn at other than its own size
+func (st overlayStep) scaled() bool {
+	return st.Size != image.Point{} && st.Size != st.Image.Rect.Size()
+}
+
+// overlayPixel computes pixel (x, y) of a composite: the overlay pixel
+// over it, if any, is blended with the base colour and laid over the base
+// with source-over alpha, following the W3C compositing rules. Shared by
+// the CPU backend and the simulated overlayKernel.
+func overlayPixel(base, dst []byte, width int, overlay []byte, ovWidth, ovHeight, dx, dy int, tile bool, opacity float32, blend BlendMode, x, y int) {
+	i := (y*width + x) * 4
+	copy(dst[i:i+4], base[i:i+4])
+
+	ox, oy := x-dx, y-dy
+	if tile {
+		ox, oy = (ox%ovWidth+ovWidth)%ovWidth, (oy%ovHeight+ovHeight)%ovHeight
+	} else if ox < 0 || oy < 0 || ox >= ovWidth || oy >= ovHeight {
+		return
+	}
+	o := overlay[(oy*ovWidth+ox)*4:]
+	as := float32(o[3]) / 255 * opacity
+	if as <= 0 {
+		return
+	}
+	ab := float32(base[i+3]) / 255
+	ao := as + ab*(1-as)
+	for c := 0; c < 3; c++ {
+		cs, cb := float32(o[c])/255, float32(base[i+c])/255
+		mixed := cs
+		switch blend {
+		case BlendMultiply:
+			mixed = cb * cs
+		case BlendScreen:
+			mixed = cb + cs - cb*cs
+		}
+		cs = (1-ab)*cs + ab*mixed
+		dst[i+c] = clampByte((as*cs + ab*cb*(1-as)) / ao * 255)
+	}
+	dst[i+3] = clampByte(ao * 255)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// TestOverlayPosition plans a 4x2 overlay on a 20x10 image and checks
+// that offsets move it inwards from the edges named by the gravity
+func TestOverlayPosition(t *testing.T) {
+	overlay := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	tests := []struct {
+		gravity Gravity
+		offset  image.Point
+		scale   float64
+		want    image.Point
+	}{
+		{GravityCenter, image.Point{}, 0, image.Pt(8, 4)},
+		{GravityNorthWest, image.Pt(2, 1), 0, image.Pt(2, 1)},
+		{GravitySouthEast, image.Pt(2, 1), 0, image.Pt(14, 7)},
+		{GravityNorthEast, image.Pt(3, 3), 0, image.Pt(13, 3)},
+		{GravitySouthWest, image.Pt(-1, 0), 0, image.Pt(-1, 8)},
+		// Scaled to half the image width, keeping the aspect ratio
+		{GravitySouthEast, image.Point{}, 0.5, image.Pt(10, 5)},
+	}
+	for _, tt := range tests {
+		steps, err := OverlayOp{Image: overlay, Gravity: tt.gravity, Offset: tt.offset, Scale: tt.scale, Opacity: 1}.plan(image.Pt(20, 10))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := steps[0].(overlayStep).Pos; got != tt.want {
+			t.Errorf("gravity %d, offset %v, scale %v: placed at %v, want %v", tt.gravity, tt.offset, tt.scale, got, tt.want)
+		}
+	}
+}
+
+// TestOverlayPlansCompareEqual checks that scaled overlays planned for
+// separate jobs give equal steps, so a batch groups them and scales the
+// overlay once
+func TestOverlayPlansCompareEqual(t *testing.T) {
+	op := OverlayOp{Image: gradientImage(16, 8), Gravity: GravitySouthEast, Scale: 0.25, Opacity: 0.5}
+	a, err := op.plan(image.Pt(100, 60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := op.plan(image.Pt(100, 60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a[0] != b[0] {
+		t.Errorf("plans differ: %+v and %+v", a[0], b[0])
+	}
+
+	// Scaling happens when the step runs, on either backend
+	src := image.NewNRGBA(image.Rect(0, 0, 100, 60))
+	want := overlayStep{Image: resampleNRGBA(op.Image, 25, 13, FilterLanczos3, true), Pos: image.Pt(75, 47), Opacity: 0.5}.cpu(src)
+	cpu, gpu := runBothImplementations(t, openSimDevice(t, newSimDriver("Simulated GPU")), src, []Operation{op})
+	for impl, got := range map[string]*image.NRGBA{"CPU": cpu, "device": gpu} {
+		if err := diffImages(want, got, 1); err != nil {
+			t.Errorf("%s: %v", impl, err)
+		}
+	}
+}
+
+// TestOverlayBlendValues composites single pixels on both backends and
+// compares them with values worked out from the W3C compositing rules
+func TestOverlayBlendValues(t *testing.T) {
+	opaque, transparent := color.NRGBA{200, 100, 50, 255}, color.NRGBA{}
+	tests := []struct {
+		name    string
+		base    color.NRGBA
+		opacity float64
+		blend   BlendMode
+		want    color.NRGBA
+	}{
+		{"normal", opaque, 1, BlendNormal, color.NRGBA{100, 200, 0, 255}},
+		{"multiply", opaque, 1, BlendMultiply, color.NRGBA{78, 78, 0, 255}},
+		{"screen", opaque, 1, BlendScreen, color.NRGBA{222, 222, 50, 255}},
+		{"half opacity", opaque, 0.5, BlendNormal, color.NRGBA{150, 150, 25, 255}},
+		// Nothing to blend with, so the overlay keeps its colour
+		{"over transparency", transparent, 0.5, BlendMultiply, color.NRGBA{100, 200, 0, 128}},
+	}
+	overlay := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	overlay.SetNRGBA(0, 0, color.NRGBA{100, 200, 0, 255})
+	dev := openSimDevice(t, newSimDriver("Simulated GPU"))
+	for _, tt := range tests {
+		src := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+		src.SetNRGBA(0, 0, tt.base)
+		want := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+		want.SetNRGBA(0, 0, tt.want)
+		cpu, gpu := runBothImplementations(t, dev, src, []Operation{OverlayOp{Image: overlay, Opacity: tt.opacity, Blend: tt.blend}})
+		for impl, got := range map[string]*image.NRGBA{"CPU": cpu, "device": gpu} {
+			if err := diffImages(want, got, 1); err != nil {
+				t.Errorf("%s on the %s: %v", tt.name, impl, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Operation is one step of a pipeline as a request describes it. Before
+// running, each operation is planned against the size of the image it
+// receives, which resolves it into steps.
+type Operation interface {
+	// plan returns the steps that run the operation on an image of the
+	// given size
+	plan(size image.Point) ([]step, error)
+}
+
+// focusedOperation is an Operation that crops around the focus of a job
+// when it has one
+type focusedOperation interface {
+	Operation
+	// planFocused is plan with the focus in pixels of the image the
+	// operation receives, or nil
+	planFocused(size image.Point, f *focus) ([]step, error)
+}
+
+// step is a primitive with every size resolved, run by both backends.
+// Steps must be comparable, so the GPU backend can group jobs that run the
+// same steps.
+type step interface {
+	// outputSize returns the size of the step's result for an input of the
+	// given size
+	outputSize(in image.Point) image.Point
+	// cpu runs the step on the CPU
+	cpu(img *image.NRGBA) *image.NRGBA
+	// gpu runs the step on every image of a device stack
+	gpu(s *gpuSession, img deviceImage) (deviceImage, error)
+}
+
+// ResizeOp scales the image into a box according to a fit mode
+type ResizeOp struct {
+	Width       int // 0 derives it from the aspect ratio
+	Height      int // 0 derives it from the aspect ratio
+	Filter      Filter
+	Fit         Fit
+	Background  color.NRGBA // Padding colour for FitContain
+	AutoSharpen bool        // Sharpen after a downscale, more strongly the more it shrinks
+	LinearLight bool        // Resample in linear light with premultiplied alpha
+}
+
+func (op ResizeOp) plan(size image.Point) ([]step, error) {
+	return op.planFocused(size, nil)
+}
+
+// planFocused moves a FitCover crop to keep the focus in frame and keeps
+// FitSeamCarve seams out of the regions of interest
+func (op ResizeOp) planFocused(size image.Point, f *focus) ([]step, error) {
+	l := planFit(size.X, size.Y, op.Width, op.Height, op.Fit)
+	resample := resampleStep{Width: l.ScaledWidth, Height: l.ScaledHeight, Filter: op.Filter, Linear: op.LinearLight}
+	if f != nil {
+		f = resample.mapFocus(size, f)
+	}
+	if op.Fit == FitCover {
+		l.Offset = f.place(l.Offset, image.Pt(l.Width, l.Height), image.Pt(l.ScaledWidth, l.ScaledHeight))
+	}
+	steps := []step{resample}
+	if op.AutoSharpen {
+		steps = append(steps, autoSharpen(size, image.Pt(l.ScaledWidth, l.ScaledHeight))...)
+	}
+	switch {
+	case op.Fit == FitSeamCarve && l.placed():
+		var protect *focus
+		if f != nil && len(f.Regions) > 0 {
+			protect = f
+		}
+		steps = append(steps, carveStep{Width: l.Width, Height: l.Height, Protect: protect})
+	case l.placed():
+		steps = append(steps, placeStep{Layout: l, Background: op.Background})
+	}
+	return steps, nil
+}
+
+// resampleStep scales the image to exactly Width x Height
+type resampleStep struct {
+	Width, Height int
+	Filter        Filter
+	Linear        bool
+}
+
+func (st resampleStep) outputSize(image.Point) image.Point { return image.Pt(st.Width, st.Height) }
+
+func (st resampleStep) cpu(img *image.NRGBA) *image.NRGBA {
+	return resampleNRGBA(img, st.Width, st.Height, st.Filter, st.Linear)
+}
+
+func (st resampleStep) gpu(s *gpuSession, img deviceImage) (deviceImage, error) {
+	return s.resample(img, st.Width, st.Height, st.Filter, st.Linear)
+}
+
+// placeStep applies the crop/pad part of a fit layout
+type placeStep struct {
+	Layout     fitLayout
+	Background color.NRGBA
+}
+
+func (st placeStep) outputSize(image.Point) image.Point {
+	return image.Pt(st.Layout.Width, st.Layout.Height)
+}
+
+func (st placeStep) cpu(img *image.NRGBA) *image.NRGBA {
+	return placeNRGBA(img, st.Layout, st.Background)
+}
+
+func (st placeStep) gpu(s *gpuSession, img deviceImage) (deviceImage, error) {
+	return s.place(img, st.Layout, st.Background)
+}
+
+// maxOutputPixels caps the size of the image after every step, so a
+// request cannot make a backend allocate an unbounded image or resampling
+// buffer
+const maxOutputPixels = 1 << 26
+
+// planPipeline resolves ops, in order, for a source of the given size and
+// optimizes the resulting steps. f, which may be nil, is the focus in
+// pixels of the source; it is followed through the steps for the
+// operations that crop around it, and the parts of it that do not make it
+// into the output are reported.
+func planPipeline(ops []Operation, src image.Point, f *focus) ([]step, FocusLoss, error) {
+	var steps []step
+	size := src
+	for i, op := range ops {
+		var opSteps []step
+		var err error
+		if fo, ok := op.(focusedOperation); ok {
+			opSteps, err = fo.planFocused(size, f)
+		} else {
+			opSteps, err = op.plan(size)
+		}
+		if err != nil {
+			return nil, FocusLoss{}, fmt.Errorf("operation %d: %w", i, err)
+		}
+		for _, st := range opSteps {
+			if m, ok := st.(focusMapper); ok && f != nil {
+				f = m.mapFocus(size, f)
+			}
+			size = st.outputSize(size)
+			if area := int64(size.X) * int64(size.Y); area > maxOutputPixels {
+				return nil, FocusLoss{}, fmt.Errorf("operation %d: %w: image of %dx%d pixels is over the limit of %d pixels", i, errInvalidRequest, size.X, size.Y, maxOutputPixels)
+			}
+		}
+		steps = append(steps, opSteps...)
+	}
+	return optimizeSteps(steps, src), f.loss(size), nil
+}
+
+// optimizeSteps merges consecutive resamples with the same filter and
+// colour space into one, so the image is resampled once from the best data
+// available, folds a crop into the crop or pad before it, and combines
+// consecutive flips and quarter turns. Resamples with different filters,
+// or one in linear light and one not, are kept apart, as each was asked
+// for. Steps that change nothing are dropped. src is the
+// size of the pipeline's input.
+func optimizeSteps(steps []step, src image.Point) []step {
+	var out []step
+	var inputs []image.Point // Input size of each step in out
+	size := src
+	pop := func() step {
+		last := out[len(out)-1]
+		size = inputs[len(inputs)-1]
+		out, inputs = out[:len(out)-1], inputs[:len(inputs)-1]
+		return last
+	}
+	for _, st := range steps {
+		switch cur := st.(type) {
+		case resampleStep:
+			if len(out) > 0 {
+				if prev, ok := out[len(out)-1].(resampleStep); ok && prev.Filter == cur.Filter && prev.Linear == cur.Linear {
+					pop()
+				}
+			}
+			if cur.outputSize(size) == size {
+				continue
+			}
+		case placeStep:
+			if !cur.Layout.placed() {
+				continue
+			}
+			if len(out) > 0 && cur.Layout.inside() {
+				if prev, ok := out[len(out)-1].(placeStep); ok {
+					pop()
+					prev.Layout.Width, prev.Layout.Height = cur.Layout.Width, cur.Layout.Height
+					prev.Layout.Offset = prev.Layout.Offset.Add(cur.Layout.Offset)
+					if !prev.Layout.placed() {
+						continue
+					}
+					st = prev
+				}
+			}
+		case orientStep:
+			if len(out) > 0 {
+				if prev, ok := out[len(out)-1].(orientStep); ok {
+					pop()
+					cur.Orientation = prev.Orientation.then(cur.Orientation)
+					st = cur
+				}
+			}
+			if cur.Orientation.identity() {
+				continue
+			}
+		}
+		out = append(out, st)
+		inputs = append(inputs, size)
+		size = st.outputSize(size)
+	}
+	return out
+}
+
+// runStepsCPU runs planned steps on img. It also returns the windows
+// chosen by the smart crops among them, in order. It stops with the error
+// of ctx between steps, and between the seams of a seam carve, once ctx is
+// done.
+func runStepsCPU(ctx context.Context, img *image.NRGBA, steps []step) (*image.NRGBA, []image.Rectangle, error) {
+	var crops []image.Rectangle
+	for _, st := range steps {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		switch st := st.(type) {
+		case smartCropStep:
+			var r image.Rectangle
+			img, r = st.choose(img)
+			crops = append(crops, r)
+		case carveStep:
+			var err error
+			if img, err = st.carve(ctx, img); err != nil {
+				return nil, nil, err
+			}
+		default:
+			img = st.cpu(img)
+		}
+	}
+	return img, crops, nil
+}
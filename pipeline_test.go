@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+// crop returns the step cutting r out of an image of the given size
+func crop(width, height int, r image.Rectangle) step {
+	return placeStep{Layout: fitLayout{ScaledWidth: width, ScaledHeight: height, Width: r.Dx(), Height: r.Dy(), Offset: r.Min}}
+}
+
+func TestOptimizeSteps(t *testing.T) {
+	src := image.Pt(100, 50)
+	contain := planFit(100, 50, 40, 40, FitContain)
+	place := placeStep{Layout: contain, Background: color.NRGBA{A: 255}}
+
+	tests := []struct {
+		name  string
+		steps []step
+		want  []step
+	}{
+		{
+			"same filter merged",
+			[]step{resampleStep{Width: 50, Height: 25, Filter: FilterLanczos3}, resampleStep{Width: 20, Height: 10, Filter: FilterLanczos3}},
+			[]step{resampleStep{Width: 20, Height: 10, Filter: FilterLanczos3}},
+		},
+		{
+			"three merged",
+			[]step{resampleStep{Width: 50, Height: 25, Filter: FilterBox}, resampleStep{Width: 200, Height: 100, Filter: FilterBox}, resampleStep{Width: 30, Height: 15, Filter: FilterBox}},
+			[]step{resampleStep{Width: 30, Height: 15, Filter: FilterBox}},
+		},
+		{
+			"different filters kept",
+			[]step{resampleStep{Width: 10, Height: 5, Filter: FilterBox}, resampleStep{Width: 40, Height: 20, Filter: FilterNearest}},
+			[]step{resampleStep{Width: 10, Height: 5, Filter: FilterBox}, resampleStep{Width: 40, Height: 20, Filter: FilterNearest}},
+		},
+		{
+			"linear light kept apart",
+			[]step{resampleStep{Width: 50, Height: 25, Filter: FilterLanczos3, Linear: true}, resampleStep{Width: 20, Height: 10, Filter: FilterLanczos3}},
+			[]step{resampleStep{Width: 50, Height: 25, Filter: FilterLanczos3, Linear: true}, resampleStep{Width: 20, Height: 10, Filter: FilterLanczos3}},
+		},
+		{
+			"linear light merged",
+			[]step{resampleStep{Width: 50, Height: 25, Filter: FilterMitchell, Linear: true}, resampleStep{Width: 20, Height: 10, Filter: FilterMitchell, Linear: true}},
+			[]step{resampleStep{Width: 20, Height: 10, Filter: FilterMitchell, Linear: true}},
+		},
+		{
+			"merged back to the source size dropped",
+			[]step{resampleStep{Width: 50, Height: 25, Filter: FilterBilinear}, resampleStep{Width: 100, Height: 50, Filter: FilterBilinear}},
+			nil,
+		},
+		{
+			"same size dropped",
+			[]step{resampleStep{Width: 100, Height: 50, Filter: FilterLanczos3}, resampleStep{Width: 20, Height: 10, Filter: FilterBox}},
+			[]step{resampleStep{Width: 20, Height: 10, Filter: FilterBox}},
+		},
+		{
+			"placement between resamples",
+			[]step{resampleStep{Width: contain.ScaledWidth, Height: contain.ScaledHeight, Filter: FilterLanczos3}, place, resampleStep{Width: 20, Height: 20, Filter: FilterLanczos3}},
+			[]step{resampleStep{Width: contain.ScaledWidth, Height: contain.ScaledHeight, Filter: FilterLanczos3}, place, resampleStep{Width: 20, Height: 20, Filter: FilterLanczos3}},
+		},
+		{
+			"crops folded",
+			[]step{crop(100, 50, image.Rect(10, 10, 90, 40)), crop(80, 30, image.Rect(5, 0, 45, 20))},
+			[]step{crop(100, 50, image.Rect(15, 10, 55, 30))},
+		},
+		{
+			"crop folded into padding",
+			[]step{resampleStep{Width: contain.ScaledWidth, Height: contain.ScaledHeight, Filter: FilterLanczos3}, place, crop(40, 40, image.Rect(0, 5, 40, 35))},
+			[]step{
+				resampleStep{Width: contain.ScaledWidth, Height: contain.ScaledHeight, Filter: FilterLanczos3},
+				placeStep{Layout: fitLayout{ScaledWidth: 40, ScaledHeight: 20, Width: 40, Height: 30, Offset: image.Pt(0, -5)}, Background: place.Background},
+			},
+		},
+		{
+			"crops back to the whole image dropped",
+			[]step{crop(100, 50, image.Rect(0, 0, 100, 50)), resampleStep{Width: 20, Height: 10, Filter: FilterBox}},
+			[]step{resampleStep{Width: 20, Height: 10, Filter: FilterBox}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := optimizeSteps(tt.steps, src); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanPipeline(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []Operation
+		want []step
+		size image.Point // Size of the result
+	}{
+		{
+			"fill",
+			[]Operation{ResizeOp{Width: 30, Height: 30, Filter: FilterBox}},
+			[]step{resampleStep{Width: 30, Height: 30, Filter: FilterBox}},
+			image.Pt(30, 30),
+		},
+		{
+			"contain pads",
+			[]Operation{ResizeOp{Width: 40, Height: 40, Filter: FilterLanczos3, Fit: FitContain}},
+			[]step{resampleStep{Width: 40, Height: 20, Filter: FilterLanczos3}, placeStep{Layout: planFit(80, 40, 40, 40, FitContain)}},
+			image.Pt(40, 40),
+		},
+		{
+			"derived height",
+			[]Operation{ResizeOp{Width: 20, Filter: FilterLanczos3}},
+			[]step{resampleStep{Width: 20, Height: 10, Filter: FilterLanczos3}},
+			image.Pt(20, 10),
+		},
+		{
+			// The second resize is planned against the 40x20 result of
+			// the first, then both are merged
+			"consecutive resizes",
+			[]Operation{ResizeOp{Width: 40, Filter: FilterLanczos3}, ResizeOp{Height: 5, Filter: FilterLanczos3}},
+			[]step{resampleStep{Width: 10, Height: 5, Filter: FilterLanczos3}},
+			image.Pt(10, 5),
+		},
+		{
+			"no change",
+			[]Operation{ResizeOp{Width: 80, Height: 40, Filter: FilterLanczos3}},
+			nil,
+			image.Pt(80, 40),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, _, err := planPipeline(tt.ops, image.Pt(80, 40), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(steps, tt.want) {
+				t.Errorf("got %v, want %v", steps, tt.want)
+			}
+			if out, _, _ := runStepsCPU(context.Background(), image.NewNRGBA(image.Rect(0, 0, 80, 40)), steps); out.Bounds().Size() != tt.size {
+				t.Errorf("result is %v, want %v", out.Bounds().Size(), tt.size)
+			}
+		})
+	}
+}
+
+// TestPlanPipelineLimitsSize checks that a size derived from the aspect
+// ratio is held to maxOutputPixels
+func TestPlanPipelineLimitsSize(t *testing.T) {
+	_, _, err := planPipeline([]Operation{ResizeOp{Width: 1 << 16}}, image.Pt(10, 1000), nil)
+	if !errors.Is(err, errInvalidRequest) {
+		t.Errorf("got %v, want an invalid request", err)
+	}
+	if _, _, err := planPipeline([]Operation{ResizeOp{Width: 1 << 9}}, image.Pt(10, 1000), nil); err != nil {
+		t.Errorf("resize under the limit: %v", err)
+	}
+}
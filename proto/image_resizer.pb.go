@@ -21,32 +21,2980 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Resampling filter used when scaling
+type Filter int32
+
+const (
+	Filter_FILTER_UNSPECIFIED Filter = 0 // Lanczos3
+	Filter_FILTER_NEAREST     Filter = 1
+	Filter_FILTER_BILINEAR    Filter = 2
+	Filter_FILTER_BICUBIC     Filter = 3 // Catmull-Rom
+	Filter_FILTER_MITCHELL    Filter = 4 // Mitchell-Netravali
+	Filter_FILTER_LANCZOS2    Filter = 5
+	Filter_FILTER_LANCZOS3    Filter = 6
+	Filter_FILTER_BOX         Filter = 7 // Area average
+)
+
+// Enum value maps for Filter.
+var (
+	Filter_name = map[int32]string{
+		0: "FILTER_UNSPECIFIED",
+		1: "FILTER_NEAREST",
+		2: "FILTER_BILINEAR",
+		3: "FILTER_BICUBIC",
+		4: "FILTER_MITCHELL",
+		5: "FILTER_LANCZOS2",
+		6: "FILTER_LANCZOS3",
+		7: "FILTER_BOX",
+	}
+	Filter_value = map[string]int32{
+		"FILTER_UNSPECIFIED": 0,
+		"FILTER_NEAREST":     1,
+		"FILTER_BILINEAR":    2,
+		"FILTER_BICUBIC":     3,
+		"FILTER_MITCHELL":    4,
+		"FILTER_LANCZOS2":    5,
+		"FILTER_LANCZOS3":    6,
+		"FILTER_BOX":         7,
+	}
+)
+
+func (x Filter) Enum() *Filter {
+	p := new(Filter)
+	*p = x
+	return p
+}
+
+func (x Filter) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Filter) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_image_resizer_proto_enumTypes[0].Descriptor()
+}
+
+func (Filter) Type() protoreflect.EnumType {
+	return &file_proto_image_resizer_proto_enumTypes[0]
+}
+
+func (x Filter) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Filter.Descriptor instead.
+func (Filter) EnumDescriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{0}
+}
+
+// How the image is fitted into the requested width x height
+type Fit int32
+
+const (
+	Fit_FIT_FILL       Fit = 0 // Stretch to exactly width x height
+	Fit_FIT_CONTAIN    Fit = 1 // Keep aspect ratio, pad with background to width x height
+	Fit_FIT_COVER      Fit = 2 // Keep aspect ratio, crop to width x height
+	Fit_FIT_INSIDE     Fit = 3 // Keep aspect ratio, fit within width x height
+	Fit_FIT_OUTSIDE    Fit = 4 // Keep aspect ratio, cover width x height without cropping
+	Fit_FIT_SEAM_CARVE Fit = 5 // Scale down to cover width x height, then remove or insert low-energy seams to fill it
+)
+
+// Enum value maps for Fit.
+var (
+	Fit_name = map[int32]string{
+		0: "FIT_FILL",
+		1: "FIT_CONTAIN",
+		2: "FIT_COVER",
+		3: "FIT_INSIDE",
+		4: "FIT_OUTSIDE",
+		5: "FIT_SEAM_CARVE",
+	}
+	Fit_value = map[string]int32{
+		"FIT_FILL":       0,
+		"FIT_CONTAIN":    1,
+		"FIT_COVER":      2,
+		"FIT_INSIDE":     3,
+		"FIT_OUTSIDE":    4,
+		"FIT_SEAM_CARVE": 5,
+	}
+)
+
+func (x Fit) Enum() *Fit {
+	p := new(Fit)
+	*p = x
+	return p
+}
+
+func (x Fit) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Fit) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_image_resizer_proto_enumTypes[1].Descriptor()
+}
+
+func (Fit) Type() protoreflect.EnumType {
+	return &file_proto_image_resizer_proto_enumTypes[1]
+}
+
+func (x Fit) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Fit.Descriptor instead.
+func (Fit) EnumDescriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{1}
+}
+
+// Encoding of the result
+type OutputFormat int32
+
+const (
+	OutputFormat_OUTPUT_FORMAT_SAME_AS_INPUT OutputFormat = 0 // Input format, PNG for inputs that cannot be encoded or JPEG results with transparency
+	OutputFormat_OUTPUT_FORMAT_JPEG          OutputFormat = 1
+	OutputFormat_OUTPUT_FORMAT_PNG           OutputFormat = 2
+	OutputFormat_OUTPUT_FORMAT_GIF           OutputFormat = 3
+	OutputFormat_OUTPUT_FORMAT_WEBP          OutputFormat = 4 // Lossless
+)
+
+// Enum value maps for OutputFormat.
+var (
+	OutputFormat_name = map[int32]string{
+		0: "OUTPUT_FORMAT_SAME_AS_INPUT",
+		1: "OUTPUT_FORMAT_JPEG",
+		2: "OUTPUT_FORMAT_PNG",
+		3: "OUTPUT_FORMAT_GIF",
+		4: "OUTPUT_FORMAT_WEBP",
+	}
+	OutputFormat_value = map[string]int32{
+		"OUTPUT_FORMAT_SAME_AS_INPUT": 0,
+		"OUTPUT_FORMAT_JPEG":          1,
+		"OUTPUT_FORMAT_PNG":           2,
+		"OUTPUT_FORMAT_GIF":           3,
+		"OUTPUT_FORMAT_WEBP":          4,
+	}
+)
+
+func (x OutputFormat) Enum() *OutputFormat {
+	p := new(OutputFormat)
+	*p = x
+	return p
+}
+
+func (x OutputFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OutputFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_image_resizer_proto_enumTypes[2].Descriptor()
+}
+
+func (OutputFormat) Type() protoreflect.EnumType {
+	return &file_proto_image_resizer_proto_enumTypes[2]
+}
+
+func (x OutputFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OutputFormat.Descriptor instead.
+func (OutputFormat) EnumDescriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{2}
+}
+
+// Where a region smaller than the image is anchored
+type Gravity int32
+
+const (
+	Gravity_GRAVITY_CENTER     Gravity = 0
+	Gravity_GRAVITY_NORTH      Gravity = 1
+	Gravity_GRAVITY_NORTH_EAST Gravity = 2
+	Gravity_GRAVITY_EAST       Gravity = 3
+	Gravity_GRAVITY_SOUTH_EAST Gravity = 4
+	Gravity_GRAVITY_SOUTH      Gravity = 5
+	Gravity_GRAVITY_SOUTH_WEST Gravity = 6
+	Gravity_GRAVITY_WEST       Gravity = 7
+	Gravity_GRAVITY_NORTH_WEST Gravity = 8
+	Gravity_GRAVITY_SMART      Gravity = 9 // Crops only: the region scoring best for edges, detail and skin tones
+)
+
+// Enum value maps for Gravity.
+var (
+	Gravity_name = map[int32]string{
+		0: "GRAVITY_CENTER",
+		1: "GRAVITY_NORTH",
+		2: "GRAVITY_NORTH_EAST",
+		3: "GRAVITY_EAST",
+		4: "GRAVITY_SOUTH_EAST",
+		5: "GRAVITY_SOUTH",
+		6: "GRAVITY_SOUTH_WEST",
+		7: "GRAVITY_WEST",
+		8: "GRAVITY_NORTH_WEST",
+		9: "GRAVITY_SMART",
+	}
+	Gravity_value = map[string]int32{
+		"GRAVITY_CENTER":     0,
+		"GRAVITY_NORTH":      1,
+		"GRAVITY_NORTH_EAST": 2,
+		"GRAVITY_EAST":       3,
+		"GRAVITY_SOUTH_EAST": 4,
+		"GRAVITY_SOUTH":      5,
+		"GRAVITY_SOUTH_WEST": 6,
+		"GRAVITY_WEST":       7,
+		"GRAVITY_NORTH_WEST": 8,
+		"GRAVITY_SMART":      9,
+	}
+)
+
+func (x Gravity) Enum() *Gravity {
+	p := new(Gravity)
+	*p = x
+	return p
+}
+
+func (x Gravity) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Gravity) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_image_resizer_proto_enumTypes[3].Descriptor()
+}
+
+func (Gravity) Type() protoreflect.EnumType {
+	return &file_proto_image_resizer_proto_enumTypes[3]
+}
+
+func (x Gravity) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Gravity.Descriptor instead.
+func (Gravity) EnumDescriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{3}
+}
+
+// How overlay colours combine with the colours below them
+type BlendMode int32
+
+const (
+	BlendMode_BLEND_NORMAL   BlendMode = 0
+	BlendMode_BLEND_MULTIPLY BlendMode = 1 // Darkens
+	BlendMode_BLEND_SCREEN   BlendMode = 2 // Lightens
+)
+
+// Enum value maps for BlendMode.
+var (
+	BlendMode_name = map[int32]string{
+		0: "BLEND_NORMAL",
+		1: "BLEND_MULTIPLY",
+		2: "BLEND_SCREEN",
+	}
+	BlendMode_value = map[string]int32{
+		"BLEND_NORMAL":   0,
+		"BLEND_MULTIPLY": 1,
+		"BLEND_SCREEN":   2,
+	}
+)
+
+func (x BlendMode) Enum() *BlendMode {
+	p := new(BlendMode)
+	*p = x
+	return p
+}
+
+func (x BlendMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BlendMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_image_resizer_proto_enumTypes[4].Descriptor()
+}
+
+func (BlendMode) Type() protoreflect.EnumType {
+	return &file_proto_image_resizer_proto_enumTypes[4]
+}
+
+func (x BlendMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BlendMode.Descriptor instead.
+func (BlendMode) EnumDescriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{4}
+}
+
+// Alignment of the lines of a text block
+type TextAlign int32
+
+const (
+	TextAlign_TEXT_ALIGN_LEFT   TextAlign = 0
+	TextAlign_TEXT_ALIGN_CENTER TextAlign = 1
+	TextAlign_TEXT_ALIGN_RIGHT  TextAlign = 2
+)
+
+// Enum value maps for TextAlign.
+var (
+	TextAlign_name = map[int32]string{
+		0: "TEXT_ALIGN_LEFT",
+		1: "TEXT_ALIGN_CENTER",
+		2: "TEXT_ALIGN_RIGHT",
+	}
+	TextAlign_value = map[string]int32{
+		"TEXT_ALIGN_LEFT":   0,
+		"TEXT_ALIGN_CENTER": 1,
+		"TEXT_ALIGN_RIGHT":  2,
+	}
+)
+
+func (x TextAlign) Enum() *TextAlign {
+	p := new(TextAlign)
+	*p = x
+	return p
+}
+
+func (x TextAlign) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TextAlign) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_image_resizer_proto_enumTypes[5].Descriptor()
+}
+
+func (TextAlign) Type() protoreflect.EnumType {
+	return &file_proto_image_resizer_proto_enumTypes[5]
+}
+
+func (x TextAlign) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TextAlign.Descriptor instead.
+func (TextAlign) EnumDescriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{5}
+}
+
+// JPEG chroma subsampling ratio
+type ChromaSubsampling int32
+
+const (
+	ChromaSubsampling_CHROMA_SUBSAMPLING_420 ChromaSubsampling = 0
+	ChromaSubsampling_CHROMA_SUBSAMPLING_422 ChromaSubsampling = 1
+	ChromaSubsampling_CHROMA_SUBSAMPLING_444 ChromaSubsampling = 2
+)
+
+// Enum value maps for ChromaSubsampling.
+var (
+	ChromaSubsampling_name = map[int32]string{
+		0: "CHROMA_SUBSAMPLING_420",
+		1: "CHROMA_SUBSAMPLING_422",
+		2: "CHROMA_SUBSAMPLING_444",
+	}
+	ChromaSubsampling_value = map[string]int32{
+		"CHROMA_SUBSAMPLING_420": 0,
+		"CHROMA_SUBSAMPLING_422": 1,
+		"CHROMA_SUBSAMPLING_444": 2,
+	}
+)
+
+func (x ChromaSubsampling) Enum() *ChromaSubsampling {
+	p := new(ChromaSubsampling)
+	*p = x
+	return p
+}
+
+func (x ChromaSubsampling) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChromaSubsampling) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_image_resizer_proto_enumTypes[6].Descriptor()
+}
+
+func (ChromaSubsampling) Type() protoreflect.EnumType {
+	return &file_proto_image_resizer_proto_enumTypes[6]
+}
+
+func (x ChromaSubsampling) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChromaSubsampling.Descriptor instead.
+func (ChromaSubsampling) EnumDescriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{6}
+}
+
+// PNG zlib compression level
+type PngCompression int32
+
+const (
+	PngCompression_PNG_COMPRESSION_DEFAULT    PngCompression = 0
+	PngCompression_PNG_COMPRESSION_NONE       PngCompression = 1
+	PngCompression_PNG_COMPRESSION_BEST_SPEED PngCompression = 2
+	PngCompression_PNG_COMPRESSION_BEST       PngCompression = 3
+)
+
+// Enum value maps for PngCompression.
+var (
+	PngCompression_name = map[int32]string{
+		0: "PNG_COMPRESSION_DEFAULT",
+		1: "PNG_COMPRESSION_NONE",
+		2: "PNG_COMPRESSION_BEST_SPEED",
+		3: "PNG_COMPRESSION_BEST",
+	}
+	PngCompression_value = map[string]int32{
+		"PNG_COMPRESSION_DEFAULT":    0,
+		"PNG_COMPRESSION_NONE":       1,
+		"PNG_COMPRESSION_BEST_SPEED": 2,
+		"PNG_COMPRESSION_BEST":       3,
+	}
+)
+
+func (x PngCompression) Enum() *PngCompression {
+	p := new(PngCompression)
+	*p = x
+	return p
+}
+
+func (x PngCompression) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PngCompression) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_image_resizer_proto_enumTypes[7].Descriptor()
+}
+
+func (PngCompression) Type() protoreflect.EnumType {
+	return &file_proto_image_resizer_proto_enumTypes[7]
+}
+
+func (x PngCompression) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PngCompression.Descriptor instead.
+func (PngCompression) EnumDescriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{7}
+}
+
+type JpegOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quality       uint32                 `protobuf:"varint,1,opt,name=quality,proto3" json:"quality,omitempty"` // 1-100, 0 falls back to the request quality
+	Subsampling   ChromaSubsampling      `protobuf:"varint,2,opt,name=subsampling,proto3,enum=proto.ChromaSubsampling" json:"subsampling,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JpegOptions) Reset() {
+	*x = JpegOptions{}
+	mi := &file_proto_image_resizer_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JpegOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JpegOptions) ProtoMessage() {}
+
+func (x *JpegOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JpegOptions.ProtoReflect.Descriptor instead.
+func (*JpegOptions) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *JpegOptions) GetQuality() uint32 {
+	if x != nil {
+		return x.Quality
+	}
+	return 0
+}
+
+func (x *JpegOptions) GetSubsampling() ChromaSubsampling {
+	if x != nil {
+		return x.Subsampling
+	}
+	return ChromaSubsampling_CHROMA_SUBSAMPLING_420
+}
+
+type PngOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Compression   PngCompression         `protobuf:"varint,1,opt,name=compression,proto3,enum=proto.PngCompression" json:"compression,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PngOptions) Reset() {
+	*x = PngOptions{}
+	mi := &file_proto_image_resizer_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PngOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PngOptions) ProtoMessage() {}
+
+func (x *PngOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PngOptions.ProtoReflect.Descriptor instead.
+func (*PngOptions) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PngOptions) GetCompression() PngCompression {
+	if x != nil {
+		return x.Compression
+	}
+	return PngCompression_PNG_COMPRESSION_DEFAULT
+}
+
+type GifOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PaletteSize   uint32                 `protobuf:"varint,1,opt,name=palette_size,json=paletteSize,proto3" json:"palette_size,omitempty"` // 2-256 colours, 0 for 256
+	Dither        bool                   `protobuf:"varint,2,opt,name=dither,proto3" json:"dither,omitempty"`                              // Floyd-Steinberg dithering
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GifOptions) Reset() {
+	*x = GifOptions{}
+	mi := &file_proto_image_resizer_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GifOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GifOptions) ProtoMessage() {}
+
+func (x *GifOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GifOptions.ProtoReflect.Descriptor instead.
+func (*GifOptions) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GifOptions) GetPaletteSize() uint32 {
+	if x != nil {
+		return x.PaletteSize
+	}
+	return 0
+}
+
+func (x *GifOptions) GetDither() bool {
+	if x != nil {
+		return x.Dither
+	}
+	return false
+}
+
+// Format-specific encoder settings; only the options for the output format apply
+type EncodeOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Jpeg          *JpegOptions           `protobuf:"bytes,1,opt,name=jpeg,proto3" json:"jpeg,omitempty"`
+	Png           *PngOptions            `protobuf:"bytes,2,opt,name=png,proto3" json:"png,omitempty"`
+	Gif           *GifOptions            `protobuf:"bytes,3,opt,name=gif,proto3" json:"gif,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EncodeOptions) Reset() {
+	*x = EncodeOptions{}
+	mi := &file_proto_image_resizer_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EncodeOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EncodeOptions) ProtoMessage() {}
+
+func (x *EncodeOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EncodeOptions.ProtoReflect.Descriptor instead.
+func (*EncodeOptions) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *EncodeOptions) GetJpeg() *JpegOptions {
+	if x != nil {
+		return x.Jpeg
+	}
+	return nil
+}
+
+func (x *EncodeOptions) GetPng() *PngOptions {
+	if x != nil {
+		return x.Png
+	}
+	return nil
+}
+
+func (x *EncodeOptions) GetGif() *GifOptions {
+	if x != nil {
+		return x.Gif
+	}
+	return nil
+}
+
+// Automatic format choice: every accepted format is encoded and the smallest
+// one meeting the quality floor is returned
+type FormatNegotiation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accept        []OutputFormat         `protobuf:"varint,1,rep,packed,name=accept,proto3,enum=proto.OutputFormat" json:"accept,omitempty"` // Formats the client can display
+	MinPsnr       float64                `protobuf:"fixed64,2,opt,name=min_psnr,json=minPsnr,proto3" json:"min_psnr,omitempty"`              // Quality floor as PSNR in dB, 0 for 35
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FormatNegotiation) Reset() {
+	*x = FormatNegotiation{}
+	mi := &file_proto_image_resizer_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FormatNegotiation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FormatNegotiation) ProtoMessage() {}
+
+func (x *FormatNegotiation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FormatNegotiation.ProtoReflect.Descriptor instead.
+func (*FormatNegotiation) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FormatNegotiation) GetAccept() []OutputFormat {
+	if x != nil {
+		return x.Accept
+	}
+	return nil
+}
+
+func (x *FormatNegotiation) GetMinPsnr() float64 {
+	if x != nil {
+		return x.MinPsnr
+	}
+	return 0
+}
+
+// One encoding tried during format negotiation
+type FormatCandidate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Format        OutputFormat           `protobuf:"varint,1,opt,name=format,proto3,enum=proto.OutputFormat" json:"format,omitempty"`
+	Size          uint32                 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`             // Encoded size in bytes
+	Psnr          float64                `protobuf:"fixed64,3,opt,name=psnr,proto3" json:"psnr,omitempty"`            // PSNR in dB against the unencoded result, infinite when lossless
+	Acceptable    bool                   `protobuf:"varint,4,opt,name=acceptable,proto3" json:"acceptable,omitempty"` // Meets the quality floor
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FormatCandidate) Reset() {
+	*x = FormatCandidate{}
+	mi := &file_proto_image_resizer_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FormatCandidate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FormatCandidate) ProtoMessage() {}
+
+func (x *FormatCandidate) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FormatCandidate.ProtoReflect.Descriptor instead.
+func (*FormatCandidate) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FormatCandidate) GetFormat() OutputFormat {
+	if x != nil {
+		return x.Format
+	}
+	return OutputFormat_OUTPUT_FORMAT_SAME_AS_INPUT
+}
+
+func (x *FormatCandidate) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FormatCandidate) GetPsnr() float64 {
+	if x != nil {
+		return x.Psnr
+	}
+	return 0
+}
+
+func (x *FormatCandidate) GetAcceptable() bool {
+	if x != nil {
+		return x.Acceptable
+	}
+	return false
+}
+
+// An 8-bit RGBA colour, not premultiplied
+type Color struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	R             uint32                 `protobuf:"varint,1,opt,name=r,proto3" json:"r,omitempty"`
+	G             uint32                 `protobuf:"varint,2,opt,name=g,proto3" json:"g,omitempty"`
+	B             uint32                 `protobuf:"varint,3,opt,name=b,proto3" json:"b,omitempty"`
+	A             uint32                 `protobuf:"varint,4,opt,name=a,proto3" json:"a,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Color) Reset() {
+	*x = Color{}
+	mi := &file_proto_image_resizer_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Color) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Color) ProtoMessage() {}
+
+func (x *Color) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Color.ProtoReflect.Descriptor instead.
+func (*Color) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Color) GetR() uint32 {
+	if x != nil {
+		return x.R
+	}
+	return 0
+}
+
+func (x *Color) GetG() uint32 {
+	if x != nil {
+		return x.G
+	}
+	return 0
+}
+
+func (x *Color) GetB() uint32 {
+	if x != nil {
+		return x.B
+	}
+	return 0
+}
+
+func (x *Color) GetA() uint32 {
+	if x != nil {
+		return x.A
+	}
+	return 0
+}
+
+// An ordered list of operations applied to the decoded image before it is
+// encoded. The server validates the operations, merges steps that can run
+// as one, such as consecutive resizes, and runs them on any backend.
+type Pipeline struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Operations    []*Operation           `protobuf:"bytes,1,rep,name=operations,proto3" json:"operations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pipeline) Reset() {
+	*x = Pipeline{}
+	mi := &file_proto_image_resizer_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pipeline) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pipeline) ProtoMessage() {}
+
+func (x *Pipeline) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pipeline.ProtoReflect.Descriptor instead.
+func (*Pipeline) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Pipeline) GetOperations() []*Operation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+// One step of a Pipeline
+type Operation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Op:
+	//
+	//	*Operation_Resize
+	//	*Operation_Crop
+	//	*Operation_Rotate
+	//	*Operation_Flip
+	//	*Operation_Adjust
+	//	*Operation_Blur
+	//	*Operation_BoxBlur
+	//	*Operation_UnsharpMask
+	//	*Operation_Overlay
+	//	*Operation_Text
+	Op            isOperation_Op `protobuf_oneof:"op"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Operation) Reset() {
+	*x = Operation{}
+	mi := &file_proto_image_resizer_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Operation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Operation) ProtoMessage() {}
+
+func (x *Operation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Operation.ProtoReflect.Descriptor instead.
+func (*Operation) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Operation) GetOp() isOperation_Op {
+	if x != nil {
+		return x.Op
+	}
+	return nil
+}
+
+func (x *Operation) GetResize() *ResizeOperation {
+	if x != nil {
+		if x, ok := x.Op.(*Operation_Resize); ok {
+			return x.Resize
+		}
+	}
+	return nil
+}
+
+func (x *Operation) GetCrop() *CropOperation {
+	if x != nil {
+		if x, ok := x.Op.(*Operation_Crop); ok {
+			return x.Crop
+		}
+	}
+	return nil
+}
+
+func (x *Operation) GetRotate() *RotateOperation {
+	if x != nil {
+		if x, ok := x.Op.(*Operation_Rotate); ok {
+			return x.Rotate
+		}
+	}
+	return nil
+}
+
+func (x *Operation) GetFlip() *FlipOperation {
+	if x != nil {
+		if x, ok := x.Op.(*Operation_Flip); ok {
+			return x.Flip
+		}
+	}
+	return nil
+}
+
+func (x *Operation) GetAdjust() *AdjustOperation {
+	if x != nil {
+		if x, ok := x.Op.(*Operation_Adjust); ok {
+			return x.Adjust
+		}
+	}
+	return nil
+}
+
+func (x *Operation) GetBlur() *BlurOperation {
+	if x != nil {
+		if x, ok := x.Op.(*Operation_Blur); ok {
+			return x.Blur
+		}
+	}
+	return nil
+}
+
+func (x *Operation) GetBoxBlur() *BoxBlurOperation {
+	if x != nil {
+		if x, ok := x.Op.(*Operation_BoxBlur); ok {
+			return x.BoxBlur
+		}
+	}
+	return nil
+}
+
+func (x *Operation) GetUnsharpMask() *UnsharpMaskOperation {
+	if x != nil {
+		if x, ok := x.Op.(*Operation_UnsharpMask); ok {
+			return x.UnsharpMask
+		}
+	}
+	return nil
+}
+
+func (x *Operation) GetOverlay() *OverlayOperation {
+	if x != nil {
+		if x, ok := x.Op.(*Operation_Overlay); ok {
+			return x.Overlay
+		}
+	}
+	return nil
+}
+
+func (x *Operation) GetText() *TextOperation {
+	if x != nil {
+		if x, ok := x.Op.(*Operation_Text); ok {
+			return x.Text
+		}
+	}
+	return nil
+}
+
+type isOperation_Op interface {
+	isOperation_Op()
+}
+
+type Operation_Resize struct {
+	Resize *ResizeOperation `protobuf:"bytes,1,opt,name=resize,proto3,oneof"`
+}
+
+type Operation_Crop struct {
+	Crop *CropOperation `protobuf:"bytes,2,opt,name=crop,proto3,oneof"`
+}
+
+type Operation_Rotate struct {
+	Rotate *RotateOperation `protobuf:"bytes,3,opt,name=rotate,proto3,oneof"`
+}
+
+type Operation_Flip struct {
+	Flip *FlipOperation `protobuf:"bytes,4,opt,name=flip,proto3,oneof"`
+}
+
+type Operation_Adjust struct {
+	Adjust *AdjustOperation `protobuf:"bytes,5,opt,name=adjust,proto3,oneof"`
+}
+
+type Operation_Blur struct {
+	Blur *BlurOperation `protobuf:"bytes,6,opt,name=blur,proto3,oneof"`
+}
+
+type Operation_BoxBlur struct {
+	BoxBlur *BoxBlurOperation `protobuf:"bytes,7,opt,name=box_blur,json=boxBlur,proto3,oneof"`
+}
+
+type Operation_UnsharpMask struct {
+	UnsharpMask *UnsharpMaskOperation `protobuf:"bytes,8,opt,name=unsharp_mask,json=unsharpMask,proto3,oneof"`
+}
+
+type Operation_Overlay struct {
+	Overlay *OverlayOperation `protobuf:"bytes,9,opt,name=overlay,proto3,oneof"`
+}
+
+type Operation_Text struct {
+	Text *TextOperation `protobuf:"bytes,10,opt,name=text,proto3,oneof"`
+}
+
+func (*Operation_Resize) isOperation_Op() {}
+
+func (*Operation_Crop) isOperation_Op() {}
+
+func (*Operation_Rotate) isOperation_Op() {}
+
+func (*Operation_Flip) isOperation_Op() {}
+
+func (*Operation_Adjust) isOperation_Op() {}
+
+func (*Operation_Blur) isOperation_Op() {}
+
+func (*Operation_BoxBlur) isOperation_Op() {}
+
+func (*Operation_UnsharpMask) isOperation_Op() {}
+
+func (*Operation_Overlay) isOperation_Op() {}
+
+func (*Operation_Text) isOperation_Op() {}
+
+// Scale the image; the fields mean the same as in ResizeImageRequest
+type ResizeOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Width         uint32                 `protobuf:"varint,1,opt,name=width,proto3" json:"width,omitempty"`
+	Height        uint32                 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Filter        Filter                 `protobuf:"varint,3,opt,name=filter,proto3,enum=proto.Filter" json:"filter,omitempty"`
+	Fit           Fit                    `protobuf:"varint,4,opt,name=fit,proto3,enum=proto.Fit" json:"fit,omitempty"`
+	Background    *Color                 `protobuf:"bytes,5,opt,name=background,proto3" json:"background,omitempty"`
+	AutoSharpen   bool                   `protobuf:"varint,6,opt,name=auto_sharpen,json=autoSharpen,proto3" json:"auto_sharpen,omitempty"`
+	LinearLight   bool                   `protobuf:"varint,7,opt,name=linear_light,json=linearLight,proto3" json:"linear_light,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResizeOperation) Reset() {
+	*x = ResizeOperation{}
+	mi := &file_proto_image_resizer_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResizeOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResizeOperation) ProtoMessage() {}
+
+func (x *ResizeOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResizeOperation.ProtoReflect.Descriptor instead.
+func (*ResizeOperation) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ResizeOperation) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *ResizeOperation) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ResizeOperation) GetFilter() Filter {
+	if x != nil {
+		return x.Filter
+	}
+	return Filter_FILTER_UNSPECIFIED
+}
+
+func (x *ResizeOperation) GetFit() Fit {
+	if x != nil {
+		return x.Fit
+	}
+	return Fit_FIT_FILL
+}
+
+func (x *ResizeOperation) GetBackground() *Color {
+	if x != nil {
+		return x.Background
+	}
+	return nil
+}
+
+func (x *ResizeOperation) GetAutoSharpen() bool {
+	if x != nil {
+		return x.AutoSharpen
+	}
+	return false
+}
+
+func (x *ResizeOperation) GetLinearLight() bool {
+	if x != nil {
+		return x.LinearLight
+	}
+	return false
+}
+
+// Cut a region out of the image. A region reaching past the image edges is
+// clipped to them; one entirely outside the image is rejected.
+type CropOperation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Region:
+	//
+	//	*CropOperation_Rect
+	//	*CropOperation_Percent
+	//	*CropOperation_Size
+	Region        isCropOperation_Region `protobuf_oneof:"region"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CropOperation) Reset() {
+	*x = CropOperation{}
+	mi := &file_proto_image_resizer_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CropOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CropOperation) ProtoMessage() {}
+
+func (x *CropOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CropOperation.ProtoReflect.Descriptor instead.
+func (*CropOperation) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CropOperation) GetRegion() isCropOperation_Region {
+	if x != nil {
+		return x.Region
+	}
+	return nil
+}
+
+func (x *CropOperation) GetRect() *CropRect {
+	if x != nil {
+		if x, ok := x.Region.(*CropOperation_Rect); ok {
+			return x.Rect
+		}
+	}
+	return nil
+}
+
+func (x *CropOperation) GetPercent() *CropPercent {
+	if x != nil {
+		if x, ok := x.Region.(*CropOperation_Percent); ok {
+			return x.Percent
+		}
+	}
+	return nil
+}
+
+func (x *CropOperation) GetSize() *CropSize {
+	if x != nil {
+		if x, ok := x.Region.(*CropOperation_Size); ok {
+			return x.Size
+		}
+	}
+	return nil
+}
+
+type isCropOperation_Region interface {
+	isCropOperation_Region()
+}
+
+type CropOperation_Rect struct {
+	Rect *CropRect `protobuf:"bytes,1,opt,name=rect,proto3,oneof"` // In pixels
+}
+
+type CropOperation_Percent struct {
+	Percent *CropPercent `protobuf:"bytes,2,opt,name=percent,proto3,oneof"` // In percent of the image size
+}
+
+type CropOperation_Size struct {
+	Size *CropSize `protobuf:"bytes,3,opt,name=size,proto3,oneof"` // A region of a given size, anchored by gravity
+}
+
+func (*CropOperation_Rect) isCropOperation_Region() {}
+
+func (*CropOperation_Percent) isCropOperation_Region() {}
+
+func (*CropOperation_Size) isCropOperation_Region() {}
+
+type CropRect struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             uint32                 `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             uint32                 `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Width         uint32                 `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height        uint32                 `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CropRect) Reset() {
+	*x = CropRect{}
+	mi := &file_proto_image_resizer_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CropRect) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CropRect) ProtoMessage() {}
+
+func (x *CropRect) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CropRect.ProtoReflect.Descriptor instead.
+func (*CropRect) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CropRect) GetX() uint32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *CropRect) GetY() uint32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *CropRect) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *CropRect) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+// A rectangle in percent (0-100) of the image width and height
+type CropPercent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             float64                `protobuf:"fixed64,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             float64                `protobuf:"fixed64,2,opt,name=y,proto3" json:"y,omitempty"`
+	Width         float64                `protobuf:"fixed64,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height        float64                `protobuf:"fixed64,4,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CropPercent) Reset() {
+	*x = CropPercent{}
+	mi := &file_proto_image_resizer_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CropPercent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CropPercent) ProtoMessage() {}
+
+func (x *CropPercent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CropPercent.ProtoReflect.Descriptor instead.
+func (*CropPercent) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CropPercent) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *CropPercent) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *CropPercent) GetWidth() float64 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *CropPercent) GetHeight() float64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type CropSize struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Width         uint32                 `protobuf:"varint,1,opt,name=width,proto3" json:"width,omitempty"`   // 0 keeps the image width
+	Height        uint32                 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"` // 0 keeps the image height
+	Gravity       Gravity                `protobuf:"varint,3,opt,name=gravity,proto3,enum=proto.Gravity" json:"gravity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CropSize) Reset() {
+	*x = CropSize{}
+	mi := &file_proto_image_resizer_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CropSize) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CropSize) ProtoMessage() {}
+
+func (x *CropSize) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CropSize.ProtoReflect.Descriptor instead.
+func (*CropSize) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CropSize) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *CropSize) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *CropSize) GetGravity() Gravity {
+	if x != nil {
+		return x.Gravity
+	}
+	return Gravity_GRAVITY_CENTER
+}
+
+// Turn the image clockwise. Multiples of 90 degrees are exact; other angles
+// enlarge the canvas to the rotated bounds and fill the corners with the
+// background.
+type RotateOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Angle         float64                `protobuf:"fixed64,1,opt,name=angle,proto3" json:"angle,omitempty"` // Degrees, negative turns counter-clockwise
+	Background    *Color                 `protobuf:"bytes,2,opt,name=background,proto3" json:"background,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateOperation) Reset() {
+	*x = RotateOperation{}
+	mi := &file_proto_image_resizer_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateOperation) ProtoMessage() {}
+
+func (x *RotateOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateOperation.ProtoReflect.Descriptor instead.
+func (*RotateOperation) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RotateOperation) GetAngle() float64 {
+	if x != nil {
+		return x.Angle
+	}
+	return 0
+}
+
+func (x *RotateOperation) GetBackground() *Color {
+	if x != nil {
+		return x.Background
+	}
+	return nil
+}
+
+type FlipOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Horizontal    bool                   `protobuf:"varint,1,opt,name=horizontal,proto3" json:"horizontal,omitempty"` // Mirror left to right
+	Vertical      bool                   `protobuf:"varint,2,opt,name=vertical,proto3" json:"vertical,omitempty"`     // Mirror top to bottom
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlipOperation) Reset() {
+	*x = FlipOperation{}
+	mi := &file_proto_image_resizer_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlipOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlipOperation) ProtoMessage() {}
+
+func (x *FlipOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlipOperation.ProtoReflect.Descriptor instead.
+func (*FlipOperation) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *FlipOperation) GetHorizontal() bool {
+	if x != nil {
+		return x.Horizontal
+	}
+	return false
+}
+
+func (x *FlipOperation) GetVertical() bool {
+	if x != nil {
+		return x.Vertical
+	}
+	return false
+}
+
+// Change the colours; unset fields change nothing. Brightness, contrast and
+// gamma apply to each channel, then saturation, hue, grayscale and sepia,
+// which follow the CSS filters of the same names. Alpha is kept.
+type AdjustOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Brightness    float64                `protobuf:"fixed64,1,opt,name=brightness,proto3" json:"brightness,omitempty"` // -1 to 1, added to every channel
+	Contrast      float64                `protobuf:"fixed64,2,opt,name=contrast,proto3" json:"contrast,omitempty"`     // -1 to 1, -1 flattens to mid grey and 1 doubles the contrast
+	Gamma         float64                `protobuf:"fixed64,3,opt,name=gamma,proto3" json:"gamma,omitempty"`           // Greater than 0, 0 for no change; above 1 brightens the mid tones
+	Saturation    float64                `protobuf:"fixed64,4,opt,name=saturation,proto3" json:"saturation,omitempty"` // -1 to 1, -1 removes the colour and 1 doubles it
+	Hue           float64                `protobuf:"fixed64,5,opt,name=hue,proto3" json:"hue,omitempty"`               // Rotation in degrees
+	Grayscale     bool                   `protobuf:"varint,6,opt,name=grayscale,proto3" json:"grayscale,omitempty"`
+	Sepia         bool                   `protobuf:"varint,7,opt,name=sepia,proto3" json:"sepia,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdjustOperation) Reset() {
+	*x = AdjustOperation{}
+	mi := &file_proto_image_resizer_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdjustOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdjustOperation) ProtoMessage() {}
+
+func (x *AdjustOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdjustOperation.ProtoReflect.Descriptor instead.
+func (*AdjustOperation) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AdjustOperation) GetBrightness() float64 {
+	if x != nil {
+		return x.Brightness
+	}
+	return 0
+}
+
+func (x *AdjustOperation) GetContrast() float64 {
+	if x != nil {
+		return x.Contrast
+	}
+	return 0
+}
+
+func (x *AdjustOperation) GetGamma() float64 {
+	if x != nil {
+		return x.Gamma
+	}
+	return 0
+}
+
+func (x *AdjustOperation) GetSaturation() float64 {
+	if x != nil {
+		return x.Saturation
+	}
+	return 0
+}
+
+func (x *AdjustOperation) GetHue() float64 {
+	if x != nil {
+		return x.Hue
+	}
+	return 0
+}
+
+func (x *AdjustOperation) GetGrayscale() bool {
+	if x != nil {
+		return x.Grayscale
+	}
+	return false
+}
+
+func (x *AdjustOperation) GetSepia() bool {
+	if x != nil {
+		return x.Sepia
+	}
+	return false
+}
+
+// Gaussian blur. Colours are weighted by alpha, so transparent pixels do
+// not bleed into their neighbours.
+type BlurOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sigma         float64                `protobuf:"fixed64,1,opt,name=sigma,proto3" json:"sigma,omitempty"` // Standard deviation in pixels, greater than 0 and at most 50
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlurOperation) Reset() {
+	*x = BlurOperation{}
+	mi := &file_proto_image_resizer_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlurOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlurOperation) ProtoMessage() {}
+
+func (x *BlurOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlurOperation.ProtoReflect.Descriptor instead.
+func (*BlurOperation) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BlurOperation) GetSigma() float64 {
+	if x != nil {
+		return x.Sigma
+	}
+	return 0
+}
+
+// Average every pixel with its neighbours within radius
+type BoxBlurOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Radius        uint32                 `protobuf:"varint,1,opt,name=radius,proto3" json:"radius,omitempty"` // 1 to 100
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BoxBlurOperation) Reset() {
+	*x = BoxBlurOperation{}
+	mi := &file_proto_image_resizer_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BoxBlurOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BoxBlurOperation) ProtoMessage() {}
+
+func (x *BoxBlurOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BoxBlurOperation.ProtoReflect.Descriptor instead.
+func (*BoxBlurOperation) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *BoxBlurOperation) GetRadius() uint32 {
+	if x != nil {
+		return x.Radius
+	}
+	return 0
+}
+
+// Sharpen by adding back the difference between the image and a Gaussian
+// blur of it
+type UnsharpMaskOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Radius        float64                `protobuf:"fixed64,1,opt,name=radius,proto3" json:"radius,omitempty"`      // Standard deviation of the blur in pixels, greater than 0 and at most 10
+	Amount        float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`      // 0 to 5, the fraction of the difference added back
+	Threshold     uint32                 `protobuf:"varint,3,opt,name=threshold,proto3" json:"threshold,omitempty"` // 0 to 255, channel differences below this are left alone
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnsharpMaskOperation) Reset() {
+	*x = UnsharpMaskOperation{}
+	mi := &file_proto_image_resizer_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnsharpMaskOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnsharpMaskOperation) ProtoMessage() {}
+
+func (x *UnsharpMaskOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnsharpMaskOperation.ProtoReflect.Descriptor instead.
+func (*UnsharpMaskOperation) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *UnsharpMaskOperation) GetRadius() float64 {
+	if x != nil {
+		return x.Radius
+	}
+	return 0
+}
+
+func (x *UnsharpMaskOperation) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *UnsharpMaskOperation) GetThreshold() uint32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+// Composite a second image, such as a watermark logo, onto the image. The
+// overlay is placed by gravity and offset, or tiled across the image from
+// that position.
+type OverlayOperation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Source:
+	//
+	//	*OverlayOperation_Image
+	//	*OverlayOperation_Asset
+	Source        isOverlayOperation_Source `protobuf_oneof:"source"`
+	Gravity       Gravity                   `protobuf:"varint,3,opt,name=gravity,proto3,enum=proto.Gravity" json:"gravity,omitempty"`
+	OffsetX       int32                     `protobuf:"varint,4,opt,name=offset_x,json=offsetX,proto3" json:"offset_x,omitempty"` // Pixels inwards from the edges named by gravity
+	OffsetY       int32                     `protobuf:"varint,5,opt,name=offset_y,json=offsetY,proto3" json:"offset_y,omitempty"`
+	Opacity       *float64                  `protobuf:"fixed64,6,opt,name=opacity,proto3,oneof" json:"opacity,omitempty"` // 0-1, 1 when unset
+	Scale         float64                   `protobuf:"fixed64,7,opt,name=scale,proto3" json:"scale,omitempty"`           // Overlay width as a fraction (0-1) of the image width, 0 keeps its size
+	Tile          bool                      `protobuf:"varint,8,opt,name=tile,proto3" json:"tile,omitempty"`
+	Blend         BlendMode                 `protobuf:"varint,9,opt,name=blend,proto3,enum=proto.BlendMode" json:"blend,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OverlayOperation) Reset() {
+	*x = OverlayOperation{}
+	mi := &file_proto_image_resizer_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OverlayOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OverlayOperation) ProtoMessage() {}
+
+func (x *OverlayOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OverlayOperation.ProtoReflect.Descriptor instead.
+func (*OverlayOperation) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *OverlayOperation) GetSource() isOverlayOperation_Source {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *OverlayOperation) GetImage() []byte {
+	if x != nil {
+		if x, ok := x.Source.(*OverlayOperation_Image); ok {
+			return x.Image
+		}
+	}
+	return nil
+}
+
+func (x *OverlayOperation) GetAsset() string {
+	if x != nil {
+		if x, ok := x.Source.(*OverlayOperation_Asset); ok {
+			return x.Asset
+		}
+	}
+	return ""
+}
+
+func (x *OverlayOperation) GetGravity() Gravity {
+	if x != nil {
+		return x.Gravity
+	}
+	return Gravity_GRAVITY_CENTER
+}
+
+func (x *OverlayOperation) GetOffsetX() int32 {
+	if x != nil {
+		return x.OffsetX
+	}
+	return 0
+}
+
+func (x *OverlayOperation) GetOffsetY() int32 {
+	if x != nil {
+		return x.OffsetY
+	}
+	return 0
+}
+
+func (x *OverlayOperation) GetOpacity() float64 {
+	if x != nil && x.Opacity != nil {
+		return *x.Opacity
+	}
+	return 0
+}
+
+func (x *OverlayOperation) GetScale() float64 {
+	if x != nil {
+		return x.Scale
+	}
+	return 0
+}
+
+func (x *OverlayOperation) GetTile() bool {
+	if x != nil {
+		return x.Tile
+	}
+	return false
+}
+
+func (x *OverlayOperation) GetBlend() BlendMode {
+	if x != nil {
+		return x.Blend
+	}
+	return BlendMode_BLEND_NORMAL
+}
+
+type isOverlayOperation_Source interface {
+	isOverlayOperation_Source()
+}
+
+type OverlayOperation_Image struct {
+	Image []byte `protobuf:"bytes,1,opt,name=image,proto3,oneof"` // Encoded image in any accepted input format
+}
+
+type OverlayOperation_Asset struct {
+	Asset string `protobuf:"bytes,2,opt,name=asset,proto3,oneof"` // Name of an image registered on the server
+}
+
+func (*OverlayOperation_Image) isOverlayOperation_Source() {}
+
+func (*OverlayOperation_Asset) isOverlayOperation_Source() {}
+
+// Draw text onto the image, such as a caption or a "SOLD" banner. The text
+// wraps at word boundaries to fit the width of the box, and the block of
+// lines is placed in the box by gravity; text taller than the box
+// overflows it.
+type TextOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`                                    // UTF-8, at most 4096 bytes; "\n" starts a new line
+	Font          string                 `protobuf:"bytes,2,opt,name=font,proto3" json:"font,omitempty"`                                    // A built-in or registered font, "regular" when empty
+	Size          float64                `protobuf:"fixed64,3,opt,name=size,proto3" json:"size,omitempty"`                                  // Em size in pixels, greater than 0 and at most 500
+	Color         *Color                 `protobuf:"bytes,4,opt,name=color,proto3" json:"color,omitempty"`                                  // Opaque black when unset
+	StrokeWidth   float64                `protobuf:"fixed64,5,opt,name=stroke_width,json=strokeWidth,proto3" json:"stroke_width,omitempty"` // Outline width in pixels, 0-20
+	StrokeColor   *Color                 `protobuf:"bytes,6,opt,name=stroke_color,json=strokeColor,proto3" json:"stroke_color,omitempty"`   // Opaque black when unset
+	Shadow        *TextShadow            `protobuf:"bytes,7,opt,name=shadow,proto3" json:"shadow,omitempty"`
+	Align         TextAlign              `protobuf:"varint,8,opt,name=align,proto3,enum=proto.TextAlign" json:"align,omitempty"`
+	Box           *CropRect              `protobuf:"bytes,9,opt,name=box,proto3" json:"box,omitempty"`                              // The whole image when unset
+	Gravity       Gravity                `protobuf:"varint,10,opt,name=gravity,proto3,enum=proto.Gravity" json:"gravity,omitempty"` // Position of the text in the box
+	Angle         float64                `protobuf:"fixed64,11,opt,name=angle,proto3" json:"angle,omitempty"`                       // Clockwise rotation in degrees around the centre of the text
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TextOperation) Reset() {
+	*x = TextOperation{}
+	mi := &file_proto_image_resizer_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextOperation) ProtoMessage() {}
+
+func (x *TextOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextOperation.ProtoReflect.Descriptor instead.
+func (*TextOperation) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *TextOperation) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *TextOperation) GetFont() string {
+	if x != nil {
+		return x.Font
+	}
+	return ""
+}
+
+func (x *TextOperation) GetSize() float64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *TextOperation) GetColor() *Color {
+	if x != nil {
+		return x.Color
+	}
+	return nil
+}
+
+func (x *TextOperation) GetStrokeWidth() float64 {
+	if x != nil {
+		return x.StrokeWidth
+	}
+	return 0
+}
+
+func (x *TextOperation) GetStrokeColor() *Color {
+	if x != nil {
+		return x.StrokeColor
+	}
+	return nil
+}
+
+func (x *TextOperation) GetShadow() *TextShadow {
+	if x != nil {
+		return x.Shadow
+	}
+	return nil
+}
+
+func (x *TextOperation) GetAlign() TextAlign {
+	if x != nil {
+		return x.Align
+	}
+	return TextAlign_TEXT_ALIGN_LEFT
+}
+
+func (x *TextOperation) GetBox() *CropRect {
+	if x != nil {
+		return x.Box
+	}
+	return nil
+}
+
+func (x *TextOperation) GetGravity() Gravity {
+	if x != nil {
+		return x.Gravity
+	}
+	return Gravity_GRAVITY_CENTER
+}
+
+func (x *TextOperation) GetAngle() float64 {
+	if x != nil {
+		return x.Angle
+	}
+	return 0
+}
+
+type TextShadow struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OffsetX       int32                  `protobuf:"varint,1,opt,name=offset_x,json=offsetX,proto3" json:"offset_x,omitempty"`
+	OffsetY       int32                  `protobuf:"varint,2,opt,name=offset_y,json=offsetY,proto3" json:"offset_y,omitempty"`
+	Color         *Color                 `protobuf:"bytes,3,opt,name=color,proto3" json:"color,omitempty"` // Translucent black when unset
+	Blur          float64                `protobuf:"fixed64,4,opt,name=blur,proto3" json:"blur,omitempty"` // Standard deviation of the blur in pixels, 0-20
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TextShadow) Reset() {
+	*x = TextShadow{}
+	mi := &file_proto_image_resizer_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextShadow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextShadow) ProtoMessage() {}
+
+func (x *TextShadow) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextShadow.ProtoReflect.Descriptor instead.
+func (*TextShadow) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *TextShadow) GetOffsetX() int32 {
+	if x != nil {
+		return x.OffsetX
+	}
+	return 0
+}
+
+func (x *TextShadow) GetOffsetY() int32 {
+	if x != nil {
+		return x.OffsetY
+	}
+	return 0
+}
+
+func (x *TextShadow) GetColor() *Color {
+	if x != nil {
+		return x.Color
+	}
+	return nil
+}
+
+func (x *TextShadow) GetBlur() float64 {
+	if x != nil {
+		return x.Blur
+	}
+	return 0
+}
+
 type ResizeImageRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ImageData     []byte                 `protobuf:"bytes,1,opt,name=image_data,json=imageData,proto3" json:"image_data,omitempty"` // Raw image bytes
-	Width         uint32                 `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`                         // Desired width
-	Height        uint32                 `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`                       // Desired height
-	Quality       uint32                 `protobuf:"varint,4,opt,name=quality,proto3" json:"quality,omitempty"`                     // JPEG quality (1-100)
-	GpuId         uint32                 `protobuf:"varint,5,opt,name=gpu_id,json=gpuId,proto3" json:"gpu_id,omitempty"`            // Optional GPU ID for multi-GPU setups
+	ImageData     []byte                 `protobuf:"bytes,1,opt,name=image_data,json=imageData,proto3" json:"image_data,omitempty"`                                   // Raw image bytes
+	Width         uint32                 `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`                                                           // Desired width, 0 derives it from the aspect ratio
+	Height        uint32                 `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`                                                         // Desired height, 0 derives it from the aspect ratio
+	Quality       uint32                 `protobuf:"varint,4,opt,name=quality,proto3" json:"quality,omitempty"`                                                       // JPEG quality (1-100), 0 for the default of 75
+	GpuId         *uint32                `protobuf:"varint,5,opt,name=gpu_id,json=gpuId,proto3,oneof" json:"gpu_id,omitempty"`                                        // GPU to run on, the least-loaded GPU when unset
+	Filter        Filter                 `protobuf:"varint,6,opt,name=filter,proto3,enum=proto.Filter" json:"filter,omitempty"`                                       // Resampling filter
+	Fit           Fit                    `protobuf:"varint,7,opt,name=fit,proto3,enum=proto.Fit" json:"fit,omitempty"`                                                // Fit mode
+	Background    *Color                 `protobuf:"bytes,8,opt,name=background,proto3" json:"background,omitempty"`                                                  // Padding colour for FIT_CONTAIN
+	OutputFormat  OutputFormat           `protobuf:"varint,9,opt,name=output_format,json=outputFormat,proto3,enum=proto.OutputFormat" json:"output_format,omitempty"` // Encoding of the result
+	EncodeOptions *EncodeOptions         `protobuf:"bytes,10,opt,name=encode_options,json=encodeOptions,proto3" json:"encode_options,omitempty"`
+	Negotiate     *FormatNegotiation     `protobuf:"bytes,11,opt,name=negotiate,proto3" json:"negotiate,omitempty"` // Choose the format automatically, output_format must be unset
+	// Produce each of these outputs from a single decode, instead of the
+	// output described by the fields above
+	Variants []*OutputVariant `protobuf:"bytes,12,rep,name=variants,proto3" json:"variants,omitempty"`
+	// Operations to run instead of the resize described by width, height,
+	// filter, fit and background, which must be unset
+	Pipeline *Pipeline `protobuf:"bytes,13,opt,name=pipeline,proto3" json:"pipeline,omitempty"`
+	// Turn JPEG images upright according to their EXIF orientation before
+	// any other processing; on when unset
+	AutoOrient *bool `protobuf:"varint,14,opt,name=auto_orient,json=autoOrient,proto3,oneof" json:"auto_orient,omitempty"`
+	// Sharpen after downscaling to restore the detail the resampling filter
+	// softened; the more the image shrinks, the stronger the sharpening
+	AutoSharpen bool `protobuf:"varint,15,opt,name=auto_sharpen,json=autoSharpen,proto3" json:"auto_sharpen,omitempty"`
+	// Resample in linear light with premultiplied alpha, which keeps fine
+	// detail from darkening and semi-transparent edges free of dark halos
+	LinearLight bool `protobuf:"varint,16,opt,name=linear_light,json=linearLight,proto3" json:"linear_light,omitempty"`
+	// What cover crops and gravity crops keep in frame, for the request's
+	// output and every variant
+	Focus         *Focus `protobuf:"bytes,17,opt,name=focus,proto3" json:"focus,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResizeImageRequest) Reset() {
+	*x = ResizeImageRequest{}
+	mi := &file_proto_image_resizer_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResizeImageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResizeImageRequest) ProtoMessage() {}
+
+func (x *ResizeImageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResizeImageRequest.ProtoReflect.Descriptor instead.
+func (*ResizeImageRequest) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ResizeImageRequest) GetImageData() []byte {
+	if x != nil {
+		return x.ImageData
+	}
+	return nil
+}
+
+func (x *ResizeImageRequest) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *ResizeImageRequest) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ResizeImageRequest) GetQuality() uint32 {
+	if x != nil {
+		return x.Quality
+	}
+	return 0
+}
+
+func (x *ResizeImageRequest) GetGpuId() uint32 {
+	if x != nil && x.GpuId != nil {
+		return *x.GpuId
+	}
+	return 0
+}
+
+func (x *ResizeImageRequest) GetFilter() Filter {
+	if x != nil {
+		return x.Filter
+	}
+	return Filter_FILTER_UNSPECIFIED
+}
+
+func (x *ResizeImageRequest) GetFit() Fit {
+	if x != nil {
+		return x.Fit
+	}
+	return Fit_FIT_FILL
+}
+
+func (x *ResizeImageRequest) GetBackground() *Color {
+	if x != nil {
+		return x.Background
+	}
+	return nil
+}
+
+func (x *ResizeImageRequest) GetOutputFormat() OutputFormat {
+	if x != nil {
+		return x.OutputFormat
+	}
+	return OutputFormat_OUTPUT_FORMAT_SAME_AS_INPUT
+}
+
+func (x *ResizeImageRequest) GetEncodeOptions() *EncodeOptions {
+	if x != nil {
+		return x.EncodeOptions
+	}
+	return nil
+}
+
+func (x *ResizeImageRequest) GetNegotiate() *FormatNegotiation {
+	if x != nil {
+		return x.Negotiate
+	}
+	return nil
+}
+
+func (x *ResizeImageRequest) GetVariants() []*OutputVariant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+func (x *ResizeImageRequest) GetPipeline() *Pipeline {
+	if x != nil {
+		return x.Pipeline
+	}
+	return nil
+}
+
+func (x *ResizeImageRequest) GetAutoOrient() bool {
+	if x != nil && x.AutoOrient != nil {
+		return *x.AutoOrient
+	}
+	return false
+}
+
+func (x *ResizeImageRequest) GetAutoSharpen() bool {
+	if x != nil {
+		return x.AutoSharpen
+	}
+	return false
+}
+
+func (x *ResizeImageRequest) GetLinearLight() bool {
+	if x != nil {
+		return x.LinearLight
+	}
+	return false
+}
+
+func (x *ResizeImageRequest) GetFocus() *Focus {
+	if x != nil {
+		return x.Focus
+	}
+	return nil
+}
+
+// Parts of the image to keep in frame, in fractions (0-1) of the width and
+// height of the upright image, for example from an editor or a face
+// detector. Cover crops and gravity crops keep every region whole when
+// they fit together, and otherwise centre on them; the focal point is then
+// centred as far as the regions and the image edges allow.
+type Focus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Point         *FocalPoint            `protobuf:"bytes,1,opt,name=point,proto3" json:"point,omitempty"`
+	Regions       []*FocusRegion         `protobuf:"bytes,2,rep,name=regions,proto3" json:"regions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Focus) Reset() {
+	*x = Focus{}
+	mi := &file_proto_image_resizer_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Focus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Focus) ProtoMessage() {}
+
+func (x *Focus) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Focus.ProtoReflect.Descriptor instead.
+func (*Focus) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *Focus) GetPoint() *FocalPoint {
+	if x != nil {
+		return x.Point
+	}
+	return nil
+}
+
+func (x *Focus) GetRegions() []*FocusRegion {
+	if x != nil {
+		return x.Regions
+	}
+	return nil
+}
+
+type FocalPoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             float64                `protobuf:"fixed64,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             float64                `protobuf:"fixed64,2,opt,name=y,proto3" json:"y,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FocalPoint) Reset() {
+	*x = FocalPoint{}
+	mi := &file_proto_image_resizer_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FocalPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FocalPoint) ProtoMessage() {}
+
+func (x *FocalPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FocalPoint.ProtoReflect.Descriptor instead.
+func (*FocalPoint) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *FocalPoint) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *FocalPoint) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+type FocusRegion struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             float64                `protobuf:"fixed64,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             float64                `protobuf:"fixed64,2,opt,name=y,proto3" json:"y,omitempty"`
+	Width         float64                `protobuf:"fixed64,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height        float64                `protobuf:"fixed64,4,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FocusRegion) Reset() {
+	*x = FocusRegion{}
+	mi := &file_proto_image_resizer_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FocusRegion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FocusRegion) ProtoMessage() {}
+
+func (x *FocusRegion) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FocusRegion.ProtoReflect.Descriptor instead.
+func (*FocusRegion) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *FocusRegion) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *FocusRegion) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *FocusRegion) GetWidth() float64 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *FocusRegion) GetHeight() float64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+// One output of a multi-variant request; the fields mean the same as in
+// ResizeImageRequest
+type OutputVariant struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // Echoed in the result, e.g. "thumbnail" or "hero@2x"
+	Width         uint32                 `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height        uint32                 `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	Quality       uint32                 `protobuf:"varint,4,opt,name=quality,proto3" json:"quality,omitempty"`
+	Filter        Filter                 `protobuf:"varint,5,opt,name=filter,proto3,enum=proto.Filter" json:"filter,omitempty"`
+	Fit           Fit                    `protobuf:"varint,6,opt,name=fit,proto3,enum=proto.Fit" json:"fit,omitempty"`
+	Background    *Color                 `protobuf:"bytes,7,opt,name=background,proto3" json:"background,omitempty"`
+	OutputFormat  OutputFormat           `protobuf:"varint,8,opt,name=output_format,json=outputFormat,proto3,enum=proto.OutputFormat" json:"output_format,omitempty"`
+	EncodeOptions *EncodeOptions         `protobuf:"bytes,9,opt,name=encode_options,json=encodeOptions,proto3" json:"encode_options,omitempty"`
+	Negotiate     *FormatNegotiation     `protobuf:"bytes,10,opt,name=negotiate,proto3" json:"negotiate,omitempty"`
+	Pipeline      *Pipeline              `protobuf:"bytes,11,opt,name=pipeline,proto3" json:"pipeline,omitempty"`
+	AutoSharpen   bool                   `protobuf:"varint,12,opt,name=auto_sharpen,json=autoSharpen,proto3" json:"auto_sharpen,omitempty"`
+	LinearLight   bool                   `protobuf:"varint,13,opt,name=linear_light,json=linearLight,proto3" json:"linear_light,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutputVariant) Reset() {
+	*x = OutputVariant{}
+	mi := &file_proto_image_resizer_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutputVariant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputVariant) ProtoMessage() {}
+
+func (x *OutputVariant) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputVariant.ProtoReflect.Descriptor instead.
+func (*OutputVariant) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *OutputVariant) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *OutputVariant) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *OutputVariant) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *OutputVariant) GetQuality() uint32 {
+	if x != nil {
+		return x.Quality
+	}
+	return 0
+}
+
+func (x *OutputVariant) GetFilter() Filter {
+	if x != nil {
+		return x.Filter
+	}
+	return Filter_FILTER_UNSPECIFIED
+}
+
+func (x *OutputVariant) GetFit() Fit {
+	if x != nil {
+		return x.Fit
+	}
+	return Fit_FIT_FILL
+}
+
+func (x *OutputVariant) GetBackground() *Color {
+	if x != nil {
+		return x.Background
+	}
+	return nil
+}
+
+func (x *OutputVariant) GetOutputFormat() OutputFormat {
+	if x != nil {
+		return x.OutputFormat
+	}
+	return OutputFormat_OUTPUT_FORMAT_SAME_AS_INPUT
+}
+
+func (x *OutputVariant) GetEncodeOptions() *EncodeOptions {
+	if x != nil {
+		return x.EncodeOptions
+	}
+	return nil
+}
+
+func (x *OutputVariant) GetNegotiate() *FormatNegotiation {
+	if x != nil {
+		return x.Negotiate
+	}
+	return nil
+}
+
+func (x *OutputVariant) GetPipeline() *Pipeline {
+	if x != nil {
+		return x.Pipeline
+	}
+	return nil
+}
+
+func (x *OutputVariant) GetAutoSharpen() bool {
+	if x != nil {
+		return x.AutoSharpen
+	}
+	return false
+}
+
+func (x *OutputVariant) GetLinearLight() bool {
+	if x != nil {
+		return x.LinearLight
+	}
+	return false
+}
+
+// The result for one OutputVariant
+type VariantImage struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Name             string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Image            []byte                 `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+	Width            uint32                 `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height           uint32                 `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	OutputFormat     OutputFormat           `protobuf:"varint,5,opt,name=output_format,json=outputFormat,proto3,enum=proto.OutputFormat" json:"output_format,omitempty"`
+	FormatCandidates []*FormatCandidate     `protobuf:"bytes,6,rep,name=format_candidates,json=formatCandidates,proto3" json:"format_candidates,omitempty"`
+	SmartCrops       []*CropRect            `protobuf:"bytes,7,rep,name=smart_crops,json=smartCrops,proto3" json:"smart_crops,omitempty"`                // Regions picked by smart crops, in pipeline order
+	FocalPointLost   bool                   `protobuf:"varint,8,opt,name=focal_point_lost,json=focalPointLost,proto3" json:"focal_point_lost,omitempty"` // The focal point is outside the image
+	LostRegions      []uint32               `protobuf:"varint,9,rep,packed,name=lost_regions,json=lostRegions,proto3" json:"lost_regions,omitempty"`     // Indices of the focus regions not wholly inside the image
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *VariantImage) Reset() {
+	*x = VariantImage{}
+	mi := &file_proto_image_resizer_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VariantImage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VariantImage) ProtoMessage() {}
+
+func (x *VariantImage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VariantImage.ProtoReflect.Descriptor instead.
+func (*VariantImage) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *VariantImage) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *VariantImage) GetImage() []byte {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *VariantImage) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *VariantImage) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *VariantImage) GetOutputFormat() OutputFormat {
+	if x != nil {
+		return x.OutputFormat
+	}
+	return OutputFormat_OUTPUT_FORMAT_SAME_AS_INPUT
+}
+
+func (x *VariantImage) GetFormatCandidates() []*FormatCandidate {
+	if x != nil {
+		return x.FormatCandidates
+	}
+	return nil
+}
+
+func (x *VariantImage) GetSmartCrops() []*CropRect {
+	if x != nil {
+		return x.SmartCrops
+	}
+	return nil
+}
+
+func (x *VariantImage) GetFocalPointLost() bool {
+	if x != nil {
+		return x.FocalPointLost
+	}
+	return false
+}
+
+func (x *VariantImage) GetLostRegions() []uint32 {
+	if x != nil {
+		return x.LostRegions
+	}
+	return nil
+}
+
+type ResizeImageResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ResizedImage     []byte                 `protobuf:"bytes,1,opt,name=resized_image,json=resizedImage,proto3" json:"resized_image,omitempty"`                          // Resized image bytes
+	UsedGpu          bool                   `protobuf:"varint,2,opt,name=used_gpu,json=usedGpu,proto3" json:"used_gpu,omitempty"`                                        // Indicates if GPU was used
+	ErrorMessage     string                 `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`                          // Error message if applicable
+	GpuId            uint32                 `protobuf:"varint,4,opt,name=gpu_id,json=gpuId,proto3" json:"gpu_id,omitempty"`                                              // GPU that ran the job when used_gpu is set
+	DeviceName       string                 `protobuf:"bytes,5,opt,name=device_name,json=deviceName,proto3" json:"device_name,omitempty"`                                // Name of the device that ran the job
+	Width            uint32                 `protobuf:"varint,6,opt,name=width,proto3" json:"width,omitempty"`                                                           // Final image width
+	Height           uint32                 `protobuf:"varint,7,opt,name=height,proto3" json:"height,omitempty"`                                                         // Final image height
+	OutputFormat     OutputFormat           `protobuf:"varint,8,opt,name=output_format,json=outputFormat,proto3,enum=proto.OutputFormat" json:"output_format,omitempty"` // Encoding of resized_image, never SAME_AS_INPUT
+	FormatCandidates []*FormatCandidate     `protobuf:"bytes,9,rep,name=format_candidates,json=formatCandidates,proto3" json:"format_candidates,omitempty"`              // Encodings tried when negotiating
+	Variants         []*VariantImage        `protobuf:"bytes,10,rep,name=variants,proto3" json:"variants,omitempty"`                                                     // One per requested variant, in order; resized_image is empty
+	SmartCrops       []*CropRect            `protobuf:"bytes,11,rep,name=smart_crops,json=smartCrops,proto3" json:"smart_crops,omitempty"`                               // Regions picked by smart crops, in pipeline order, each in the coordinates of the crop's input
+	FocalPointLost   bool                   `protobuf:"varint,12,opt,name=focal_point_lost,json=focalPointLost,proto3" json:"focal_point_lost,omitempty"`                // The focal point is outside the image
+	LostRegions      []uint32               `protobuf:"varint,13,rep,packed,name=lost_regions,json=lostRegions,proto3" json:"lost_regions,omitempty"`                    // Indices of the focus regions not wholly inside the image
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ResizeImageResponse) Reset() {
+	*x = ResizeImageResponse{}
+	mi := &file_proto_image_resizer_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResizeImageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResizeImageResponse) ProtoMessage() {}
+
+func (x *ResizeImageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResizeImageResponse.ProtoReflect.Descriptor instead.
+func (*ResizeImageResponse) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ResizeImageResponse) GetResizedImage() []byte {
+	if x != nil {
+		return x.ResizedImage
+	}
+	return nil
+}
+
+func (x *ResizeImageResponse) GetUsedGpu() bool {
+	if x != nil {
+		return x.UsedGpu
+	}
+	return false
+}
+
+func (x *ResizeImageResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *ResizeImageResponse) GetGpuId() uint32 {
+	if x != nil {
+		return x.GpuId
+	}
+	return 0
+}
+
+func (x *ResizeImageResponse) GetDeviceName() string {
+	if x != nil {
+		return x.DeviceName
+	}
+	return ""
+}
+
+func (x *ResizeImageResponse) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *ResizeImageResponse) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ResizeImageResponse) GetOutputFormat() OutputFormat {
+	if x != nil {
+		return x.OutputFormat
+	}
+	return OutputFormat_OUTPUT_FORMAT_SAME_AS_INPUT
+}
+
+func (x *ResizeImageResponse) GetFormatCandidates() []*FormatCandidate {
+	if x != nil {
+		return x.FormatCandidates
+	}
+	return nil
+}
+
+func (x *ResizeImageResponse) GetVariants() []*VariantImage {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+func (x *ResizeImageResponse) GetSmartCrops() []*CropRect {
+	if x != nil {
+		return x.SmartCrops
+	}
+	return nil
+}
+
+func (x *ResizeImageResponse) GetFocalPointLost() bool {
+	if x != nil {
+		return x.FocalPointLost
+	}
+	return false
+}
+
+func (x *ResizeImageResponse) GetLostRegions() []uint32 {
+	if x != nil {
+		return x.LostRegions
+	}
+	return nil
+}
+
+// One message of a ResizeImageStream upload
+type ResizeImageChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ResizeImageChunk_Header
+	//	*ResizeImageChunk_Data
+	Payload       isResizeImageChunk_Payload `protobuf_oneof:"payload"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ResizeImageRequest) Reset() {
-	*x = ResizeImageRequest{}
-	mi := &file_proto_image_resizer_proto_msgTypes[0]
+func (x *ResizeImageChunk) Reset() {
+	*x = ResizeImageChunk{}
+	mi := &file_proto_image_resizer_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ResizeImageRequest) String() string {
+func (x *ResizeImageChunk) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResizeImageRequest) ProtoMessage() {}
+func (*ResizeImageChunk) ProtoMessage() {}
 
-func (x *ResizeImageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_image_resizer_proto_msgTypes[0]
+func (x *ResizeImageChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -57,70 +3005,228 @@ func (x *ResizeImageRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResizeImageRequest.ProtoReflect.Descriptor instead.
-func (*ResizeImageRequest) Descriptor() ([]byte, []int) {
-	return file_proto_image_resizer_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use ResizeImageChunk.ProtoReflect.Descriptor instead.
+func (*ResizeImageChunk) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{30}
 }
 
-func (x *ResizeImageRequest) GetImageData() []byte {
+func (x *ResizeImageChunk) GetPayload() isResizeImageChunk_Payload {
 	if x != nil {
-		return x.ImageData
+		return x.Payload
 	}
 	return nil
 }
 
-func (x *ResizeImageRequest) GetWidth() uint32 {
+func (x *ResizeImageChunk) GetHeader() *ResizeImageRequest {
 	if x != nil {
-		return x.Width
+		if x, ok := x.Payload.(*ResizeImageChunk_Header); ok {
+			return x.Header
+		}
 	}
-	return 0
+	return nil
 }
 
-func (x *ResizeImageRequest) GetHeight() uint32 {
+func (x *ResizeImageChunk) GetData() []byte {
 	if x != nil {
-		return x.Height
+		if x, ok := x.Payload.(*ResizeImageChunk_Data); ok {
+			return x.Data
+		}
 	}
-	return 0
+	return nil
 }
 
-func (x *ResizeImageRequest) GetQuality() uint32 {
+type isResizeImageChunk_Payload interface {
+	isResizeImageChunk_Payload()
+}
+
+type ResizeImageChunk_Header struct {
+	Header *ResizeImageRequest `protobuf:"bytes,1,opt,name=header,proto3,oneof"` // First message only; image_data may hold the first bytes
+}
+
+type ResizeImageChunk_Data struct {
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3,oneof"` // Next bytes of the image, in order
+}
+
+func (*ResizeImageChunk_Header) isResizeImageChunk_Payload() {}
+
+func (*ResizeImageChunk_Data) isResizeImageChunk_Payload() {}
+
+// Integrity check sent after the last chunk of each downloaded image
+type DownloadChecksum struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          uint64                 `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`    // Total image size in bytes
+	Sha256        []byte                 `protobuf:"bytes,2,opt,name=sha256,proto3" json:"sha256,omitempty"` // SHA-256 of the complete image
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadChecksum) Reset() {
+	*x = DownloadChecksum{}
+	mi := &file_proto_image_resizer_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadChecksum) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadChecksum) ProtoMessage() {}
+
+func (x *DownloadChecksum) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[31]
 	if x != nil {
-		return x.Quality
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *ResizeImageRequest) GetGpuId() uint32 {
+// Deprecated: Use DownloadChecksum.ProtoReflect.Descriptor instead.
+func (*DownloadChecksum) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *DownloadChecksum) GetSize() uint64 {
 	if x != nil {
-		return x.GpuId
+		return x.Size
 	}
 	return 0
 }
 
-type ResizeImageResponse struct {
+func (x *DownloadChecksum) GetSha256() []byte {
+	if x != nil {
+		return x.Sha256
+	}
+	return nil
+}
+
+// One message of a ResizeImageDownload result
+type ResizeImageDownloadChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ResizeImageDownloadChunk_Metadata
+	//	*ResizeImageDownloadChunk_Data
+	//	*ResizeImageDownloadChunk_Checksum
+	Payload       isResizeImageDownloadChunk_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResizeImageDownloadChunk) Reset() {
+	*x = ResizeImageDownloadChunk{}
+	mi := &file_proto_image_resizer_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResizeImageDownloadChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResizeImageDownloadChunk) ProtoMessage() {}
+
+func (x *ResizeImageDownloadChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResizeImageDownloadChunk.ProtoReflect.Descriptor instead.
+func (*ResizeImageDownloadChunk) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ResizeImageDownloadChunk) GetPayload() isResizeImageDownloadChunk_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ResizeImageDownloadChunk) GetMetadata() *ResizeImageResponse {
+	if x != nil {
+		if x, ok := x.Payload.(*ResizeImageDownloadChunk_Metadata); ok {
+			return x.Metadata
+		}
+	}
+	return nil
+}
+
+func (x *ResizeImageDownloadChunk) GetData() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*ResizeImageDownloadChunk_Data); ok {
+			return x.Data
+		}
+	}
+	return nil
+}
+
+func (x *ResizeImageDownloadChunk) GetChecksum() *DownloadChecksum {
+	if x != nil {
+		if x, ok := x.Payload.(*ResizeImageDownloadChunk_Checksum); ok {
+			return x.Checksum
+		}
+	}
+	return nil
+}
+
+type isResizeImageDownloadChunk_Payload interface {
+	isResizeImageDownloadChunk_Payload()
+}
+
+type ResizeImageDownloadChunk_Metadata struct {
+	Metadata *ResizeImageResponse `protobuf:"bytes,1,opt,name=metadata,proto3,oneof"` // First message; resized_image and the variant images are empty
+}
+
+type ResizeImageDownloadChunk_Data struct {
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3,oneof"` // Next bytes of the current image, in order
+}
+
+type ResizeImageDownloadChunk_Checksum struct {
+	Checksum *DownloadChecksum `protobuf:"bytes,3,opt,name=checksum,proto3,oneof"` // Ends the current image
+}
+
+func (*ResizeImageDownloadChunk_Metadata) isResizeImageDownloadChunk_Payload() {}
+
+func (*ResizeImageDownloadChunk_Data) isResizeImageDownloadChunk_Payload() {}
+
+func (*ResizeImageDownloadChunk_Checksum) isResizeImageDownloadChunk_Payload() {}
+
+// One image of a ResizeBatch stream
+type BatchItem struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ResizedImage  []byte                 `protobuf:"bytes,1,opt,name=resized_image,json=resizedImage,proto3" json:"resized_image,omitempty"` // Resized image bytes
-	UsedGpu       bool                   `protobuf:"varint,2,opt,name=used_gpu,json=usedGpu,proto3" json:"used_gpu,omitempty"`               // Indicates if GPU was used
-	ErrorMessage  string                 `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"` // Error message if applicable
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // Correlation id echoed in the result
+	Request       *ResizeImageRequest    `protobuf:"bytes,2,opt,name=request,proto3" json:"request,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ResizeImageResponse) Reset() {
-	*x = ResizeImageResponse{}
-	mi := &file_proto_image_resizer_proto_msgTypes[1]
+func (x *BatchItem) Reset() {
+	*x = BatchItem{}
+	mi := &file_proto_image_resizer_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ResizeImageResponse) String() string {
+func (x *BatchItem) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResizeImageResponse) ProtoMessage() {}
+func (*BatchItem) ProtoMessage() {}
 
-func (x *ResizeImageResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_image_resizer_proto_msgTypes[1]
+func (x *BatchItem) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -131,26 +3237,88 @@ func (x *ResizeImageResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResizeImageResponse.ProtoReflect.Descriptor instead.
-func (*ResizeImageResponse) Descriptor() ([]byte, []int) {
-	return file_proto_image_resizer_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use BatchItem.ProtoReflect.Descriptor instead.
+func (*BatchItem) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{33}
 }
 
-func (x *ResizeImageResponse) GetResizedImage() []byte {
+func (x *BatchItem) GetId() string {
 	if x != nil {
-		return x.ResizedImage
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BatchItem) GetRequest() *ResizeImageRequest {
+	if x != nil {
+		return x.Request
 	}
 	return nil
 }
 
-func (x *ResizeImageResponse) GetUsedGpu() bool {
+// The outcome of one BatchItem; a failed item does not fail the batch
+type BatchResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Response      *ResizeImageResponse   `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`                             // Set on success
+	Code          uint32                 `protobuf:"varint,3,opt,name=code,proto3" json:"code,omitempty"`                                    // gRPC status code, 0 on success
+	ErrorMessage  string                 `protobuf:"bytes,4,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"` // Set on failure
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchResult) Reset() {
+	*x = BatchResult{}
+	mi := &file_proto_image_resizer_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResult) ProtoMessage() {}
+
+func (x *BatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_resizer_proto_msgTypes[34]
 	if x != nil {
-		return x.UsedGpu
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *ResizeImageResponse) GetErrorMessage() string {
+// Deprecated: Use BatchResult.ProtoReflect.Descriptor instead.
+func (*BatchResult) Descriptor() ([]byte, []int) {
+	return file_proto_image_resizer_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *BatchResult) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BatchResult) GetResponse() *ResizeImageResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *BatchResult) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *BatchResult) GetErrorMessage() string {
 	if x != nil {
 		return x.ErrorMessage
 	}
@@ -162,33 +3330,481 @@ var File_proto_image_resizer_proto protoreflect.FileDescriptor
 var file_proto_image_resizer_proto_rawDesc = string([]byte{
 	0x0a, 0x19, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x72, 0x65,
 	0x73, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x22, 0x92, 0x01, 0x0a, 0x12, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61,
-	0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x69, 0x6d, 0x61,
-	0x67, 0x65, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x69,
-	0x6d, 0x61, 0x67, 0x65, 0x44, 0x61, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74,
-	0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16,
-	0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06,
-	0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74,
-	0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79,
-	0x12, 0x15, 0x0a, 0x06, 0x67, 0x70, 0x75, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d,
-	0x52, 0x05, 0x67, 0x70, 0x75, 0x49, 0x64, 0x22, 0x7a, 0x0a, 0x13, 0x52, 0x65, 0x73, 0x69, 0x7a,
-	0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x23,
-	0x0a, 0x0d, 0x72, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x64, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x72, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x64, 0x49, 0x6d,
-	0x61, 0x67, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x64, 0x5f, 0x67, 0x70, 0x75, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x75, 0x73, 0x65, 0x64, 0x47, 0x70, 0x75, 0x12, 0x23,
-	0x0a, 0x0d, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x32, 0x54, 0x0a, 0x0c, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x73, 0x69,
-	0x7a, 0x65, 0x72, 0x12, 0x44, 0x0a, 0x0b, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61,
-	0x67, 0x65, 0x12, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x73, 0x69, 0x7a,
-	0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61, 0x67,
-	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x2e, 0x5a, 0x2c, 0x67, 0x69, 0x74,
-	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x65, 0x61, 0x75, 0x63, 0x68, 0x74, 0x65,
-	0x72, 0x2f, 0x67, 0x6f, 0x2d, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x2d, 0x61, 0x64, 0x6a, 0x75, 0x73,
-	0x74, 0x65, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	0x74, 0x6f, 0x22, 0x63, 0x0a, 0x0b, 0x4a, 0x70, 0x65, 0x67, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x12, 0x18, 0x0a, 0x07, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x07, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x3a, 0x0a, 0x0b, 0x73,
+	0x75, 0x62, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x68, 0x72, 0x6f, 0x6d, 0x61, 0x53,
+	0x75, 0x62, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x69, 0x6e, 0x67, 0x52, 0x0b, 0x73, 0x75, 0x62, 0x73,
+	0x61, 0x6d, 0x70, 0x6c, 0x69, 0x6e, 0x67, 0x22, 0x45, 0x0a, 0x0a, 0x50, 0x6e, 0x67, 0x4f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x37, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x50, 0x6e, 0x67, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x47,
+	0x0a, 0x0a, 0x47, 0x69, 0x66, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x21, 0x0a, 0x0c,
+	0x70, 0x61, 0x6c, 0x65, 0x74, 0x74, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x0b, 0x70, 0x61, 0x6c, 0x65, 0x74, 0x74, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12,
+	0x16, 0x0a, 0x06, 0x64, 0x69, 0x74, 0x68, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x06, 0x64, 0x69, 0x74, 0x68, 0x65, 0x72, 0x22, 0x81, 0x01, 0x0a, 0x0d, 0x45, 0x6e, 0x63, 0x6f,
+	0x64, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x26, 0x0a, 0x04, 0x6a, 0x70, 0x65,
+	0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x4a, 0x70, 0x65, 0x67, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x04, 0x6a, 0x70, 0x65,
+	0x67, 0x12, 0x23, 0x0a, 0x03, 0x70, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x6e, 0x67, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x52, 0x03, 0x70, 0x6e, 0x67, 0x12, 0x23, 0x0a, 0x03, 0x67, 0x69, 0x66, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x47, 0x69, 0x66, 0x4f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x03, 0x67, 0x69, 0x66, 0x22, 0x5b, 0x0a, 0x11, 0x46,
+	0x6f, 0x72, 0x6d, 0x61, 0x74, 0x4e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x2b, 0x0a, 0x06, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0e,
+	0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x46,
+	0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x06, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x12, 0x19, 0x0a,
+	0x08, 0x6d, 0x69, 0x6e, 0x5f, 0x70, 0x73, 0x6e, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x07, 0x6d, 0x69, 0x6e, 0x50, 0x73, 0x6e, 0x72, 0x22, 0x86, 0x01, 0x0a, 0x0f, 0x46, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x43, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65, 0x12, 0x2b, 0x0a, 0x06,
+	0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x46, 0x6f, 0x72, 0x6d, 0x61,
+	0x74, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x70, 0x73, 0x6e, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x70, 0x73, 0x6e,
+	0x72, 0x12, 0x1e, 0x0a, 0x0a, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x61, 0x62, 0x6c,
+	0x65, 0x22, 0x3f, 0x0a, 0x05, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x0c, 0x0a, 0x01, 0x72, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x01, 0x72, 0x12, 0x0c, 0x0a, 0x01, 0x67, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x01, 0x67, 0x12, 0x0c, 0x0a, 0x01, 0x62, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x01, 0x62, 0x12, 0x0c, 0x0a, 0x01, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x01, 0x61, 0x22, 0x3c, 0x0a, 0x08, 0x50, 0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x30,
+	0x0a, 0x0a, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4f, 0x70, 0x65, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x22, 0x84, 0x04, 0x0a, 0x09, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x30,
+	0x0a, 0x06, 0x72, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x4f, 0x70, 0x65,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x06, 0x72, 0x65, 0x73, 0x69, 0x7a, 0x65,
+	0x12, 0x2a, 0x0a, 0x04, 0x63, 0x72, 0x6f, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x72, 0x6f, 0x70, 0x4f, 0x70, 0x65, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x04, 0x63, 0x72, 0x6f, 0x70, 0x12, 0x30, 0x0a, 0x06,
+	0x72, 0x6f, 0x74, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x6f, 0x74, 0x61, 0x74, 0x65, 0x4f, 0x70, 0x65, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x06, 0x72, 0x6f, 0x74, 0x61, 0x74, 0x65, 0x12, 0x2a,
+	0x0a, 0x04, 0x66, 0x6c, 0x69, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x46, 0x6c, 0x69, 0x70, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x48, 0x00, 0x52, 0x04, 0x66, 0x6c, 0x69, 0x70, 0x12, 0x30, 0x0a, 0x06, 0x61, 0x64,
+	0x6a, 0x75, 0x73, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x41, 0x64, 0x6a, 0x75, 0x73, 0x74, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x48, 0x00, 0x52, 0x06, 0x61, 0x64, 0x6a, 0x75, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x04,
+	0x62, 0x6c, 0x75, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x42, 0x6c, 0x75, 0x72, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x48, 0x00, 0x52, 0x04, 0x62, 0x6c, 0x75, 0x72, 0x12, 0x34, 0x0a, 0x08, 0x62, 0x6f, 0x78, 0x5f,
+	0x62, 0x6c, 0x75, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x42, 0x6f, 0x78, 0x42, 0x6c, 0x75, 0x72, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x07, 0x62, 0x6f, 0x78, 0x42, 0x6c, 0x75, 0x72, 0x12, 0x40,
+	0x0a, 0x0c, 0x75, 0x6e, 0x73, 0x68, 0x61, 0x72, 0x70, 0x5f, 0x6d, 0x61, 0x73, 0x6b, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x55, 0x6e, 0x73,
+	0x68, 0x61, 0x72, 0x70, 0x4d, 0x61, 0x73, 0x6b, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x48, 0x00, 0x52, 0x0b, 0x75, 0x6e, 0x73, 0x68, 0x61, 0x72, 0x70, 0x4d, 0x61, 0x73, 0x6b,
+	0x12, 0x33, 0x0a, 0x07, 0x6f, 0x76, 0x65, 0x72, 0x6c, 0x61, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4f, 0x76, 0x65, 0x72, 0x6c, 0x61,
+	0x79, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x07, 0x6f, 0x76,
+	0x65, 0x72, 0x6c, 0x61, 0x79, 0x12, 0x2a, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x54, 0x65, 0x78, 0x74,
+	0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x04, 0x74, 0x65, 0x78,
+	0x74, 0x42, 0x04, 0x0a, 0x02, 0x6f, 0x70, 0x22, 0xf8, 0x01, 0x0a, 0x0f, 0x52, 0x65, 0x73, 0x69,
+	0x7a, 0x65, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x77,
+	0x69, 0x64, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74,
+	0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x25, 0x0a, 0x06, 0x66, 0x69, 0x6c,
+	0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72,
+	0x12, 0x1c, 0x0a, 0x03, 0x66, 0x69, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x46, 0x69, 0x74, 0x52, 0x03, 0x66, 0x69, 0x74, 0x12, 0x2c,
+	0x0a, 0x0a, 0x62, 0x61, 0x63, 0x6b, 0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x6f, 0x6c, 0x6f, 0x72,
+	0x52, 0x0a, 0x62, 0x61, 0x63, 0x6b, 0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x21, 0x0a, 0x0c,
+	0x61, 0x75, 0x74, 0x6f, 0x5f, 0x73, 0x68, 0x61, 0x72, 0x70, 0x65, 0x6e, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0b, 0x61, 0x75, 0x74, 0x6f, 0x53, 0x68, 0x61, 0x72, 0x70, 0x65, 0x6e, 0x12,
+	0x21, 0x0a, 0x0c, 0x6c, 0x69, 0x6e, 0x65, 0x61, 0x72, 0x5f, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x6c, 0x69, 0x6e, 0x65, 0x61, 0x72, 0x4c, 0x69, 0x67,
+	0x68, 0x74, 0x22, 0x97, 0x01, 0x0a, 0x0d, 0x43, 0x72, 0x6f, 0x70, 0x4f, 0x70, 0x65, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x04, 0x72, 0x65, 0x63, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x72, 0x6f, 0x70, 0x52,
+	0x65, 0x63, 0x74, 0x48, 0x00, 0x52, 0x04, 0x72, 0x65, 0x63, 0x74, 0x12, 0x2e, 0x0a, 0x07, 0x70,
+	0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x72, 0x6f, 0x70, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74,
+	0x48, 0x00, 0x52, 0x07, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x12, 0x25, 0x0a, 0x04, 0x73,
+	0x69, 0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x43, 0x72, 0x6f, 0x70, 0x53, 0x69, 0x7a, 0x65, 0x48, 0x00, 0x52, 0x04, 0x73, 0x69,
+	0x7a, 0x65, 0x42, 0x08, 0x0a, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x22, 0x54, 0x0a, 0x08,
+	0x43, 0x72, 0x6f, 0x70, 0x52, 0x65, 0x63, 0x74, 0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x01, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65,
+	0x69, 0x67, 0x68, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x22, 0x57, 0x0a, 0x0b, 0x43, 0x72, 0x6f, 0x70, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e,
+	0x74, 0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x01, 0x78, 0x12,
+	0x0c, 0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x01, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x77, 0x69,
+	0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x62, 0x0a, 0x08, 0x43,
+	0x72, 0x6f, 0x70, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a,
+	0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x68,
+	0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x28, 0x0a, 0x07, 0x67, 0x72, 0x61, 0x76, 0x69, 0x74, 0x79,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x47,
+	0x72, 0x61, 0x76, 0x69, 0x74, 0x79, 0x52, 0x07, 0x67, 0x72, 0x61, 0x76, 0x69, 0x74, 0x79, 0x22,
+	0x55, 0x0a, 0x0f, 0x52, 0x6f, 0x74, 0x61, 0x74, 0x65, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x05, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x12, 0x2c, 0x0a, 0x0a, 0x62, 0x61, 0x63, 0x6b,
+	0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x52, 0x0a, 0x62, 0x61, 0x63, 0x6b,
+	0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x22, 0x4b, 0x0a, 0x0d, 0x46, 0x6c, 0x69, 0x70, 0x4f, 0x70,
+	0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x68, 0x6f, 0x72, 0x69, 0x7a,
+	0x6f, 0x6e, 0x74, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x68, 0x6f, 0x72,
+	0x69, 0x7a, 0x6f, 0x6e, 0x74, 0x61, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x76, 0x65, 0x72, 0x74, 0x69,
+	0x63, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x76, 0x65, 0x72, 0x74, 0x69,
+	0x63, 0x61, 0x6c, 0x22, 0xc9, 0x01, 0x0a, 0x0f, 0x41, 0x64, 0x6a, 0x75, 0x73, 0x74, 0x4f, 0x70,
+	0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x62, 0x72, 0x69, 0x67, 0x68,
+	0x74, 0x6e, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x62, 0x72, 0x69,
+	0x67, 0x68, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x6f, 0x6e, 0x74, 0x72,
+	0x61, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x63, 0x6f, 0x6e, 0x74, 0x72,
+	0x61, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x67, 0x61, 0x6d, 0x6d, 0x61, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x05, 0x67, 0x61, 0x6d, 0x6d, 0x61, 0x12, 0x1e, 0x0a, 0x0a, 0x73, 0x61, 0x74,
+	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x73,
+	0x61, 0x74, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x68, 0x75, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x68, 0x75, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x67,
+	0x72, 0x61, 0x79, 0x73, 0x63, 0x61, 0x6c, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09,
+	0x67, 0x72, 0x61, 0x79, 0x73, 0x63, 0x61, 0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x65, 0x70,
+	0x69, 0x61, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x73, 0x65, 0x70, 0x69, 0x61, 0x22,
+	0x25, 0x0a, 0x0d, 0x42, 0x6c, 0x75, 0x72, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x14, 0x0a, 0x05, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x05, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x22, 0x2a, 0x0a, 0x10, 0x42, 0x6f, 0x78, 0x42, 0x6c, 0x75,
+	0x72, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x61,
+	0x64, 0x69, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x72, 0x61, 0x64, 0x69,
+	0x75, 0x73, 0x22, 0x64, 0x0a, 0x14, 0x55, 0x6e, 0x73, 0x68, 0x61, 0x72, 0x70, 0x4d, 0x61, 0x73,
+	0x6b, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x61,
+	0x64, 0x69, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x72, 0x61, 0x64, 0x69,
+	0x75, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x68,
+	0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x74,
+	0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x22, 0xa9, 0x02, 0x0a, 0x10, 0x4f, 0x76, 0x65,
+	0x72, 0x6c, 0x61, 0x79, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a,
+	0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x05,
+	0x69, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x05, 0x61, 0x73, 0x73, 0x65, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x61, 0x73, 0x73, 0x65, 0x74, 0x12, 0x28, 0x0a,
+	0x07, 0x67, 0x72, 0x61, 0x76, 0x69, 0x74, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0e,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x47, 0x72, 0x61, 0x76, 0x69, 0x74, 0x79, 0x52, 0x07,
+	0x67, 0x72, 0x61, 0x76, 0x69, 0x74, 0x79, 0x12, 0x19, 0x0a, 0x08, 0x6f, 0x66, 0x66, 0x73, 0x65,
+	0x74, 0x5f, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x6f, 0x66, 0x66, 0x73, 0x65,
+	0x74, 0x58, 0x12, 0x19, 0x0a, 0x08, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x5f, 0x79, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x59, 0x12, 0x1d, 0x0a,
+	0x07, 0x6f, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x48, 0x01,
+	0x52, 0x07, 0x6f, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x88, 0x01, 0x01, 0x12, 0x14, 0x0a, 0x05,
+	0x73, 0x63, 0x61, 0x6c, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x63, 0x61,
+	0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x6c, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x04, 0x74, 0x69, 0x6c, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x62, 0x6c, 0x65, 0x6e, 0x64, 0x18,
+	0x09, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x42, 0x6c,
+	0x65, 0x6e, 0x64, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x05, 0x62, 0x6c, 0x65, 0x6e, 0x64, 0x42, 0x08,
+	0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x6f, 0x70, 0x61,
+	0x63, 0x69, 0x74, 0x79, 0x22, 0xf9, 0x02, 0x0a, 0x0d, 0x54, 0x65, 0x78, 0x74, 0x4f, 0x70, 0x65,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x6f,
+	0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x6f, 0x6e, 0x74, 0x12, 0x12,
+	0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x73, 0x69,
+	0x7a, 0x65, 0x12, 0x22, 0x0a, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x52,
+	0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x72, 0x6f, 0x6b, 0x65,
+	0x5f, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x73, 0x74,
+	0x72, 0x6f, 0x6b, 0x65, 0x57, 0x69, 0x64, 0x74, 0x68, 0x12, 0x2f, 0x0a, 0x0c, 0x73, 0x74, 0x72,
+	0x6f, 0x6b, 0x65, 0x5f, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x52, 0x0b, 0x73,
+	0x74, 0x72, 0x6f, 0x6b, 0x65, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x29, 0x0a, 0x06, 0x73, 0x68,
+	0x61, 0x64, 0x6f, 0x77, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x54, 0x65, 0x78, 0x74, 0x53, 0x68, 0x61, 0x64, 0x6f, 0x77, 0x52, 0x06, 0x73,
+	0x68, 0x61, 0x64, 0x6f, 0x77, 0x12, 0x26, 0x0a, 0x05, 0x61, 0x6c, 0x69, 0x67, 0x6e, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x54, 0x65, 0x78,
+	0x74, 0x41, 0x6c, 0x69, 0x67, 0x6e, 0x52, 0x05, 0x61, 0x6c, 0x69, 0x67, 0x6e, 0x12, 0x21, 0x0a,
+	0x03, 0x62, 0x6f, 0x78, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x43, 0x72, 0x6f, 0x70, 0x52, 0x65, 0x63, 0x74, 0x52, 0x03, 0x62, 0x6f, 0x78,
+	0x12, 0x28, 0x0a, 0x07, 0x67, 0x72, 0x61, 0x76, 0x69, 0x74, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x0e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x47, 0x72, 0x61, 0x76, 0x69, 0x74,
+	0x79, 0x52, 0x07, 0x67, 0x72, 0x61, 0x76, 0x69, 0x74, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6e,
+	0x67, 0x6c, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x61, 0x6e, 0x67, 0x6c, 0x65,
+	0x22, 0x7a, 0x0a, 0x0a, 0x54, 0x65, 0x78, 0x74, 0x53, 0x68, 0x61, 0x64, 0x6f, 0x77, 0x12, 0x19,
+	0x0a, 0x08, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x5f, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x07, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x58, 0x12, 0x19, 0x0a, 0x08, 0x6f, 0x66, 0x66,
+	0x73, 0x65, 0x74, 0x5f, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x6f, 0x66, 0x66,
+	0x73, 0x65, 0x74, 0x59, 0x12, 0x22, 0x0a, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x6f, 0x6c, 0x6f,
+	0x72, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x6c, 0x75, 0x72,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x62, 0x6c, 0x75, 0x72, 0x22, 0xc3, 0x05, 0x0a,
+	0x12, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x64, 0x61, 0x74,
+	0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x44, 0x61,
+	0x74, 0x61, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74,
+	0x12, 0x18, 0x0a, 0x07, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x07, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x1a, 0x0a, 0x06, 0x67, 0x70,
+	0x75, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00, 0x52, 0x05, 0x67, 0x70,
+	0x75, 0x49, 0x64, 0x88, 0x01, 0x01, 0x12, 0x25, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x46,
+	0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x1c, 0x0a,
+	0x03, 0x66, 0x69, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x46, 0x69, 0x74, 0x52, 0x03, 0x66, 0x69, 0x74, 0x12, 0x2c, 0x0a, 0x0a, 0x62,
+	0x61, 0x63, 0x6b, 0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x52, 0x0a, 0x62,
+	0x61, 0x63, 0x6b, 0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x38, 0x0a, 0x0d, 0x6f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x46,
+	0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x0c, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x46, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x12, 0x3b, 0x0a, 0x0e, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x5f, 0x6f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x52, 0x0d, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x12, 0x36, 0x0a, 0x09, 0x6e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x46, 0x6f, 0x72, 0x6d,
+	0x61, 0x74, 0x4e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x6e,
+	0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x12, 0x30, 0x0a, 0x08, 0x76, 0x61, 0x72, 0x69,
+	0x61, 0x6e, 0x74, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x56, 0x61, 0x72, 0x69, 0x61, 0x6e, 0x74,
+	0x52, 0x08, 0x76, 0x61, 0x72, 0x69, 0x61, 0x6e, 0x74, 0x73, 0x12, 0x2b, 0x0a, 0x08, 0x70, 0x69,
+	0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x52, 0x08, 0x70,
+	0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x24, 0x0a, 0x0b, 0x61, 0x75, 0x74, 0x6f, 0x5f,
+	0x6f, 0x72, 0x69, 0x65, 0x6e, 0x74, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x48, 0x01, 0x52, 0x0a,
+	0x61, 0x75, 0x74, 0x6f, 0x4f, 0x72, 0x69, 0x65, 0x6e, 0x74, 0x88, 0x01, 0x01, 0x12, 0x21, 0x0a,
+	0x0c, 0x61, 0x75, 0x74, 0x6f, 0x5f, 0x73, 0x68, 0x61, 0x72, 0x70, 0x65, 0x6e, 0x18, 0x0f, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x0b, 0x61, 0x75, 0x74, 0x6f, 0x53, 0x68, 0x61, 0x72, 0x70, 0x65, 0x6e,
+	0x12, 0x21, 0x0a, 0x0c, 0x6c, 0x69, 0x6e, 0x65, 0x61, 0x72, 0x5f, 0x6c, 0x69, 0x67, 0x68, 0x74,
+	0x18, 0x10, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x6c, 0x69, 0x6e, 0x65, 0x61, 0x72, 0x4c, 0x69,
+	0x67, 0x68, 0x74, 0x12, 0x22, 0x0a, 0x05, 0x66, 0x6f, 0x63, 0x75, 0x73, 0x18, 0x11, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x46, 0x6f, 0x63, 0x75, 0x73,
+	0x52, 0x05, 0x66, 0x6f, 0x63, 0x75, 0x73, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x67, 0x70, 0x75, 0x5f,
+	0x69, 0x64, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x61, 0x75, 0x74, 0x6f, 0x5f, 0x6f, 0x72, 0x69, 0x65,
+	0x6e, 0x74, 0x22, 0x5e, 0x0a, 0x05, 0x46, 0x6f, 0x63, 0x75, 0x73, 0x12, 0x27, 0x0a, 0x05, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x46, 0x6f, 0x63, 0x61, 0x6c, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x05, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x12, 0x2c, 0x0a, 0x07, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x46, 0x6f,
+	0x63, 0x75, 0x73, 0x52, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x72, 0x65, 0x67, 0x69, 0x6f,
+	0x6e, 0x73, 0x22, 0x28, 0x0a, 0x0a, 0x46, 0x6f, 0x63, 0x61, 0x6c, 0x50, 0x6f, 0x69, 0x6e, 0x74,
+	0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x01, 0x78, 0x12, 0x0c,
+	0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x01, 0x79, 0x22, 0x57, 0x0a, 0x0b,
+	0x46, 0x6f, 0x63, 0x75, 0x73, 0x52, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x12, 0x0c, 0x0a, 0x01, 0x78,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x01, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a,
+	0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x68,
+	0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x80, 0x04, 0x0a, 0x0d, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74,
+	0x56, 0x61, 0x72, 0x69, 0x61, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x77,
+	0x69, 0x64, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74,
+	0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x71, 0x75, 0x61,
+	0x6c, 0x69, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x71, 0x75, 0x61, 0x6c,
+	0x69, 0x74, 0x79, 0x12, 0x25, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x46, 0x69, 0x6c, 0x74,
+	0x65, 0x72, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x1c, 0x0a, 0x03, 0x66, 0x69,
+	0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x46, 0x69, 0x74, 0x52, 0x03, 0x66, 0x69, 0x74, 0x12, 0x2c, 0x0a, 0x0a, 0x62, 0x61, 0x63, 0x6b,
+	0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x52, 0x0a, 0x62, 0x61, 0x63, 0x6b,
+	0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x38, 0x0a, 0x0d, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74,
+	0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x46, 0x6f, 0x72, 0x6d,
+	0x61, 0x74, 0x52, 0x0c, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74,
+	0x12, 0x3b, 0x0a, 0x0e, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x5f, 0x6f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x0d,
+	0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x36, 0x0a,
+	0x09, 0x6e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x4e,
+	0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x6e, 0x65, 0x67, 0x6f,
+	0x74, 0x69, 0x61, 0x74, 0x65, 0x12, 0x2b, 0x0a, 0x08, 0x70, 0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e,
+	0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x50, 0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x52, 0x08, 0x70, 0x69, 0x70, 0x65, 0x6c, 0x69,
+	0x6e, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x61, 0x75, 0x74, 0x6f, 0x5f, 0x73, 0x68, 0x61, 0x72, 0x70,
+	0x65, 0x6e, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x61, 0x75, 0x74, 0x6f, 0x53, 0x68,
+	0x61, 0x72, 0x70, 0x65, 0x6e, 0x12, 0x21, 0x0a, 0x0c, 0x6c, 0x69, 0x6e, 0x65, 0x61, 0x72, 0x5f,
+	0x6c, 0x69, 0x67, 0x68, 0x74, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x6c, 0x69, 0x6e,
+	0x65, 0x61, 0x72, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x22, 0xe4, 0x02, 0x0a, 0x0c, 0x56, 0x61, 0x72,
+	0x69, 0x61, 0x6e, 0x74, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a,
+	0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x69, 0x6d,
+	0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69,
+	0x67, 0x68, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68,
+	0x74, 0x12, 0x38, 0x0a, 0x0d, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x66, 0x6f, 0x72, 0x6d,
+	0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x0c, 0x6f,
+	0x75, 0x74, 0x70, 0x75, 0x74, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x43, 0x0a, 0x11, 0x66,
+	0x6f, 0x72, 0x6d, 0x61, 0x74, 0x5f, 0x63, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65, 0x73,
+	0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x46,
+	0x6f, 0x72, 0x6d, 0x61, 0x74, 0x43, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65, 0x52, 0x10,
+	0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x43, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65, 0x73,
+	0x12, 0x30, 0x0a, 0x0b, 0x73, 0x6d, 0x61, 0x72, 0x74, 0x5f, 0x63, 0x72, 0x6f, 0x70, 0x73, 0x18,
+	0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x72,
+	0x6f, 0x70, 0x52, 0x65, 0x63, 0x74, 0x52, 0x0a, 0x73, 0x6d, 0x61, 0x72, 0x74, 0x43, 0x72, 0x6f,
+	0x70, 0x73, 0x12, 0x28, 0x0a, 0x10, 0x66, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x70, 0x6f, 0x69, 0x6e,
+	0x74, 0x5f, 0x6c, 0x6f, 0x73, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x66, 0x6f,
+	0x63, 0x61, 0x6c, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x4c, 0x6f, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0c,
+	0x6c, 0x6f, 0x73, 0x74, 0x5f, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x09, 0x20, 0x03,
+	0x28, 0x0d, 0x52, 0x0b, 0x6c, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x73, 0x22,
+	0x8f, 0x04, 0x0a, 0x13, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x65, 0x73, 0x69, 0x7a,
+	0x65, 0x64, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c,
+	0x72, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x64, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x19, 0x0a, 0x08,
+	0x75, 0x73, 0x65, 0x64, 0x5f, 0x67, 0x70, 0x75, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07,
+	0x75, 0x73, 0x65, 0x64, 0x47, 0x70, 0x75, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x15, 0x0a, 0x06,
+	0x67, 0x70, 0x75, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x67, 0x70,
+	0x75, 0x49, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65,
+	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65,
+	0x69, 0x67, 0x68, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x12, 0x38, 0x0a, 0x0d, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x66, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x0c,
+	0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x43, 0x0a, 0x11,
+	0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x5f, 0x63, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65,
+	0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x43, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65, 0x52,
+	0x10, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x43, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65,
+	0x73, 0x12, 0x2f, 0x0a, 0x08, 0x76, 0x61, 0x72, 0x69, 0x61, 0x6e, 0x74, 0x73, 0x18, 0x0a, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x56, 0x61, 0x72, 0x69,
+	0x61, 0x6e, 0x74, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x08, 0x76, 0x61, 0x72, 0x69, 0x61, 0x6e,
+	0x74, 0x73, 0x12, 0x30, 0x0a, 0x0b, 0x73, 0x6d, 0x61, 0x72, 0x74, 0x5f, 0x63, 0x72, 0x6f, 0x70,
+	0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x43, 0x72, 0x6f, 0x70, 0x52, 0x65, 0x63, 0x74, 0x52, 0x0a, 0x73, 0x6d, 0x61, 0x72, 0x74, 0x43,
+	0x72, 0x6f, 0x70, 0x73, 0x12, 0x28, 0x0a, 0x10, 0x66, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x70, 0x6f,
+	0x69, 0x6e, 0x74, 0x5f, 0x6c, 0x6f, 0x73, 0x74, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e,
+	0x66, 0x6f, 0x63, 0x61, 0x6c, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x4c, 0x6f, 0x73, 0x74, 0x12, 0x21,
+	0x0a, 0x0c, 0x6c, 0x6f, 0x73, 0x74, 0x5f, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0d,
+	0x20, 0x03, 0x28, 0x0d, 0x52, 0x0b, 0x6c, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x67, 0x69, 0x6f, 0x6e,
+	0x73, 0x22, 0x68, 0x0a, 0x10, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65,
+	0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x33, 0x0a, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65,
+	0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x48, 0x00, 0x52, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x04, 0x64, 0x61,
+	0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61,
+	0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x3e, 0x0a, 0x10, 0x44,
+	0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x12,
+	0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x73,
+	0x69, 0x7a, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x22, 0xac, 0x01, 0x0a, 0x18,
+	0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x44, 0x6f, 0x77, 0x6e, 0x6c,
+	0x6f, 0x61, 0x64, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x38, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61,
+	0x74, 0x61, 0x12, 0x14, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x48, 0x00, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x35, 0x0a, 0x08, 0x63, 0x68, 0x65, 0x63,
+	0x6b, 0x73, 0x75, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x43, 0x68, 0x65, 0x63, 0x6b,
+	0x73, 0x75, 0x6d, 0x48, 0x00, 0x52, 0x08, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x42,
+	0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x50, 0x0a, 0x09, 0x42, 0x61,
+	0x74, 0x63, 0x68, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x33, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x8e, 0x01, 0x0a,
+	0x0b, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x36, 0x0a, 0x08,
+	0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61,
+	0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2a, 0xac, 0x01,
+	0x0a, 0x06, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x12, 0x46, 0x49, 0x4c, 0x54,
+	0x45, 0x52, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00,
+	0x12, 0x12, 0x0a, 0x0e, 0x46, 0x49, 0x4c, 0x54, 0x45, 0x52, 0x5f, 0x4e, 0x45, 0x41, 0x52, 0x45,
+	0x53, 0x54, 0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x46, 0x49, 0x4c, 0x54, 0x45, 0x52, 0x5f, 0x42,
+	0x49, 0x4c, 0x49, 0x4e, 0x45, 0x41, 0x52, 0x10, 0x02, 0x12, 0x12, 0x0a, 0x0e, 0x46, 0x49, 0x4c,
+	0x54, 0x45, 0x52, 0x5f, 0x42, 0x49, 0x43, 0x55, 0x42, 0x49, 0x43, 0x10, 0x03, 0x12, 0x13, 0x0a,
+	0x0f, 0x46, 0x49, 0x4c, 0x54, 0x45, 0x52, 0x5f, 0x4d, 0x49, 0x54, 0x43, 0x48, 0x45, 0x4c, 0x4c,
+	0x10, 0x04, 0x12, 0x13, 0x0a, 0x0f, 0x46, 0x49, 0x4c, 0x54, 0x45, 0x52, 0x5f, 0x4c, 0x41, 0x4e,
+	0x43, 0x5a, 0x4f, 0x53, 0x32, 0x10, 0x05, 0x12, 0x13, 0x0a, 0x0f, 0x46, 0x49, 0x4c, 0x54, 0x45,
+	0x52, 0x5f, 0x4c, 0x41, 0x4e, 0x43, 0x5a, 0x4f, 0x53, 0x33, 0x10, 0x06, 0x12, 0x0e, 0x0a, 0x0a,
+	0x46, 0x49, 0x4c, 0x54, 0x45, 0x52, 0x5f, 0x42, 0x4f, 0x58, 0x10, 0x07, 0x2a, 0x68, 0x0a, 0x03,
+	0x46, 0x69, 0x74, 0x12, 0x0c, 0x0a, 0x08, 0x46, 0x49, 0x54, 0x5f, 0x46, 0x49, 0x4c, 0x4c, 0x10,
+	0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x46, 0x49, 0x54, 0x5f, 0x43, 0x4f, 0x4e, 0x54, 0x41, 0x49, 0x4e,
+	0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x46, 0x49, 0x54, 0x5f, 0x43, 0x4f, 0x56, 0x45, 0x52, 0x10,
+	0x02, 0x12, 0x0e, 0x0a, 0x0a, 0x46, 0x49, 0x54, 0x5f, 0x49, 0x4e, 0x53, 0x49, 0x44, 0x45, 0x10,
+	0x03, 0x12, 0x0f, 0x0a, 0x0b, 0x46, 0x49, 0x54, 0x5f, 0x4f, 0x55, 0x54, 0x53, 0x49, 0x44, 0x45,
+	0x10, 0x04, 0x12, 0x12, 0x0a, 0x0e, 0x46, 0x49, 0x54, 0x5f, 0x53, 0x45, 0x41, 0x4d, 0x5f, 0x43,
+	0x41, 0x52, 0x56, 0x45, 0x10, 0x05, 0x2a, 0x8d, 0x01, 0x0a, 0x0c, 0x4f, 0x75, 0x74, 0x70, 0x75,
+	0x74, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x1f, 0x0a, 0x1b, 0x4f, 0x55, 0x54, 0x50, 0x55,
+	0x54, 0x5f, 0x46, 0x4f, 0x52, 0x4d, 0x41, 0x54, 0x5f, 0x53, 0x41, 0x4d, 0x45, 0x5f, 0x41, 0x53,
+	0x5f, 0x49, 0x4e, 0x50, 0x55, 0x54, 0x10, 0x00, 0x12, 0x16, 0x0a, 0x12, 0x4f, 0x55, 0x54, 0x50,
+	0x55, 0x54, 0x5f, 0x46, 0x4f, 0x52, 0x4d, 0x41, 0x54, 0x5f, 0x4a, 0x50, 0x45, 0x47, 0x10, 0x01,
+	0x12, 0x15, 0x0a, 0x11, 0x4f, 0x55, 0x54, 0x50, 0x55, 0x54, 0x5f, 0x46, 0x4f, 0x52, 0x4d, 0x41,
+	0x54, 0x5f, 0x50, 0x4e, 0x47, 0x10, 0x02, 0x12, 0x15, 0x0a, 0x11, 0x4f, 0x55, 0x54, 0x50, 0x55,
+	0x54, 0x5f, 0x46, 0x4f, 0x52, 0x4d, 0x41, 0x54, 0x5f, 0x47, 0x49, 0x46, 0x10, 0x03, 0x12, 0x16,
+	0x0a, 0x12, 0x4f, 0x55, 0x54, 0x50, 0x55, 0x54, 0x5f, 0x46, 0x4f, 0x52, 0x4d, 0x41, 0x54, 0x5f,
+	0x57, 0x45, 0x42, 0x50, 0x10, 0x04, 0x2a, 0xda, 0x01, 0x0a, 0x07, 0x47, 0x72, 0x61, 0x76, 0x69,
+	0x74, 0x79, 0x12, 0x12, 0x0a, 0x0e, 0x47, 0x52, 0x41, 0x56, 0x49, 0x54, 0x59, 0x5f, 0x43, 0x45,
+	0x4e, 0x54, 0x45, 0x52, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x47, 0x52, 0x41, 0x56, 0x49, 0x54,
+	0x59, 0x5f, 0x4e, 0x4f, 0x52, 0x54, 0x48, 0x10, 0x01, 0x12, 0x16, 0x0a, 0x12, 0x47, 0x52, 0x41,
+	0x56, 0x49, 0x54, 0x59, 0x5f, 0x4e, 0x4f, 0x52, 0x54, 0x48, 0x5f, 0x45, 0x41, 0x53, 0x54, 0x10,
+	0x02, 0x12, 0x10, 0x0a, 0x0c, 0x47, 0x52, 0x41, 0x56, 0x49, 0x54, 0x59, 0x5f, 0x45, 0x41, 0x53,
+	0x54, 0x10, 0x03, 0x12, 0x16, 0x0a, 0x12, 0x47, 0x52, 0x41, 0x56, 0x49, 0x54, 0x59, 0x5f, 0x53,
+	0x4f, 0x55, 0x54, 0x48, 0x5f, 0x45, 0x41, 0x53, 0x54, 0x10, 0x04, 0x12, 0x11, 0x0a, 0x0d, 0x47,
+	0x52, 0x41, 0x56, 0x49, 0x54, 0x59, 0x5f, 0x53, 0x4f, 0x55, 0x54, 0x48, 0x10, 0x05, 0x12, 0x16,
+	0x0a, 0x12, 0x47, 0x52, 0x41, 0x56, 0x49, 0x54, 0x59, 0x5f, 0x53, 0x4f, 0x55, 0x54, 0x48, 0x5f,
+	0x57, 0x45, 0x53, 0x54, 0x10, 0x06, 0x12, 0x10, 0x0a, 0x0c, 0x47, 0x52, 0x41, 0x56, 0x49, 0x54,
+	0x59, 0x5f, 0x57, 0x45, 0x53, 0x54, 0x10, 0x07, 0x12, 0x16, 0x0a, 0x12, 0x47, 0x52, 0x41, 0x56,
+	0x49, 0x54, 0x59, 0x5f, 0x4e, 0x4f, 0x52, 0x54, 0x48, 0x5f, 0x57, 0x45, 0x53, 0x54, 0x10, 0x08,
+	0x12, 0x11, 0x0a, 0x0d, 0x47, 0x52, 0x41, 0x56, 0x49, 0x54, 0x59, 0x5f, 0x53, 0x4d, 0x41, 0x52,
+	0x54, 0x10, 0x09, 0x2a, 0x43, 0x0a, 0x09, 0x42, 0x6c, 0x65, 0x6e, 0x64, 0x4d, 0x6f, 0x64, 0x65,
+	0x12, 0x10, 0x0a, 0x0c, 0x42, 0x4c, 0x45, 0x4e, 0x44, 0x5f, 0x4e, 0x4f, 0x52, 0x4d, 0x41, 0x4c,
+	0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x42, 0x4c, 0x45, 0x4e, 0x44, 0x5f, 0x4d, 0x55, 0x4c, 0x54,
+	0x49, 0x50, 0x4c, 0x59, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c, 0x42, 0x4c, 0x45, 0x4e, 0x44, 0x5f,
+	0x53, 0x43, 0x52, 0x45, 0x45, 0x4e, 0x10, 0x02, 0x2a, 0x4d, 0x0a, 0x09, 0x54, 0x65, 0x78, 0x74,
+	0x41, 0x6c, 0x69, 0x67, 0x6e, 0x12, 0x13, 0x0a, 0x0f, 0x54, 0x45, 0x58, 0x54, 0x5f, 0x41, 0x4c,
+	0x49, 0x47, 0x4e, 0x5f, 0x4c, 0x45, 0x46, 0x54, 0x10, 0x00, 0x12, 0x15, 0x0a, 0x11, 0x54, 0x45,
+	0x58, 0x54, 0x5f, 0x41, 0x4c, 0x49, 0x47, 0x4e, 0x5f, 0x43, 0x45, 0x4e, 0x54, 0x45, 0x52, 0x10,
+	0x01, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x45, 0x58, 0x54, 0x5f, 0x41, 0x4c, 0x49, 0x47, 0x4e, 0x5f,
+	0x52, 0x49, 0x47, 0x48, 0x54, 0x10, 0x02, 0x2a, 0x67, 0x0a, 0x11, 0x43, 0x68, 0x72, 0x6f, 0x6d,
+	0x61, 0x53, 0x75, 0x62, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x69, 0x6e, 0x67, 0x12, 0x1a, 0x0a, 0x16,
+	0x43, 0x48, 0x52, 0x4f, 0x4d, 0x41, 0x5f, 0x53, 0x55, 0x42, 0x53, 0x41, 0x4d, 0x50, 0x4c, 0x49,
+	0x4e, 0x47, 0x5f, 0x34, 0x32, 0x30, 0x10, 0x00, 0x12, 0x1a, 0x0a, 0x16, 0x43, 0x48, 0x52, 0x4f,
+	0x4d, 0x41, 0x5f, 0x53, 0x55, 0x42, 0x53, 0x41, 0x4d, 0x50, 0x4c, 0x49, 0x4e, 0x47, 0x5f, 0x34,
+	0x32, 0x32, 0x10, 0x01, 0x12, 0x1a, 0x0a, 0x16, 0x43, 0x48, 0x52, 0x4f, 0x4d, 0x41, 0x5f, 0x53,
+	0x55, 0x42, 0x53, 0x41, 0x4d, 0x50, 0x4c, 0x49, 0x4e, 0x47, 0x5f, 0x34, 0x34, 0x34, 0x10, 0x02,
+	0x2a, 0x81, 0x01, 0x0a, 0x0e, 0x50, 0x6e, 0x67, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x1b, 0x0a, 0x17, 0x50, 0x4e, 0x47, 0x5f, 0x43, 0x4f, 0x4d, 0x50, 0x52,
+	0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x44, 0x45, 0x46, 0x41, 0x55, 0x4c, 0x54, 0x10, 0x00,
+	0x12, 0x18, 0x0a, 0x14, 0x50, 0x4e, 0x47, 0x5f, 0x43, 0x4f, 0x4d, 0x50, 0x52, 0x45, 0x53, 0x53,
+	0x49, 0x4f, 0x4e, 0x5f, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x01, 0x12, 0x1e, 0x0a, 0x1a, 0x50, 0x4e,
+	0x47, 0x5f, 0x43, 0x4f, 0x4d, 0x50, 0x52, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x42, 0x45,
+	0x53, 0x54, 0x5f, 0x53, 0x50, 0x45, 0x45, 0x44, 0x10, 0x02, 0x12, 0x18, 0x0a, 0x14, 0x50, 0x4e,
+	0x47, 0x5f, 0x43, 0x4f, 0x4d, 0x50, 0x52, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x42, 0x45,
+	0x53, 0x54, 0x10, 0x03, 0x32, 0xae, 0x02, 0x0a, 0x0c, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65,
+	0x73, 0x69, 0x7a, 0x65, 0x72, 0x12, 0x44, 0x0a, 0x0b, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49,
+	0x6d, 0x61, 0x67, 0x65, 0x12, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x73,
+	0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1a, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d,
+	0x61, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4a, 0x0a, 0x11, 0x52,
+	0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x12, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49,
+	0x6d, 0x61, 0x67, 0x65, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x1a, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x12, 0x53, 0x0a, 0x13, 0x52, 0x65, 0x73, 0x69, 0x7a,
+	0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x19,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61,
+	0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x44, 0x6f, 0x77,
+	0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x30, 0x01, 0x12, 0x37, 0x0a, 0x0b,
+	0x52, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x10, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x49, 0x74, 0x65, 0x6d, 0x1a, 0x12, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x28, 0x01, 0x30, 0x01, 0x42, 0x2e, 0x5a, 0x2c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
+	0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x65, 0x61, 0x75, 0x63, 0x68, 0x74, 0x65, 0x72, 0x2f, 0x67, 0x6f,
+	0x2d, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x2d, 0x61, 0x64, 0x6a, 0x75, 0x73, 0x74, 0x65, 0x72, 0x2f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 })
 
 var (
@@ -203,19 +3819,132 @@ func file_proto_image_resizer_proto_rawDescGZIP() []byte {
 	return file_proto_image_resizer_proto_rawDescData
 }
 
-var file_proto_image_resizer_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_image_resizer_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
+var file_proto_image_resizer_proto_msgTypes = make([]protoimpl.MessageInfo, 35)
 var file_proto_image_resizer_proto_goTypes = []any{
-	(*ResizeImageRequest)(nil),  // 0: proto.ResizeImageRequest
-	(*ResizeImageResponse)(nil), // 1: proto.ResizeImageResponse
+	(Filter)(0),                      // 0: proto.Filter
+	(Fit)(0),                         // 1: proto.Fit
+	(OutputFormat)(0),                // 2: proto.OutputFormat
+	(Gravity)(0),                     // 3: proto.Gravity
+	(BlendMode)(0),                   // 4: proto.BlendMode
+	(TextAlign)(0),                   // 5: proto.TextAlign
+	(ChromaSubsampling)(0),           // 6: proto.ChromaSubsampling
+	(PngCompression)(0),              // 7: proto.PngCompression
+	(*JpegOptions)(nil),              // 8: proto.JpegOptions
+	(*PngOptions)(nil),               // 9: proto.PngOptions
+	(*GifOptions)(nil),               // 10: proto.GifOptions
+	(*EncodeOptions)(nil),            // 11: proto.EncodeOptions
+	(*FormatNegotiation)(nil),        // 12: proto.FormatNegotiation
+	(*FormatCandidate)(nil),          // 13: proto.FormatCandidate
+	(*Color)(nil),                    // 14: proto.Color
+	(*Pipeline)(nil),                 // 15: proto.Pipeline
+	(*Operation)(nil),                // 16: proto.Operation
+	(*ResizeOperation)(nil),          // 17: proto.ResizeOperation
+	(*CropOperation)(nil),            // 18: proto.CropOperation
+	(*CropRect)(nil),                 // 19: proto.CropRect
+	(*CropPercent)(nil),              // 20: proto.CropPercent
+	(*CropSize)(nil),                 // 21: proto.CropSize
+	(*RotateOperation)(nil),          // 22: proto.RotateOperation
+	(*FlipOperation)(nil),            // 23: proto.FlipOperation
+	(*AdjustOperation)(nil),          // 24: proto.AdjustOperation
+	(*BlurOperation)(nil),            // 25: proto.BlurOperation
+	(*BoxBlurOperation)(nil),         // 26: proto.BoxBlurOperation
+	(*UnsharpMaskOperation)(nil),     // 27: proto.UnsharpMaskOperation
+	(*OverlayOperation)(nil),         // 28: proto.OverlayOperation
+	(*TextOperation)(nil),            // 29: proto.TextOperation
+	(*TextShadow)(nil),               // 30: proto.TextShadow
+	(*ResizeImageRequest)(nil),       // 31: proto.ResizeImageRequest
+	(*Focus)(nil),                    // 32: proto.Focus
+	(*FocalPoint)(nil),               // 33: proto.FocalPoint
+	(*FocusRegion)(nil),              // 34: proto.FocusRegion
+	(*OutputVariant)(nil),            // 35: proto.OutputVariant
+	(*VariantImage)(nil),             // 36: proto.VariantImage
+	(*ResizeImageResponse)(nil),      // 37: proto.ResizeImageResponse
+	(*ResizeImageChunk)(nil),         // 38: proto.ResizeImageChunk
+	(*DownloadChecksum)(nil),         // 39: proto.DownloadChecksum
+	(*ResizeImageDownloadChunk)(nil), // 40: proto.ResizeImageDownloadChunk
+	(*BatchItem)(nil),                // 41: proto.BatchItem
+	(*BatchResult)(nil),              // 42: proto.BatchResult
 }
 var file_proto_image_resizer_proto_depIdxs = []int32{
-	0, // 0: proto.ImageResizer.ResizeImage:input_type -> proto.ResizeImageRequest
-	1, // 1: proto.ImageResizer.ResizeImage:output_type -> proto.ResizeImageResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	6,  // 0: proto.JpegOptions.subsampling:type_name -> proto.ChromaSubsampling
+	7,  // 1: proto.PngOptions.compression:type_name -> proto.PngCompression
+	8,  // 2: proto.EncodeOptions.jpeg:type_name -> proto.JpegOptions
+	9,  // 3: proto.EncodeOptions.png:type_name -> proto.PngOptions
+	10, // 4: proto.EncodeOptions.gif:type_name -> proto.GifOptions
+	2,  // 5: proto.FormatNegotiation.accept:type_name -> proto.OutputFormat
+	2,  // 6: proto.FormatCandidate.format:type_name -> proto.OutputFormat
+	16, // 7: proto.Pipeline.operations:type_name -> proto.Operation
+	17, // 8: proto.Operation.resize:type_name -> proto.ResizeOperation
+	18, // 9: proto.Operation.crop:type_name -> proto.CropOperation
+	22, // 10: proto.Operation.rotate:type_name -> proto.RotateOperation
+	23, // 11: proto.Operation.flip:type_name -> proto.FlipOperation
+	24, // 12: proto.Operation.adjust:type_name -> proto.AdjustOperation
+	25, // 13: proto.Operation.blur:type_name -> proto.BlurOperation
+	26, // 14: proto.Operation.box_blur:type_name -> proto.BoxBlurOperation
+	27, // 15: proto.Operation.unsharp_mask:type_name -> proto.UnsharpMaskOperation
+	28, // 16: proto.Operation.overlay:type_name -> proto.OverlayOperation
+	29, // 17: proto.Operation.text:type_name -> proto.TextOperation
+	0,  // 18: proto.ResizeOperation.filter:type_name -> proto.Filter
+	1,  // 19: proto.ResizeOperation.fit:type_name -> proto.Fit
+	14, // 20: proto.ResizeOperation.background:type_name -> proto.Color
+	19, // 21: proto.CropOperation.rect:type_name -> proto.CropRect
+	20, // 22: proto.CropOperation.percent:type_name -> proto.CropPercent
+	21, // 23: proto.CropOperation.size:type_name -> proto.CropSize
+	3,  // 24: proto.CropSize.gravity:type_name -> proto.Gravity
+	14, // 25: proto.RotateOperation.background:type_name -> proto.Color
+	3,  // 26: proto.OverlayOperation.gravity:type_name -> proto.Gravity
+	4,  // 27: proto.OverlayOperation.blend:type_name -> proto.BlendMode
+	14, // 28: proto.TextOperation.color:type_name -> proto.Color
+	14, // 29: proto.TextOperation.stroke_color:type_name -> proto.Color
+	30, // 30: proto.TextOperation.shadow:type_name -> proto.TextShadow
+	5,  // 31: proto.TextOperation.align:type_name -> proto.TextAlign
+	19, // 32: proto.TextOperation.box:type_name -> proto.CropRect
+	3,  // 33: proto.TextOperation.gravity:type_name -> proto.Gravity
+	14, // 34: proto.TextShadow.color:type_name -> proto.Color
+	0,  // 35: proto.ResizeImageRequest.filter:type_name -> proto.Filter
+	1,  // 36: proto.ResizeImageRequest.fit:type_name -> proto.Fit
+	14, // 37: proto.ResizeImageRequest.background:type_name -> proto.Color
+	2,  // 38: proto.ResizeImageRequest.output_format:type_name -> proto.OutputFormat
+	11, // 39: proto.ResizeImageRequest.encode_options:type_name -> proto.EncodeOptions
+	12, // 40: proto.ResizeImageRequest.negotiate:type_name -> proto.FormatNegotiation
+	35, // 41: proto.ResizeImageRequest.variants:type_name -> proto.OutputVariant
+	15, // 42: proto.ResizeImageRequest.pipeline:type_name -> proto.Pipeline
+	32, // 43: proto.ResizeImageRequest.focus:type_name -> proto.Focus
+	33, // 44: proto.Focus.point:type_name -> proto.FocalPoint
+	34, // 45: proto.Focus.regions:type_name -> proto.FocusRegion
+	0,  // 46: proto.OutputVariant.filter:type_name -> proto.Filter
+	1,  // 47: proto.OutputVariant.fit:type_name -> proto.Fit
+	14, // 48: proto.OutputVariant.background:type_name -> proto.Color
+	2,  // 49: proto.OutputVariant.output_format:type_name -> proto.OutputFormat
+	11, // 50: proto.OutputVariant.encode_options:type_name -> proto.EncodeOptions
+	12, // 51: proto.OutputVariant.negotiate:type_name -> proto.FormatNegotiation
+	15, // 52: proto.OutputVariant.pipeline:type_name -> proto.Pipeline
+	2,  // 53: proto.VariantImage.output_format:type_name -> proto.OutputFormat
+	13, // 54: proto.VariantImage.format_candidates:type_name -> proto.FormatCandidate
+	19, // 55: proto.VariantImage.smart_crops:type_name -> proto.CropRect
+	2,  // 56: proto.ResizeImageResponse.output_format:type_name -> proto.OutputFormat
+	13, // 57: proto.ResizeImageResponse.format_candidates:type_name -> proto.FormatCandidate
+	36, // 58: proto.ResizeImageResponse.variants:type_name -> proto.VariantImage
+	19, // 59: proto.ResizeImageResponse.smart_crops:type_name -> proto.CropRect
+	31, // 60: proto.ResizeImageChunk.header:type_name -> proto.ResizeImageRequest
+	37, // 61: proto.ResizeImageDownloadChunk.metadata:type_name -> proto.ResizeImageResponse
+	39, // 62: proto.ResizeImageDownloadChunk.checksum:type_name -> proto.DownloadChecksum
+	31, // 63: proto.BatchItem.request:type_name -> proto.ResizeImageRequest
+	37, // 64: proto.BatchResult.response:type_name -> proto.ResizeImageResponse
+	31, // 65: proto.ImageResizer.ResizeImage:input_type -> proto.ResizeImageRequest
+	38, // 66: proto.ImageResizer.ResizeImageStream:input_type -> proto.ResizeImageChunk
+	31, // 67: proto.ImageResizer.ResizeImageDownload:input_type -> proto.ResizeImageRequest
+	41, // 68: proto.ImageResizer.ResizeBatch:input_type -> proto.BatchItem
+	37, // 69: proto.ImageResizer.ResizeImage:output_type -> proto.ResizeImageResponse
+	37, // 70: proto.ImageResizer.ResizeImageStream:output_type -> proto.ResizeImageResponse
+	40, // 71: proto.ImageResizer.ResizeImageDownload:output_type -> proto.ResizeImageDownloadChunk
+	42, // 72: proto.ImageResizer.ResizeBatch:output_type -> proto.BatchResult
+	69, // [69:73] is the sub-list for method output_type
+	65, // [65:69] is the sub-list for method input_type
+	65, // [65:65] is the sub-list for extension type_name
+	65, // [65:65] is the sub-list for extension extendee
+	0,  // [0:65] is the sub-list for field type_name
 }
 
 func init() { file_proto_image_resizer_proto_init() }
@@ -223,18 +3952,50 @@ func file_proto_image_resizer_proto_init() {
 	if File_proto_image_resizer_proto != nil {
 		return
 	}
+	file_proto_image_resizer_proto_msgTypes[8].OneofWrappers = []any{
+		(*Operation_Resize)(nil),
+		(*Operation_Crop)(nil),
+		(*Operation_Rotate)(nil),
+		(*Operation_Flip)(nil),
+		(*Operation_Adjust)(nil),
+		(*Operation_Blur)(nil),
+		(*Operation_BoxBlur)(nil),
+		(*Operation_UnsharpMask)(nil),
+		(*Operation_Overlay)(nil),
+		(*Operation_Text)(nil),
+	}
+	file_proto_image_resizer_proto_msgTypes[10].OneofWrappers = []any{
+		(*CropOperation_Rect)(nil),
+		(*CropOperation_Percent)(nil),
+		(*CropOperation_Size)(nil),
+	}
+	file_proto_image_resizer_proto_msgTypes[20].OneofWrappers = []any{
+		(*OverlayOperation_Image)(nil),
+		(*OverlayOperation_Asset)(nil),
+	}
+	file_proto_image_resizer_proto_msgTypes[23].OneofWrappers = []any{}
+	file_proto_image_resizer_proto_msgTypes[30].OneofWrappers = []any{
+		(*ResizeImageChunk_Header)(nil),
+		(*ResizeImageChunk_Data)(nil),
+	}
+	file_proto_image_resizer_proto_msgTypes[32].OneofWrappers = []any{
+		(*ResizeImageDownloadChunk_Metadata)(nil),
+		(*ResizeImageDownloadChunk_Data)(nil),
+		(*ResizeImageDownloadChunk_Checksum)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_image_resizer_proto_rawDesc), len(file_proto_image_resizer_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      8,
+			NumMessages:   35,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_image_resizer_proto_goTypes,
 		DependencyIndexes: file_proto_image_resizer_proto_depIdxs,
+		EnumInfos:         file_proto_image_resizer_proto_enumTypes,
 		MessageInfos:      file_proto_image_resizer_proto_msgTypes,
 	}.Build()
 	File_proto_image_resizer_proto = out.File
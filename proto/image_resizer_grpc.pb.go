@@ -19,7 +19,10 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ImageResizer_ResizeImage_FullMethodName = "/proto.ImageResizer/ResizeImage"
+	ImageResizer_ResizeImage_FullMethodName         = "/proto.ImageResizer/ResizeImage"
+	ImageResizer_ResizeImageStream_FullMethodName   = "/proto.ImageResizer/ResizeImageStream"
+	ImageResizer_ResizeImageDownload_FullMethodName = "/proto.ImageResizer/ResizeImageDownload"
+	ImageResizer_ResizeBatch_FullMethodName         = "/proto.ImageResizer/ResizeBatch"
 )
 
 // ImageResizerClient is the client API for ImageResizer service.
@@ -27,6 +30,16 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ImageResizerClient interface {
 	ResizeImage(ctx context.Context, in *ResizeImageRequest, opts ...grpc.CallOption) (*ResizeImageResponse, error)
+	// Upload an image too large for a single message: a header with the
+	// parameters, then the image bytes in chunks
+	ResizeImageStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ResizeImageChunk, ResizeImageResponse], error)
+	// Download a result too large for a single message: the response metadata,
+	// then the image bytes in chunks, then a checksum. With variants, each
+	// variant image follows in order with its own chunks and checksum.
+	ResizeImageDownload(ctx context.Context, in *ResizeImageRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ResizeImageDownloadChunk], error)
+	// Resize many images over one stream; results arrive as they complete,
+	// tagged with the id of their item
+	ResizeBatch(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[BatchItem, BatchResult], error)
 }
 
 type imageResizerClient struct {
@@ -47,11 +60,66 @@ func (c *imageResizerClient) ResizeImage(ctx context.Context, in *ResizeImageReq
 	return out, nil
 }
 
+func (c *imageResizerClient) ResizeImageStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ResizeImageChunk, ResizeImageResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ImageResizer_ServiceDesc.Streams[0], ImageResizer_ResizeImageStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ResizeImageChunk, ResizeImageResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImageResizer_ResizeImageStreamClient = grpc.ClientStreamingClient[ResizeImageChunk, ResizeImageResponse]
+
+func (c *imageResizerClient) ResizeImageDownload(ctx context.Context, in *ResizeImageRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ResizeImageDownloadChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ImageResizer_ServiceDesc.Streams[1], ImageResizer_ResizeImageDownload_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ResizeImageRequest, ResizeImageDownloadChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImageResizer_ResizeImageDownloadClient = grpc.ServerStreamingClient[ResizeImageDownloadChunk]
+
+func (c *imageResizerClient) ResizeBatch(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[BatchItem, BatchResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ImageResizer_ServiceDesc.Streams[2], ImageResizer_ResizeBatch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BatchItem, BatchResult]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImageResizer_ResizeBatchClient = grpc.BidiStreamingClient[BatchItem, BatchResult]
+
 // ImageResizerServer is the server API for ImageResizer service.
 // All implementations must embed UnimplementedImageResizerServer
 // for forward compatibility.
 type ImageResizerServer interface {
 	ResizeImage(context.Context, *ResizeImageRequest) (*ResizeImageResponse, error)
+	// Upload an image too large for a single message: a header with the
+	// parameters, then the image bytes in chunks
+	ResizeImageStream(grpc.ClientStreamingServer[ResizeImageChunk, ResizeImageResponse]) error
+	// Download a result too large for a single message: the response metadata,
+	// then the image bytes in chunks, then a checksum. With variants, each
+	// variant image follows in order with its own chunks and checksum.
+	ResizeImageDownload(*ResizeImageRequest, grpc.ServerStreamingServer[ResizeImageDownloadChunk]) error
+	// Resize many images over one stream; results arrive as they complete,
+	// tagged with the id of their item
+	ResizeBatch(grpc.BidiStreamingServer[BatchItem, BatchResult]) error
 	mustEmbedUnimplementedImageResizerServer()
 }
 
@@ -65,6 +133,15 @@ type UnimplementedImageResizerServer struct{}
 func (UnimplementedImageResizerServer) ResizeImage(context.Context, *ResizeImageRequest) (*ResizeImageResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ResizeImage not implemented")
 }
+func (UnimplementedImageResizerServer) ResizeImageStream(grpc.ClientStreamingServer[ResizeImageChunk, ResizeImageResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ResizeImageStream not implemented")
+}
+func (UnimplementedImageResizerServer) ResizeImageDownload(*ResizeImageRequest, grpc.ServerStreamingServer[ResizeImageDownloadChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method ResizeImageDownload not implemented")
+}
+func (UnimplementedImageResizerServer) ResizeBatch(grpc.BidiStreamingServer[BatchItem, BatchResult]) error {
+	return status.Errorf(codes.Unimplemented, "method ResizeBatch not implemented")
+}
 func (UnimplementedImageResizerServer) mustEmbedUnimplementedImageResizerServer() {}
 func (UnimplementedImageResizerServer) testEmbeddedByValue()                      {}
 
@@ -104,6 +181,31 @@ func _ImageResizer_ResizeImage_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ImageResizer_ResizeImageStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ImageResizerServer).ResizeImageStream(&grpc.GenericServerStream[ResizeImageChunk, ResizeImageResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImageResizer_ResizeImageStreamServer = grpc.ClientStreamingServer[ResizeImageChunk, ResizeImageResponse]
+
+func _ImageResizer_ResizeImageDownload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ResizeImageRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ImageResizerServer).ResizeImageDownload(m, &grpc.GenericServerStream[ResizeImageRequest, ResizeImageDownloadChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImageResizer_ResizeImageDownloadServer = grpc.ServerStreamingServer[ResizeImageDownloadChunk]
+
+func _ImageResizer_ResizeBatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ImageResizerServer).ResizeBatch(&grpc.GenericServerStream[BatchItem, BatchResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImageResizer_ResizeBatchServer = grpc.BidiStreamingServer[BatchItem, BatchResult]
+
 // ImageResizer_ServiceDesc is the grpc.ServiceDesc for ImageResizer service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -116,6 +218,23 @@ var ImageResizer_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _ImageResizer_ResizeImage_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ResizeImageStream",
+			Handler:       _ImageResizer_ResizeImageStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ResizeImageDownload",
+			Handler:       _ImageResizer_ResizeImageDownload_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ResizeBatch",
+			Handler:       _ImageResizer_ResizeBatch_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "proto/image_resizer.proto",
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"slices"
+)
+
+// medianCut is a draw.Quantizer that builds a palette by median cut over a
+// histogram of 5-bit-per-channel colours. When the image has transparent
+// pixels, one palette entry is reserved for full transparency.
+type medianCut struct{}
+
+// histogramBits is the number of bits kept per channel in the histogram
+const histogramBits = 5
+
+// colorBucket accumulates the pixels that fall into one histogram cell
+type colorBucket struct {
+	key     int
+	count   int
+	r, g, b int
+}
+
+func (medianCut) Quantize(p color.Palette, m image.Image) color.Palette {
+	img := toNRGBA(m)
+	size := cap(p) - len(p)
+
+	buckets := make([]colorBucket, 1<<(3*histogramBits))
+	transparent := false
+	for i := 0; i < len(img.Pix); i += 4 {
+		px := img.Pix[i : i+4 : i+4]
+		if px[3] < 128 {
+			transparent = true
+			continue
+		}
+		key := bucketKey(px[0], px[1], px[2])
+		bucket := &buckets[key]
+		bucket.key = key
+		bucket.count++
+		bucket.r += int(px[0])
+		bucket.g += int(px[1])
+		bucket.b += int(px[2])
+	}
+	if transparent {
+		size--
+	}
+
+	var used []colorBucket
+	for _, bucket := range buckets {
+		if bucket.count > 0 {
+			used = append(used, bucket)
+		}
+	}
+
+	boxes := [][]colorBucket{}
+	if len(used) > 0 {
+		boxes = append(boxes, used)
+	}
+	for len(boxes) < size {
+		// Split the box with the widest channel range at its median pixel
+		best, bestChannel, bestRange := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			channel, extent := widestChannel(box)
+			if extent > bestRange {
+				best, bestChannel, bestRange = i, channel, extent
+			}
+		}
+		if best < 0 {
+			break
+		}
+		box := boxes[best]
+		slices.SortFunc(box, func(a, b colorBucket) int {
+			return bucketChannel(a.key, bestChannel) - bucketChannel(b.key, bestChannel)
+		})
+		total := 0
+		for _, bucket := range box {
+			total += bucket.count
+		}
+		split, seen := 1, box[0].count
+		for split < len(box)-1 && seen+box[split].count <= total/2 {
+			seen += box[split].count
+			split++
+		}
+		boxes[best] = box[:split]
+		boxes = append(boxes, box[split:])
+	}
+
+	for _, box := range boxes {
+		var count, r, g, b int
+		for _, bucket := range box {
+			count += bucket.count
+			r += bucket.r
+			g += bucket.g
+			b += bucket.b
+		}
+		p = append(p, color.NRGBA{R: uint8(r / count), G: uint8(g / count), B: uint8(b / count), A: 255})
+	}
+	if transparent {
+		p = append(p, color.NRGBA{})
+	}
+	return p
+}
+
+// bucketKey packs the top histogramBits of each channel into a histogram index
+func bucketKey(r, g, b uint8) int {
+	const shift = 8 - histogramBits
+	return int(r>>shift)<<(2*histogramBits) | int(g>>shift)<<histogramBits | int(b>>shift)
+}
+
+// bucketChannel extracts channel 0 (red), 1 (green) or 2 (blue) from a key
+func bucketChannel(key, channel int) int {
+	return key >> ((2 - channel) * histogramBits) & (1<<histogramBits - 1)
+}
+
+// widestChannel reports the channel with the largest range in a box
+func widestChannel(box []colorBucket) (channel, extent int) {
+	for c := 0; c < 3; c++ {
+		lo, hi := 1<<histogramBits, -1
+		for _, bucket := range box {
+			v := bucketChannel(bucket.key, c)
+			lo = min(lo, v)
+			hi = max(hi, v)
+		}
+		if hi-lo > extent {
+			channel, extent = c, hi-lo
+		}
+	}
+	return channel, extent
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"image"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// Filter selects the resampling kernel. The values match the FILTER_*
+// constants in cuda/resize_kernel.cu.
+type Filter int32
+
+const (
+	FilterNearest  Filter = 1
+	FilterBilinear Filter = 2
+	FilterBicubic  Filter = 3 // Catmull-Rom
+	FilterMitchell Filter = 4 // Mitchell-Netravali, B = C = 1/3
+	FilterLanczos2 Filter = 5
+	FilterLanczos3 Filter = 6
+	FilterBox      Filter = 7 // Area average when downscaling
+)
+
+// radius is the half-width of the filter at unit scale
+func (f Filter) radius() float32 {
+	switch f {
+	case FilterBilinear:
+		return 1
+	case FilterBicubic, FilterMitchell, FilterLanczos2:
+		return 2
+	case FilterLanczos3:
+		return 3
+	default:
+		return 0.5
+	}
+}
+
+func sinc(x float32) float32 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return float32(math.Sin(float64(x))) / x
+}
+
+// weight evaluates the filter at distance t from the sample centre
+func (f Filter) weight(t float32) float32 {
+	if t < 0 {
+		t = -t
+	}
+	switch f {
+	case FilterBilinear:
+		if t < 1 {
+			return 1 - t
+		}
+	case FilterBicubic:
+		if t < 1 {
+			return (1.5*t-2.5)*t*t + 1
+		}
+		if t < 2 {
+			return ((-0.5*t+2.5)*t-4)*t + 2
+		}
+	case FilterMitchell:
+		if t < 1 {
+			return ((7*t-12)*t*t + 16.0/3) / 6
+		}
+		if t < 2 {
+			return (((-7.0/3*t+12)*t-20)*t + 32.0/3) / 6
+		}
+	case FilterLanczos2:
+		if t < 2 {
+			return sinc(t) * sinc(t/2)
+		}
+	case FilterLanczos3:
+		if t < 3 {
+			return sinc(t) * sinc(t/3)
+		}
+	default:
+		if t < 0.5 {
+			return 1
+		}
+	}
+	return 0
+}
+
+func clampInt(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
+
+func clampByte(v float32) uint8 {
+	return uint8(min(max(v+0.5, 0), 255))
+}
+
+// srgbToLinear maps sRGB bytes to linear light in 0-1
+var srgbToLinear = func() (table [256]float32) {
+	for i := range table {
+		v := float64(i) / 255
+		if v <= 0.04045 {
+			table[i] = float32(v / 12.92)
+		} else {
+			table[i] = float32(math.Pow((v+0.055)/1.055, 2.4))
+		}
+	}
+	return table
+}()
+
+// linearToSRGB encodes linear light in 0-1 as sRGB in 0-1
+func linearToSRGB(v float32) float32 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return float32(1.055*math.Pow(float64(v), 1/2.4) - 0.055)
+}
+
+// loadPixel returns an RGBA8 pixel as floats in 0-255. In linear mode the
+// colour is converted to linear light and premultiplied by alpha.
+func loadPixel(p []byte, linear bool) [4]float32 {
+	if !linear {
+		return [4]float32{float32(p[0]), float32(p[1]), float32(p[2]), float32(p[3])}
+	}
+	a := float32(p[3]) / 255
+	return [4]float32{srgbToLinear[p[0]] * 255 * a, srgbToLinear[p[1]] * 255 * a, srgbToLinear[p[2]] * 255 * a, float32(p[3])}
+}
+
+// storePixel reverses loadPixel, writing an RGBA8 pixel
+func storePixel(dst []byte, v [4]float32, linear bool) {
+	if !linear {
+		for c := 0; c < 4; c++ {
+			dst[c] = clampByte(v[c])
+		}
+		return
+	}
+	if v[3] <= 0 {
+		dst[0], dst[1], dst[2], dst[3] = 0, 0, 0, 0
+		return
+	}
+	for c := 0; c < 3; c++ {
+		dst[c] = clampByte(linearToSRGB(min(max(v[c]/v[3], 0), 1)) * 255)
+	}
+	dst[3] = clampByte(v[3])
+}
+
+// resampleTaps computes the sampling window for output coordinate o when
+// scaling inSize to outSize. Downscaling widens the filter so every source
+// pixel contributes.
+func resampleTaps(o, inSize, outSize int, f Filter) (center, filterScale float32, lo, hi int) {
+	scale := float32(inSize) / float32(outSize)
+	center = (float32(o) + 0.5) * scale
+	filterScale = max(scale, 1)
+	support := f.radius() * filterScale
+	lo = int(math.Floor(float64(center - support)))
+	hi = int(math.Ceil(float64(center + support)))
+	return center, filterScale, lo, hi
+}
+
+// resampleHorizontalPixel computes pixel (x, y) of the horizontal pass:
+// input is inWidth pixels wide RGBA8, temp is outWidth pixels wide RGBA float32,
+// premultiplied linear light when linear is set.
+// It is shared by the CPU backend and the simulated resampleHorizontal kernel.
+func resampleHorizontalPixel(input []byte, inWidth int, temp []float32, outWidth int, filter Filter, linear bool, x, y int) {
+	dst := temp[(y*outWidth+x)*4 : (y*outWidth+x)*4+4]
+	row := input[y*inWidth*4 : (y+1)*inWidth*4]
+	center, filterScale, lo, hi := resampleTaps(x, inWidth, outWidth, filter)
+
+	if filter == FilterNearest {
+		src := clampInt(int(center), 0, inWidth-1) * 4
+		for c := 0; c < 4; c++ {
+			dst[c] = float32(row[src+c])
+		}
+		return
+	}
+
+	var acc [4]float32
+	var sum float32
+	for i := lo; i < hi; i++ {
+		w := filter.weight((float32(i) + 0.5 - center) / filterScale)
+		if w == 0 {
+			continue
+		}
+		src := clampInt(i, 0, inWidth-1) * 4
+		px := loadPixel(row[src:src+4], linear)
+		for c := 0; c < 4; c++ {
+			acc[c] += w * px[c]
+		}
+		sum += w
+	}
+	if sum == 0 {
+		// An upscaling box can fall exactly between two source pixels;
+		// take the nearest one
+		src := clampInt(int(center), 0, inWidth-1) * 4
+		px := loadPixel(row[src:src+4], linear)
+		copy(dst, px[:])
+		return
+	}
+	for c := 0; c < 4; c++ {
+		dst[c] = acc[c] / sum
+	}
+}
+
+// resampleVerticalPixel computes pixel (x, y) of the vertical pass: temp is
+// width x inHeight RGBA float32 and output is width x outHeight RGBA8.
+// Nearest neighbour copies pixels, so only the other filters use linear.
+// It is shared by the CPU backend and the simulated resampleVertical kernel.
+func resampleVerticalPixel(temp []float32, width, inHeight int, output []byte, outHeight int, filter Filter, linear bool, x, y int) {
+	dst := output[(y*width+x)*4 : (y*width+x)*4+4]
+	center, filterScale, lo, hi := resampleTaps(y, inHeight, outHeight, filter)
+
+	if filter == FilterNearest {
+		src := (clampInt(int(center), 0, inHeight-1)*width + x) * 4
+		for c := 0; c < 4; c++ {
+			dst[c] = clampByte(temp[src+c])
+		}
+		return
+	}
+
+	var acc [4]float32
+	var sum float32
+	for i := lo; i < hi; i++ {
+		w := filter.weight((float32(i) + 0.5 - center) / filterScale)
+		if w == 0 {
+			continue
+		}
+		src := (clampInt(i, 0, inHeight-1)*width + x) * 4
+		for c := 0; c < 4; c++ {
+			acc[c] += w * temp[src+c]
+		}
+		sum += w
+	}
+	if sum == 0 {
+		// An upscaling box can fall exactly between two source pixels;
+		// take the nearest one
+		src := (clampInt(int(center), 0, inHeight-1)*width + x) * 4
+		storePixel(dst, [4]float32(temp[src:src+4]), linear)
+		return
+	}
+	for c := 0; c < 4; c++ {
+		acc[c] /= sum
+	}
+	storePixel(dst, acc, linear)
+}
+
+// parallelRows runs fn for every row in [0, rows), spread over all CPUs
+func parallelRows(rows int, fn func(y int)) {
+	workers := min(runtime.GOMAXPROCS(0), rows)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for y := w; y < rows; y += workers {
+				fn(y)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// resampleNRGBA resizes img to width x height on the CPU with a separable
+// filter, in premultiplied linear light when linear is set
+func resampleNRGBA(img *image.NRGBA, width, height int, filter Filter, linear bool) *image.NRGBA {
+	inWidth, inHeight := img.Bounds().Dx(), img.Bounds().Dy()
+	if img.Stride != inWidth*4 {
+		img = cloneNRGBA(img)
+	}
+
+	temp := make([]float32, width*inHeight*4)
+	parallelRows(inHeight, func(y int) {
+		for x := 0; x < width; x++ {
+			resampleHorizontalPixel(img.Pix, inWidth, temp, width, filter, linear, x, y)
+		}
+	})
+
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	parallelRows(height, func(y int) {
+		for x := 0; x < width; x++ {
+			resampleVerticalPixel(temp, width, inHeight, out.Pix, height, filter, linear, x, y)
+		}
+	})
+	return out
+}
+
+// cloneNRGBA copies img into a tightly packed NRGBA at the origin
+func cloneNRGBA(img *image.NRGBA) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		copy(out.Pix[y*out.Stride:(y+1)*out.Stride], img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):])
+	}
+	return out
+}
+
+// scaledSize fills in a zero width or height from the source aspect ratio;
+// if both are zero the source size is kept
+func scaledSize(srcWidth, srcHeight, width, height int) (int, int) {
+	switch {
+	case width == 0 && height == 0:
+		return srcWidth, srcHeight
+	case width == 0:
+		width = max(1, int(math.Round(float64(srcWidth)*float64(height)/float64(srcHeight))))
+	case height == 0:
+		height = max(1, int(math.Round(float64(srcHeight)*float64(width)/float64(srcWidth))))
+	}
+	return width, height
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// allFilters lists every resampling filter
+var allFilters = []Filter{FilterNearest, FilterBilinear, FilterBicubic, FilterMitchell, FilterLanczos2, FilterLanczos3, FilterBox}
+
+// gradientImage returns a w x h image with every channel varying
+func gradientImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 255 / w), uint8(y * 255 / h), uint8((x + y) * 37), uint8(128 + x*127/w)})
+		}
+	}
+	return img
+}
+
+// rowImage returns an opaque grey image one pixel high with the given levels
+func rowImage(levels ...uint8) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, len(levels), 1))
+	for x, v := range levels {
+		img.SetNRGBA(x, 0, color.NRGBA{v, v, v, 255})
+	}
+	return img
+}
+
+// columnImage is rowImage turned on its side, for the vertical pass
+func columnImage(levels ...uint8) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, len(levels)))
+	for y, v := range levels {
+		img.SetNRGBA(0, y, color.NRGBA{v, v, v, 255})
+	}
+	return img
+}
+
+// diffImages returns an error naming the first channel of got that
+// differs from want by more than tolerance
+func diffImages(want, got *image.NRGBA, tolerance int) error {
+	if want.Rect.Size() != got.Rect.Size() {
+		return fmt.Errorf("got a %v image, want %v", got.Rect.Size(), want.Rect.Size())
+	}
+	for y := 0; y < want.Rect.Dy(); y++ {
+		for x := 0; x < want.Rect.Dx(); x++ {
+			w := want.NRGBAAt(want.Rect.Min.X+x, want.Rect.Min.Y+y)
+			g := got.NRGBAAt(got.Rect.Min.X+x, got.Rect.Min.Y+y)
+			for ch, d := range []int{int(w.R) - int(g.R), int(w.G) - int(g.G), int(w.B) - int(g.B), int(w.A) - int(g.A)} {
+				if d > tolerance || d < -tolerance {
+					return fmt.Errorf("channel %d at (%d, %d) is %v, want %v", ch, x, y, g, w)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// resamplers runs a resize on the CPU and on a simulated device
+func resamplers(t *testing.T) map[string]func(img *image.NRGBA, width, height int, filter Filter) *image.NRGBA {
+	dev := openSimDevice(t, newSimDriver("Simulated GPU"))
+	return map[string]func(*image.NRGBA, int, int, Filter) *image.NRGBA{
+		"cpu": func(img *image.NRGBA, width, height int, filter Filter) *image.NRGBA {
+			return resampleNRGBA(img, width, height, filter, false)
+		},
+		"cuda-sim": func(img *image.NRGBA, width, height int, filter Filter) *image.NRGBA {
+			out, _, err := runBatchGPU(context.Background(), dev, []*image.NRGBA{img}, [][]step{{resampleStep{Width: width, Height: height, Filter: filter}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return out[0][0]
+		},
+	}
+}
+
+// TestFilterValues resizes rows and columns of grey levels and compares
+// them with values worked out by hand from the filter definitions
+func TestFilterValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		src    []uint8
+		want   []uint8
+	}{
+		{"nearest down", FilterNearest, []uint8{0, 60, 120, 240}, []uint8{60, 240}},
+		{"box down", FilterBox, []uint8{0, 60, 120, 240}, []uint8{30, 180}},
+		// Taps at 1/4, 3/4, 3/4 and 1/4 of the widened filter
+		{"bilinear down", FilterBilinear, []uint8{0, 60, 120, 240}, []uint8{38, 173}},
+		{"bilinear up", FilterBilinear, []uint8{0, 100}, []uint8{0, 25, 75, 100}},
+		// The middle output sits exactly between the two sources, where
+		// neither box tap has any weight
+		{"box up", FilterBox, []uint8{0, 100}, []uint8{0, 100, 100}},
+		// Catmull-Rom taps 0.2266 and -0.0234 on 100 for the fourth output,
+		// 0.8672 and -0.0703 for the fifth, and 0.8672, 0.2266 and -0.0234
+		// for the sixth; the weights sum to one and the lobes overshoot
+		{"bicubic up", FilterBicubic, []uint8{0, 0, 100, 100}, []uint8{0, 0, 0, 20, 80, 107, 102, 100}},
+		// Mitchell widened by 3/2: the second output takes 0.1752 and
+		// -0.0347 of 255 out of a weight sum of 1.5
+		{"mitchell down", FilterMitchell, []uint8{0, 0, 0, 255, 255, 255}, []uint8{0, 24, 231, 255}},
+	}
+	for name, resample := range resamplers(t) {
+		for _, tt := range tests {
+			got := resample(rowImage(tt.src...), len(tt.want), 1, tt.filter)
+			if err := diffImages(rowImage(tt.want...), got, 0); err != nil {
+				t.Errorf("%s: %s: %v", name, tt.name, err)
+			}
+			got = resample(columnImage(tt.src...), 1, len(tt.want), tt.filter)
+			if err := diffImages(columnImage(tt.want...), got, 0); err != nil {
+				t.Errorf("%s: %s vertically: %v", name, tt.name, err)
+			}
+		}
+	}
+}
+
+// TestBoxUpscaleKeepsAlpha checks that no output pixel of a 2 to 3 box
+// upscale of an opaque image loses its alpha to a zero weight sum
+func TestBoxUpscaleKeepsAlpha(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for i := range src.Pix {
+		src.Pix[i] = 255
+	}
+	for name, resample := range resamplers(t) {
+		got := resample(src, 3, 3, FilterBox)
+		for y := 0; y < 3; y++ {
+			for x := 0; x < 3; x++ {
+				if a := got.NRGBAAt(x, y).A; a != 255 {
+					t.Errorf("%s: alpha at (%d, %d) is %d, want 255", name, x, y, a)
+				}
+			}
+		}
+	}
+}
+
+// TestBoxUpscaleLinearStaysFlat checks that the pixels of a 2 to 3 box
+// upscale in linear light that no tap reaches still go through linear
+// light and back, so a flat translucent image stays flat
+func TestBoxUpscaleLinearStaysFlat(t *testing.T) {
+	flat := color.NRGBA{128, 128, 128, 128}
+	src := uniformImage(2, 2, flat)
+	want := uniformImage(3, 3, flat)
+	steps := []step{resampleStep{Width: 3, Height: 3, Filter: FilterBox, Linear: true}}
+	dev := openSimDevice(t, newSimDriver("Simulated GPU"))
+	gpu, _, err := runBatchGPU(context.Background(), dev, []*image.NRGBA{src}, [][]step{steps})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu, _, _ := runStepsCPU(context.Background(), src, steps)
+	for name, got := range map[string]*image.NRGBA{"cpu": cpu, "cuda-sim": gpu[0][0]} {
+		if err := diffImages(want, got, 1); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+// TestFiltersAgreeAcrossBackends resizes with every filter on the CPU and
+// on a simulated device, up and down. The simulated kernels run the same
+// Go pixel functions as the CPU backend, so this checks the launch
+// geometry and the arguments passed to each kernel, not the filter maths;
+// TestFilterValues covers those.
+func TestFiltersAgreeAcrossBackends(t *testing.T) {
+	r := resamplers(t)
+	src := gradientImage(31, 23)
+	sizes := []image.Point{{13, 7}, {20, 16}, {50, 37}}
+	for _, filter := range allFilters {
+		for _, size := range sizes {
+			want := r["cpu"](src, size.X, size.Y, filter)
+			got := r["cuda-sim"](src, size.X, size.Y, filter)
+			if err := diffImages(want, got, 0); err != nil {
+				t.Errorf("filter %d to %v: %v", filter, size, err)
+			}
+		}
+	}
+}
+
+// TestFiltersKeepFlatColour checks that every filter keeps a uniform image
+// uniform on every backend, so weights that do not sum to one show up
+func TestFiltersKeepFlatColour(t *testing.T) {
+	c := color.NRGBA{200, 100, 50, 255}
+	src := image.NewNRGBA(image.Rect(0, 0, 17, 11))
+	for y := 0; y < 11; y++ {
+		for x := 0; x < 17; x++ {
+			src.SetNRGBA(x, y, c)
+		}
+	}
+	want := image.NewNRGBA(image.Rect(0, 0, 40, 5))
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 40; x++ {
+			want.SetNRGBA(x, y, c)
+		}
+	}
+	for name, resample := range resamplers(t) {
+		for _, filter := range allFilters {
+			if err := diffImages(want, resample(src, 40, 5, filter), 1); err != nil {
+				t.Errorf("filter %d on %s: %v", filter, name, err)
+			}
+		}
+	}
+}
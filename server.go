@@ -0,0 +1,996 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jeauchter/go-image-adjuster/jpegenc"
+	pb "github.com/jeauchter/go-image-adjuster/proto"
+)
+
+// gRPC server implementation
+type server struct {
+	pb.UnimplementedImageResizerServer
+	backends       *Registry
+	policy         Policy
+	maxUploadBytes int         // Size limit for streamed uploads
+	batchWorkers   int         // Concurrent workers per ResizeBatch stream
+	assets         *assetStore // Overlay images and fonts that requests refer to by name
+}
+
+// filterFromProto maps the request filter to a Filter, defaulting to Lanczos3
+func filterFromProto(f pb.Filter) (Filter, error) {
+	switch {
+	case f == pb.Filter_FILTER_UNSPECIFIED:
+		return FilterLanczos3, nil
+	case f < pb.Filter_FILTER_NEAREST || f > pb.Filter_FILTER_BOX:
+		return 0, fmt.Errorf("%w: unknown filter %d", errInvalidRequest, f)
+	}
+	return Filter(f), nil
+}
+
+// fitFromProto maps the request fit mode to a Fit
+func fitFromProto(f pb.Fit) (Fit, error) {
+	switch f {
+	case pb.Fit_FIT_FILL:
+		return FitFill, nil
+	case pb.Fit_FIT_CONTAIN:
+		return FitContain, nil
+	case pb.Fit_FIT_COVER:
+		return FitCover, nil
+	case pb.Fit_FIT_INSIDE:
+		return FitInside, nil
+	case pb.Fit_FIT_OUTSIDE:
+		return FitOutside, nil
+	case pb.Fit_FIT_SEAM_CARVE:
+		return FitSeamCarve, nil
+	}
+	return 0, fmt.Errorf("%w: unknown fit %d", errInvalidRequest, f)
+}
+
+// colorFromProto converts a request colour, treating nil as transparent
+func colorFromProto(c *pb.Color) (color.NRGBA, error) {
+	if c == nil {
+		return color.NRGBA{}, nil
+	}
+	if c.GetR() > 255 || c.GetG() > 255 || c.GetB() > 255 || c.GetA() > 255 {
+		return color.NRGBA{}, fmt.Errorf("%w: colour components must be 0-255", errInvalidRequest)
+	}
+	return color.NRGBA{R: uint8(c.GetR()), G: uint8(c.GetG()), B: uint8(c.GetB()), A: uint8(c.GetA())}, nil
+}
+
+// encodeOptionsFromProto validates the output format and encoder settings.
+// The JPEG quality falls back to the request's top-level quality.
+func encodeOptionsFromProto(format pb.OutputFormat, opts *pb.EncodeOptions, quality uint32) (EncodeOptions, error) {
+	out := EncodeOptions{JPEGQuality: int(quality)}
+	if format < pb.OutputFormat_OUTPUT_FORMAT_SAME_AS_INPUT || format > pb.OutputFormat_OUTPUT_FORMAT_WEBP {
+		return out, fmt.Errorf("%w: unknown output format %d", errInvalidRequest, format)
+	}
+	out.Format = OutputFormat(format)
+
+	if q := opts.GetJpeg().GetQuality(); q != 0 {
+		out.JPEGQuality = int(q)
+	}
+	if out.JPEGQuality > 100 {
+		return out, fmt.Errorf("%w: JPEG quality must be 1-100", errInvalidRequest)
+	}
+	switch opts.GetJpeg().GetSubsampling() {
+	case pb.ChromaSubsampling_CHROMA_SUBSAMPLING_420:
+		out.JPEGSubsampling = jpegenc.Subsample420
+	case pb.ChromaSubsampling_CHROMA_SUBSAMPLING_422:
+		out.JPEGSubsampling = jpegenc.Subsample422
+	case pb.ChromaSubsampling_CHROMA_SUBSAMPLING_444:
+		out.JPEGSubsampling = jpegenc.Subsample444
+	default:
+		return out, fmt.Errorf("%w: unknown chroma subsampling %d", errInvalidRequest, opts.GetJpeg().GetSubsampling())
+	}
+
+	switch opts.GetPng().GetCompression() {
+	case pb.PngCompression_PNG_COMPRESSION_DEFAULT:
+		out.PNGCompression = png.DefaultCompression
+	case pb.PngCompression_PNG_COMPRESSION_NONE:
+		out.PNGCompression = png.NoCompression
+	case pb.PngCompression_PNG_COMPRESSION_BEST_SPEED:
+		out.PNGCompression = png.BestSpeed
+	case pb.PngCompression_PNG_COMPRESSION_BEST:
+		out.PNGCompression = png.BestCompression
+	default:
+		return out, fmt.Errorf("%w: unknown PNG compression %d", errInvalidRequest, opts.GetPng().GetCompression())
+	}
+
+	out.GIFColors = int(opts.GetGif().GetPaletteSize())
+	if out.GIFColors == 1 || out.GIFColors > 256 {
+		return out, fmt.Errorf("%w: GIF palette size must be 2-256", errInvalidRequest)
+	}
+	out.GIFDither = opts.GetGif().GetDither()
+	return out, nil
+}
+
+// negotiationFromProto validates a format negotiation and returns the
+// accepted formats and quality floor. A nil negotiation disables it.
+func negotiationFromProto(n *pb.FormatNegotiation, format pb.OutputFormat) ([]OutputFormat, float64, error) {
+	if n == nil {
+		return nil, 0, nil
+	}
+	if len(n.GetAccept()) == 0 {
+		return nil, 0, fmt.Errorf("%w: negotiation must accept at least one format", errInvalidRequest)
+	}
+	if format != pb.OutputFormat_OUTPUT_FORMAT_SAME_AS_INPUT {
+		return nil, 0, fmt.Errorf("%w: output_format cannot be combined with negotiation", errInvalidRequest)
+	}
+	if !(n.GetMinPsnr() >= 0) {
+		return nil, 0, fmt.Errorf("%w: minimum PSNR must not be negative", errInvalidRequest)
+	}
+
+	accept := make([]OutputFormat, len(n.GetAccept()))
+	for i, f := range n.GetAccept() {
+		if f < pb.OutputFormat_OUTPUT_FORMAT_SAME_AS_INPUT || f > pb.OutputFormat_OUTPUT_FORMAT_WEBP {
+			return nil, 0, fmt.Errorf("%w: unknown output format %d", errInvalidRequest, f)
+		}
+		accept[i] = OutputFormat(f)
+	}
+	return accept, n.GetMinPsnr(), nil
+}
+
+// candidatesToProto converts the negotiated encodings for the response
+func candidatesToProto(candidates []FormatCandidate) []*pb.FormatCandidate {
+	var out []*pb.FormatCandidate
+	for _, c := range candidates {
+		out = append(out, &pb.FormatCandidate{
+			Format:     pb.OutputFormat(c.Format),
+			Size:       uint32(c.Size),
+			Psnr:       c.PSNR,
+			Acceptable: c.Acceptable,
+		})
+	}
+	return out
+}
+
+// rectsToProto converts the regions picked by smart crops for the response
+func rectsToProto(rects []image.Rectangle) []*pb.CropRect {
+	var out []*pb.CropRect
+	for _, r := range rects {
+		out = append(out, &pb.CropRect{
+			X:      uint32(r.Min.X),
+			Y:      uint32(r.Min.Y),
+			Width:  uint32(r.Dx()),
+			Height: uint32(r.Dy()),
+		})
+	}
+	return out
+}
+
+// indicesToProto converts the indices of the focus regions an output cut
+func indicesToProto(indices []int) []uint32 {
+	var out []uint32
+	for _, i := range indices {
+		out = append(out, uint32(i))
+	}
+	return out
+}
+
+// maxVariants limits the number of outputs one request may ask for
+const maxVariants = 16
+
+// maxOperations limits the length of one pipeline
+const maxOperations = 32
+
+// maxFocusRegions limits the regions of interest of one request
+const maxFocusRegions = 32
+
+// Limits on the filter sizes, which bound the work per pixel
+const (
+	maxBlurSigma     = 50
+	maxBoxBlurRadius = 100
+	maxUnsharpRadius = 10
+	maxUnsharpAmount = 5
+)
+
+// Limits on text operations
+const (
+	maxTextBytes    = 4096
+	maxTextSize     = 500
+	maxTextStroke   = 20
+	maxShadowBlur   = 20
+	maxShadowOffset = 100
+)
+
+// pipelineFromProto validates a pipeline and converts its operations
+func pipelineFromProto(p *pb.Pipeline, assets *assetStore) ([]Operation, error) {
+	if len(p.GetOperations()) > maxOperations {
+		return nil, fmt.Errorf("%w: at most %d operations per pipeline", errInvalidRequest, maxOperations)
+	}
+	ops := make([]Operation, len(p.GetOperations()))
+	for i, op := range p.GetOperations() {
+		var err error
+		if ops[i], err = operationFromProto(op, assets); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return ops, nil
+}
+
+// operationFromProto converts one pipeline operation
+func operationFromProto(op *pb.Operation, assets *assetStore) (Operation, error) {
+	switch op := op.GetOp().(type) {
+	case *pb.Operation_Resize:
+		return resizeFromProto(op.Resize)
+	case *pb.Operation_Crop:
+		return cropFromProto(op.Crop)
+	case *pb.Operation_Rotate:
+		background, err := colorFromProto(op.Rotate.GetBackground())
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(op.Rotate.GetAngle()) || math.IsInf(op.Rotate.GetAngle(), 0) {
+			return nil, fmt.Errorf("%w: rotation angle must be finite", errInvalidRequest)
+		}
+		return RotateOp{Angle: op.Rotate.GetAngle(), Background: background}, nil
+	case *pb.Operation_Flip:
+		return FlipOp{Horizontal: op.Flip.GetHorizontal(), Vertical: op.Flip.GetVertical()}, nil
+	case *pb.Operation_Adjust:
+		return adjustFromProto(op.Adjust)
+	case *pb.Operation_Blur:
+		if !(op.Blur.GetSigma() > 0 && op.Blur.GetSigma() <= maxBlurSigma) {
+			return nil, fmt.Errorf("%w: blur sigma must be greater than 0 and at most %d", errInvalidRequest, maxBlurSigma)
+		}
+		return BlurOp{Sigma: op.Blur.GetSigma()}, nil
+	case *pb.Operation_BoxBlur:
+		if op.BoxBlur.GetRadius() < 1 || op.BoxBlur.GetRadius() > maxBoxBlurRadius {
+			return nil, fmt.Errorf("%w: box blur radius must be 1 to %d", errInvalidRequest, maxBoxBlurRadius)
+		}
+		return BoxBlurOp{Radius: int(op.BoxBlur.GetRadius())}, nil
+	case *pb.Operation_UnsharpMask:
+		return unsharpFromProto(op.UnsharpMask)
+	case *pb.Operation_Overlay:
+		return overlayFromProto(op.Overlay, assets)
+	case *pb.Operation_Text:
+		return textFromProto(op.Text, assets)
+	}
+	return nil, fmt.Errorf("%w: operation is empty or unknown", errInvalidRequest)
+}
+
+// checkOutputSize rejects a requested size over maxOutputPixels before
+// anything is decoded. Sizes derived from the aspect ratio are checked
+// when the pipeline is planned.
+func checkOutputSize(width, height uint32) error {
+	if width > maxOutputPixels || height > maxOutputPixels || uint64(width)*uint64(height) > maxOutputPixels {
+		return fmt.Errorf("%w: %dx%d is over the limit of %d pixels", errInvalidRequest, width, height, maxOutputPixels)
+	}
+	return nil
+}
+
+// resizeFromProto converts a resize operation
+func resizeFromProto(r *pb.ResizeOperation) (ResizeOp, error) {
+	if err := checkOutputSize(r.GetWidth(), r.GetHeight()); err != nil {
+		return ResizeOp{}, err
+	}
+	filter, err := filterFromProto(r.GetFilter())
+	if err != nil {
+		return ResizeOp{}, err
+	}
+	fit, err := fitFromProto(r.GetFit())
+	if err != nil {
+		return ResizeOp{}, err
+	}
+	background, err := colorFromProto(r.GetBackground())
+	if err != nil {
+		return ResizeOp{}, err
+	}
+	return ResizeOp{
+		Width:       int(r.GetWidth()),
+		Height:      int(r.GetHeight()),
+		Filter:      filter,
+		Fit:         fit,
+		Background:  background,
+		AutoSharpen: r.GetAutoSharpen(),
+		LinearLight: r.GetLinearLight(),
+	}, nil
+}
+
+// cropFromProto converts a crop operation
+func cropFromProto(c *pb.CropOperation) (Operation, error) {
+	switch region := c.GetRegion().(type) {
+	case *pb.CropOperation_Rect:
+		r := region.Rect
+		if r.GetWidth() == 0 || r.GetHeight() == 0 {
+			return nil, fmt.Errorf("%w: crop rectangle must not be empty", errInvalidRequest)
+		}
+		x, y := int(r.GetX()), int(r.GetY())
+		return CropOp{Rect: image.Rect(x, y, x+int(r.GetWidth()), y+int(r.GetHeight()))}, nil
+	case *pb.CropOperation_Percent:
+		p := region.Percent
+		inRange := func(v float64) bool { return v >= 0 && v <= 100 }
+		if !inRange(p.GetX()) || !inRange(p.GetY()) || !inRange(p.GetWidth()) || !inRange(p.GetHeight()) {
+			return nil, fmt.Errorf("%w: crop percentages must be 0-100", errInvalidRequest)
+		}
+		if p.GetWidth() == 0 || p.GetHeight() == 0 {
+			return nil, fmt.Errorf("%w: crop rectangle must not be empty", errInvalidRequest)
+		}
+		return CropPercentOp{X: p.GetX(), Y: p.GetY(), Width: p.GetWidth(), Height: p.GetHeight()}, nil
+	case *pb.CropOperation_Size:
+		gravity, err := gravityFromProto(region.Size.GetGravity())
+		if err != nil {
+			return nil, err
+		}
+		return CropGravityOp{Width: int(region.Size.GetWidth()), Height: int(region.Size.GetHeight()), Gravity: gravity}, nil
+	}
+	return nil, fmt.Errorf("%w: crop needs a rect, percent or size", errInvalidRequest)
+}
+
+// adjustFromProto validates a colour adjustment
+func adjustFromProto(a *pb.AdjustOperation) (AdjustOp, error) {
+	unit := func(v float64) bool { return v >= -1 && v <= 1 }
+	switch {
+	case !unit(a.GetBrightness()):
+		return AdjustOp{}, fmt.Errorf("%w: brightness must be -1 to 1", errInvalidRequest)
+	case !unit(a.GetContrast()):
+		return AdjustOp{}, fmt.Errorf("%w: contrast must be -1 to 1", errInvalidRequest)
+	case !unit(a.GetSaturation()):
+		return AdjustOp{}, fmt.Errorf("%w: saturation must be -1 to 1", errInvalidRequest)
+	case !(a.GetGamma() >= 0) || math.IsInf(a.GetGamma(), 0):
+		return AdjustOp{}, fmt.Errorf("%w: gamma must be positive, or 0 for no change", errInvalidRequest)
+	case math.IsNaN(a.GetHue()) || math.IsInf(a.GetHue(), 0):
+		return AdjustOp{}, fmt.Errorf("%w: hue rotation must be finite", errInvalidRequest)
+	}
+	return AdjustOp{
+		Brightness: a.GetBrightness(),
+		Contrast:   a.GetContrast(),
+		Gamma:      a.GetGamma(),
+		Saturation: a.GetSaturation(),
+		Hue:        a.GetHue(),
+		Grayscale:  a.GetGrayscale(),
+		Sepia:      a.GetSepia(),
+	}, nil
+}
+
+// unsharpFromProto validates an unsharp mask
+func unsharpFromProto(u *pb.UnsharpMaskOperation) (UnsharpMaskOp, error) {
+	switch {
+	case !(u.GetRadius() > 0 && u.GetRadius() <= maxUnsharpRadius):
+		return UnsharpMaskOp{}, fmt.Errorf("%w: unsharp radius must be greater than 0 and at most %d", errInvalidRequest, maxUnsharpRadius)
+	case !(u.GetAmount() >= 0 && u.GetAmount() <= maxUnsharpAmount):
+		return UnsharpMaskOp{}, fmt.Errorf("%w: unsharp amount must be 0 to %d", errInvalidRequest, maxUnsharpAmount)
+	case u.GetThreshold() > 255:
+		return UnsharpMaskOp{}, fmt.Errorf("%w: unsharp threshold must be 0 to 255", errInvalidRequest)
+	}
+	return UnsharpMaskOp{Sigma: u.GetRadius(), Amount: u.GetAmount(), Threshold: int(u.GetThreshold())}, nil
+}
+
+// overlayFromProto validates an overlay and decodes or looks up its image
+func overlayFromProto(o *pb.OverlayOperation, assets *assetStore) (OverlayOp, error) {
+	if o == nil {
+		return OverlayOp{}, fmt.Errorf("%w: overlay needs an image or an asset", errInvalidRequest)
+	}
+	gravity, err := placementFromProto(o.GetGravity())
+	if err != nil {
+		return OverlayOp{}, err
+	}
+	if o.GetBlend() < pb.BlendMode_BLEND_NORMAL || o.GetBlend() > pb.BlendMode_BLEND_SCREEN {
+		return OverlayOp{}, fmt.Errorf("%w: unknown blend mode %d", errInvalidRequest, o.GetBlend())
+	}
+	opacity := 1.0
+	if o.Opacity != nil {
+		opacity = o.GetOpacity()
+	}
+	if !(opacity >= 0 && opacity <= 1) {
+		return OverlayOp{}, fmt.Errorf("%w: overlay opacity must be 0-1", errInvalidRequest)
+	}
+	if !(o.GetScale() >= 0 && o.GetScale() <= 1) {
+		return OverlayOp{}, fmt.Errorf("%w: overlay scale must be 0-1", errInvalidRequest)
+	}
+
+	var img *image.NRGBA
+	switch source := o.GetSource().(type) {
+	case *pb.OverlayOperation_Image:
+		img, _, err = decodeToNRGBA(source.Image)
+		if errors.Is(err, errInvalidRequest) {
+			return OverlayOp{}, fmt.Errorf("overlay image: %w", err)
+		}
+		if err != nil {
+			return OverlayOp{}, fmt.Errorf("%w: overlay image: %v", errInvalidRequest, err)
+		}
+	case *pb.OverlayOperation_Asset:
+		if img = assets.image(source.Asset); img == nil {
+			return OverlayOp{}, fmt.Errorf("%w: unknown overlay asset %q", errInvalidRequest, source.Asset)
+		}
+	default:
+		return OverlayOp{}, fmt.Errorf("%w: overlay needs an image or an asset", errInvalidRequest)
+	}
+
+	return OverlayOp{
+		Image:   img,
+		Gravity: gravity,
+		Offset:  image.Pt(int(o.GetOffsetX()), int(o.GetOffsetY())),
+		Opacity: opacity,
+		Scale:   o.GetScale(),
+		Tile:    o.GetTile(),
+		Blend:   BlendMode(o.GetBlend()),
+	}, nil
+}
+
+// textFromProto validates a text operation and looks up its font
+func textFromProto(t *pb.TextOperation, assets *assetStore) (TextOp, error) {
+	switch {
+	case t.GetText() == "":
+		return TextOp{}, fmt.Errorf("%w: text must not be empty", errInvalidRequest)
+	case len(t.GetText()) > maxTextBytes:
+		return TextOp{}, fmt.Errorf("%w: text must be at most %d bytes", errInvalidRequest, maxTextBytes)
+	case !(t.GetSize() > 0 && t.GetSize() <= maxTextSize):
+		return TextOp{}, fmt.Errorf("%w: text size must be greater than 0 and at most %d", errInvalidRequest, maxTextSize)
+	case !(t.GetStrokeWidth() >= 0 && t.GetStrokeWidth() <= maxTextStroke):
+		return TextOp{}, fmt.Errorf("%w: stroke width must be 0-%d", errInvalidRequest, maxTextStroke)
+	case !(t.GetShadow().GetBlur() >= 0 && t.GetShadow().GetBlur() <= maxShadowBlur):
+		return TextOp{}, fmt.Errorf("%w: shadow blur must be 0-%d", errInvalidRequest, maxShadowBlur)
+	case abs(int(t.GetShadow().GetOffsetX())) > maxShadowOffset || abs(int(t.GetShadow().GetOffsetY())) > maxShadowOffset:
+		return TextOp{}, fmt.Errorf("%w: shadow offsets must be at most %d pixels", errInvalidRequest, maxShadowOffset)
+	case math.IsNaN(t.GetAngle()) || math.IsInf(t.GetAngle(), 0):
+		return TextOp{}, fmt.Errorf("%w: text angle must be finite", errInvalidRequest)
+	case t.GetAlign() < pb.TextAlign_TEXT_ALIGN_LEFT || t.GetAlign() > pb.TextAlign_TEXT_ALIGN_RIGHT:
+		return TextOp{}, fmt.Errorf("%w: unknown text alignment %d", errInvalidRequest, t.GetAlign())
+	}
+
+	name := t.GetFont()
+	if name == "" {
+		name = defaultFont
+	}
+	f := assets.font(name)
+	if f == nil {
+		return TextOp{}, fmt.Errorf("%w: unknown font %q", errInvalidRequest, name)
+	}
+	gravity, err := placementFromProto(t.GetGravity())
+	if err != nil {
+		return TextOp{}, err
+	}
+
+	// Unset colours default to black rather than transparent
+	colour := func(c *pb.Color, fallback color.NRGBA) (color.NRGBA, error) {
+		if c == nil {
+			return fallback, nil
+		}
+		return colorFromProto(c)
+	}
+	fill, err := colour(t.GetColor(), color.NRGBA{A: 255})
+	if err != nil {
+		return TextOp{}, err
+	}
+	stroke, err := colour(t.GetStrokeColor(), color.NRGBA{A: 255})
+	if err != nil {
+		return TextOp{}, err
+	}
+	op := TextOp{
+		Text:        t.GetText(),
+		Font:        f,
+		Size:        t.GetSize(),
+		Color:       fill,
+		Stroke:      t.GetStrokeWidth(),
+		StrokeColor: stroke,
+		Align:       TextAlign(t.GetAlign()),
+		Gravity:     gravity,
+		Angle:       t.GetAngle(),
+	}
+	if sh := t.GetShadow(); sh != nil {
+		if op.ShadowColor, err = colour(sh.GetColor(), color.NRGBA{A: 160}); err != nil {
+			return TextOp{}, err
+		}
+		op.Shadow = image.Pt(int(sh.GetOffsetX()), int(sh.GetOffsetY()))
+		op.ShadowBlur = sh.GetBlur()
+	}
+	if b := t.GetBox(); b != nil {
+		if b.GetWidth() == 0 || b.GetHeight() == 0 {
+			return TextOp{}, fmt.Errorf("%w: text box must not be empty", errInvalidRequest)
+		}
+		x, y := int(b.GetX()), int(b.GetY())
+		op.Box = image.Rect(x, y, x+int(b.GetWidth()), y+int(b.GetHeight()))
+	}
+	return op, nil
+}
+
+// gravityFromProto maps a request gravity to a Gravity
+func gravityFromProto(g pb.Gravity) (Gravity, error) {
+	if g < pb.Gravity_GRAVITY_CENTER || g > pb.Gravity_GRAVITY_SMART {
+		return 0, fmt.Errorf("%w: unknown gravity %d", errInvalidRequest, g)
+	}
+	return Gravity(g), nil
+}
+
+// placementFromProto maps the gravity that places an overlay or text,
+// which cannot be smart
+func placementFromProto(g pb.Gravity) (Gravity, error) {
+	if g == pb.Gravity_GRAVITY_SMART {
+		return 0, fmt.Errorf("%w: smart gravity only applies to crops", errInvalidRequest)
+	}
+	return gravityFromProto(g)
+}
+
+// outputSpecMessage is implemented by the messages that describe an
+// output: ResizeImageRequest and OutputVariant
+type outputSpecMessage interface {
+	GetWidth() uint32
+	GetHeight() uint32
+	GetQuality() uint32
+	GetFilter() pb.Filter
+	GetFit() pb.Fit
+	GetBackground() *pb.Color
+	GetOutputFormat() pb.OutputFormat
+	GetEncodeOptions() *pb.EncodeOptions
+	GetNegotiate() *pb.FormatNegotiation
+	GetAutoSharpen() bool
+	GetLinearLight() bool
+	GetPipeline() *pb.Pipeline
+}
+
+// outputSpecFromProto validates an output description
+func outputSpecFromProto(m outputSpecMessage, assets *assetStore) (OutputSpec, error) {
+	if err := checkOutputSize(m.GetWidth(), m.GetHeight()); err != nil {
+		return OutputSpec{}, err
+	}
+	filter, err := filterFromProto(m.GetFilter())
+	if err != nil {
+		return OutputSpec{}, err
+	}
+	fit, err := fitFromProto(m.GetFit())
+	if err != nil {
+		return OutputSpec{}, err
+	}
+	background, err := colorFromProto(m.GetBackground())
+	if err != nil {
+		return OutputSpec{}, err
+	}
+	output, err := encodeOptionsFromProto(m.GetOutputFormat(), m.GetEncodeOptions(), m.GetQuality())
+	if err != nil {
+		return OutputSpec{}, err
+	}
+	output.Accept, output.MinPSNR, err = negotiationFromProto(m.GetNegotiate(), m.GetOutputFormat())
+	if err != nil {
+		return OutputSpec{}, err
+	}
+	pipeline, err := pipelineFromProto(m.GetPipeline(), assets)
+	if err != nil {
+		return OutputSpec{}, err
+	}
+	if len(pipeline) > 0 && (m.GetWidth() != 0 || m.GetHeight() != 0 || m.GetFilter() != pb.Filter_FILTER_UNSPECIFIED ||
+		m.GetFit() != pb.Fit_FIT_FILL || m.GetBackground() != nil || m.GetAutoSharpen() || m.GetLinearLight()) {
+		return OutputSpec{}, fmt.Errorf("%w: width, height, filter, fit, background, auto_sharpen and linear_light cannot be combined with a pipeline", errInvalidRequest)
+	}
+
+	return OutputSpec{
+		Width:       int(m.GetWidth()),
+		Height:      int(m.GetHeight()),
+		Output:      output,
+		Filter:      filter,
+		Fit:         fit,
+		Background:  background,
+		AutoSharpen: m.GetAutoSharpen(),
+		LinearLight: m.GetLinearLight(),
+		Pipeline:    pipeline,
+	}, nil
+}
+
+// jobFromRequest validates a request and converts it to a Job; assets
+// resolve the overlays that name one
+func jobFromRequest(req *pb.ResizeImageRequest, assets *assetStore) (*Job, error) {
+	spec, err := outputSpecFromProto(req, assets)
+	if err != nil {
+		return nil, err
+	}
+	job := &Job{ImageData: req.GetImageData(), OutputSpec: spec, IgnoreOrientation: req.AutoOrient != nil && !req.GetAutoOrient()}
+
+	if len(req.GetVariants()) > maxVariants {
+		return nil, fmt.Errorf("%w: at most %d variants per request", errInvalidRequest, maxVariants)
+	}
+	for i, v := range req.GetVariants() {
+		spec, err := outputSpecFromProto(v, assets)
+		if err != nil {
+			return nil, fmt.Errorf("variant %d (%s): %w", i, v.GetName(), err)
+		}
+		job.Variants = append(job.Variants, Variant{Name: v.GetName(), OutputSpec: spec})
+	}
+
+	if job.Focus, err = focusFromProto(req.GetFocus()); err != nil {
+		return nil, err
+	}
+	if req.GpuId != nil {
+		gpu := int(req.GetGpuId())
+		job.GPU = &gpu
+	}
+	return job, nil
+}
+
+// focusFromProto validates a focal point and regions of interest
+func focusFromProto(f *pb.Focus) (Focus, error) {
+	var focus Focus
+	unit := func(v float64) bool { return v >= 0 && v <= 1 }
+	if p := f.GetPoint(); p != nil {
+		if !unit(p.GetX()) || !unit(p.GetY()) {
+			return Focus{}, fmt.Errorf("%w: focal point must be 0-1", errInvalidRequest)
+		}
+		focus.Point = &FocalPoint{X: p.GetX(), Y: p.GetY()}
+	}
+	if len(f.GetRegions()) > maxFocusRegions {
+		return Focus{}, fmt.Errorf("%w: at most %d focus regions", errInvalidRequest, maxFocusRegions)
+	}
+	for i, r := range f.GetRegions() {
+		if !unit(r.GetX()) || !unit(r.GetY()) || !(r.GetWidth() > 0) || !(r.GetHeight() > 0) ||
+			!unit(r.GetX()+r.GetWidth()) || !unit(r.GetY()+r.GetHeight()) {
+			return Focus{}, fmt.Errorf("%w: focus region %d must be a non-empty rectangle within 0-1", errInvalidRequest, i)
+		}
+		focus.Regions = append(focus.Regions, FocusRegion{X: r.GetX(), Y: r.GetY(), Width: r.GetWidth(), Height: r.GetHeight()})
+	}
+	return focus, nil
+}
+
+func (s *server) ResizeImage(ctx context.Context, req *pb.ResizeImageRequest) (*pb.ResizeImageResponse, error) {
+	// Check if the context is canceled before doing expensive work
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		// proceed
+	}
+	log.Println("Received resize request")
+
+	job, err := jobFromRequest(req, s.assets)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return s.process(ctx, job)
+}
+
+// ResizeImageStream receives the image in chunks after a header message.
+// The upload is decoded while it arrives and stops at maxUploadBytes.
+func (s *server) ResizeImageStream(stream pb.ImageResizer_ResizeImageStreamServer) error {
+	ctx := stream.Context()
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	header := first.GetHeader()
+	if header == nil {
+		return status.Error(codes.InvalidArgument, "the first message must be the header")
+	}
+	log.Println("Received streamed resize request")
+
+	job, err := jobFromRequest(header, s.assets)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Decode in the background, reading chunks as they are written
+	type decoded struct {
+		img         *image.NRGBA
+		format      string
+		orientation Orientation
+		err         error
+	}
+	pr, pw := io.Pipe()
+	done := make(chan decoded, 1)
+	go func() {
+		img, format, orientation, err := decodeStreamToNRGBA(pr)
+		if err != nil {
+			pr.CloseWithError(err)
+		}
+		done <- decoded{img, format, orientation, err}
+	}()
+
+	received := 0
+	data := header.GetImageData()
+	for {
+		received += len(data)
+		if received > s.maxUploadBytes {
+			pw.CloseWithError(errors.New("upload too large"))
+			return status.Errorf(codes.ResourceExhausted, "image exceeds the %d byte upload limit", s.maxUploadBytes)
+		}
+		if _, err := pw.Write(data); err != nil {
+			// The decoder failed and stopped reading
+			break
+		}
+
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			pw.CloseWithError(err)
+			return err
+		}
+		if msg.GetHeader() != nil {
+			pw.CloseWithError(errInvalidRequest)
+			return status.Error(codes.InvalidArgument, "only the first message may be a header")
+		}
+		data = msg.GetData()
+	}
+	pw.Close()
+
+	result := <-done
+	if result.err != nil {
+		if errors.Is(result.err, errInvalidRequest) {
+			return status.Error(codes.InvalidArgument, result.err.Error())
+		}
+		return result.err
+	}
+	job.Source, job.SourceFormat, job.SourceOrientation = result.img, result.format, result.orientation
+
+	resp, err := s.process(ctx, job)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(resp)
+}
+
+// downloadChunkSize is the size of each chunk sent by ResizeImageDownload
+const downloadChunkSize = 1 << 20
+
+// ResizeImageDownload sends the response metadata, then each image in
+// chunks followed by its checksum
+func (s *server) ResizeImageDownload(req *pb.ResizeImageRequest, stream pb.ImageResizer_ResizeImageDownloadServer) error {
+	log.Println("Received resize request for chunked download")
+
+	job, err := jobFromRequest(req, s.assets)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	resp, err := s.process(stream.Context(), job)
+	if err != nil {
+		return err
+	}
+
+	// Send the metadata without image bytes, then each image in sections
+	var images [][]byte
+	if len(resp.Variants) == 0 {
+		images = append(images, resp.ResizedImage)
+		resp.ResizedImage = nil
+	}
+	for _, v := range resp.Variants {
+		images = append(images, v.Image)
+		v.Image = nil
+	}
+	if err := stream.Send(&pb.ResizeImageDownloadChunk{Payload: &pb.ResizeImageDownloadChunk_Metadata{Metadata: resp}}); err != nil {
+		return err
+	}
+	for _, data := range images {
+		if err := sendDownloadImage(stream, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendDownloadImage sends one image as data chunks followed by its checksum
+func sendDownloadImage(stream pb.ImageResizer_ResizeImageDownloadServer, data []byte) error {
+	sum := sha256.Sum256(data)
+	for offset := 0; offset < len(data); offset += downloadChunkSize {
+		chunk := data[offset:min(offset+downloadChunkSize, len(data))]
+		if err := stream.Send(&pb.ResizeImageDownloadChunk{Payload: &pb.ResizeImageDownloadChunk_Data{Data: chunk}}); err != nil {
+			return err
+		}
+	}
+	return stream.Send(&pb.ResizeImageDownloadChunk{Payload: &pb.ResizeImageDownloadChunk_Checksum{
+		Checksum: &pb.DownloadChecksum{Size: uint64(len(data)), Sha256: sum[:]},
+	}})
+}
+
+// maxBatchItems caps how many queued batch items a worker takes at once
+const maxBatchItems = 16
+
+// ResizeBatch processes a stream of items on batchWorkers workers. When
+// the backend chosen for an item can batch, its worker also takes whatever
+// items are queued, up to maxBatchItems, so the backend can group
+// compatible items; otherwise items run one per worker. Every item gets its
+// own result, in completion order, until sending one fails or the stream
+// ends.
+func (s *server) ResizeBatch(stream pb.ImageResizer_ResizeBatchServer) error {
+	ctx := stream.Context()
+	log.Println("Received batch resize stream")
+
+	var sendMu sync.Mutex
+	var sendErr error
+	send := func(id string, resp *pb.ResizeImageResponse, err error) {
+		result := &pb.BatchResult{Id: id, Response: resp}
+		if err != nil {
+			st, _ := status.FromError(err)
+			result.Code = uint32(st.Code())
+			result.ErrorMessage = st.Message()
+		}
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		if sendErr == nil {
+			sendErr = stream.Send(result)
+		}
+	}
+	// Once results cannot be sent, the remaining items are dropped
+	stopped := func() bool {
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		return sendErr != nil || ctx.Err() != nil
+	}
+
+	type batchJob struct {
+		id  string
+		job *Job
+	}
+	queue := make(chan batchJob, s.batchWorkers*maxBatchItems)
+	var wg sync.WaitGroup
+	for range s.batchWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for first := range queue {
+				if stopped() {
+					continue
+				}
+				group := []batchJob{first}
+				candidates := s.policy(ctx, first.job, s.backends.Backends())
+				_, batches := firstBackend(candidates).(BatchBackend)
+			drain:
+				for batches && len(group) < maxBatchItems {
+					select {
+					case item, ok := <-queue:
+						if !ok {
+							break drain
+						}
+						group = append(group, item)
+					default:
+						break drain
+					}
+				}
+
+				jobs := make([]*Job, len(group))
+				for i, item := range group {
+					jobs[i] = item.job
+				}
+				responses, errs := s.processOn(ctx, candidates, jobs)
+				for i, item := range group {
+					send(item.id, responses[i], errs[i])
+				}
+			}
+		}()
+	}
+
+	var recvErr error
+	for {
+		item, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			recvErr = err
+			break
+		}
+		if stopped() {
+			break
+		}
+		job, err := jobFromRequest(item.GetRequest(), s.assets)
+		if err != nil {
+			send(item.GetId(), nil, status.Error(codes.InvalidArgument, err.Error()))
+			continue
+		}
+		queue <- batchJob{id: item.GetId(), job: job}
+	}
+	close(queue)
+	wg.Wait()
+
+	if recvErr != nil {
+		return recvErr
+	}
+	return sendErr
+}
+
+// process runs a job on the backends chosen by the policy
+func (s *server) process(ctx context.Context, job *Job) (*pb.ResizeImageResponse, error) {
+	responses, errs := s.processBatch(ctx, []*Job{job})
+	return responses[0], errs[0]
+}
+
+// processBatch runs jobs on the backends chosen by the policy for the first
+// job
+func (s *server) processBatch(ctx context.Context, jobs []*Job) ([]*pb.ResizeImageResponse, []error) {
+	return s.processOn(ctx, s.policy(ctx, jobs[0], s.backends.Backends()), jobs)
+}
+
+// firstBackend returns the backend tried first, or nil when there is none
+func firstBackend(candidates []Backend) Backend {
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[0]
+}
+
+// processOn runs jobs on the candidate backends in order. Jobs that fail on
+// a backend fall back to the next one, and each backend gets all of its
+// pending jobs at once so it can batch them.
+func (s *server) processOn(ctx context.Context, candidates []Backend, jobs []*Job) ([]*pb.ResizeImageResponse, []error) {
+	responses := make([]*pb.ResizeImageResponse, len(jobs))
+	errs := make([]error, len(jobs))
+
+	if len(candidates) == 0 {
+		for i := range errs {
+			errs[i] = errors.New("no backend available to process the request")
+		}
+		return responses, errs
+	}
+
+	// Try each candidate in turn, falling back on failure
+	pending := make([]int, len(jobs))
+	for i := range pending {
+		pending[i] = i
+	}
+	for _, b := range candidates {
+		if len(pending) == 0 {
+			break
+		}
+		log.Printf("Using %s backend for resizing %d images", b.Name(), len(pending))
+		batch := make([]*Job, len(pending))
+		for i, j := range pending {
+			batch[i] = jobs[j]
+		}
+		results, batchErrs := processJobs(ctx, b, batch)
+
+		var retry []int
+		for i, j := range pending {
+			err := batchErrs[i]
+			if err == nil {
+				log.Printf("%s resizing successful on %s", b.Name(), results[i].DeviceName)
+				responses[j], errs[j] = responseFromResult(b, results[i]), nil
+				continue
+			}
+			log.Printf("%s resizing failed: %v", b.Name(), err)
+			if errors.Is(err, errInvalidRequest) {
+				errs[j] = status.Error(codes.InvalidArgument, err.Error())
+				continue
+			}
+			if ctx.Err() != nil {
+				// No other backend would finish in time either
+				errs[j] = status.FromContextError(ctx.Err()).Err()
+				continue
+			}
+			errs[j] = fmt.Errorf("%s resize failed: %w", b.Name(), err)
+			retry = append(retry, j)
+		}
+		pending = retry
+	}
+	return responses, errs
+}
+
+// responseFromResult converts a backend result for the response
+func responseFromResult(b Backend, result *Result) *pb.ResizeImageResponse {
+	resp := &pb.ResizeImageResponse{
+		ResizedImage:     result.Image,
+		OutputFormat:     pb.OutputFormat(result.Format),
+		FormatCandidates: candidatesToProto(result.Candidates),
+		SmartCrops:       rectsToProto(result.SmartCrops),
+		FocalPointLost:   result.FocusLoss.Point,
+		LostRegions:      indicesToProto(result.FocusLoss.Regions),
+		UsedGpu:          b.Capabilities().GPU,
+		GpuId:            uint32(result.DeviceID),
+		DeviceName:       result.DeviceName,
+		Width:            uint32(result.Width),
+		Height:           uint32(result.Height),
+	}
+	for _, v := range result.Variants {
+		resp.Variants = append(resp.Variants, &pb.VariantImage{
+			Name:             v.Name,
+			Image:            v.Image,
+			Width:            uint32(v.Width),
+			Height:           uint32(v.Height),
+			OutputFormat:     pb.OutputFormat(v.Format),
+			FormatCandidates: candidatesToProto(v.Candidates),
+			SmartCrops:       rectsToProto(v.SmartCrops),
+			FocalPointLost:   v.FocusLoss.Point,
+			LostRegions:      indicesToProto(v.FocusLoss.Regions),
+		})
+	}
+	return resp
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/jeauchter/go-image-adjuster/proto"
+)
+
+// fakeUploadStream feeds chunks to ResizeImageStream and keeps its response
+type fakeUploadStream struct {
+	grpc.ServerStream
+	chunks   []*pb.ResizeImageChunk
+	received int // Chunks read by the server
+	resp     *pb.ResizeImageResponse
+}
+
+func (s *fakeUploadStream) Context() context.Context { return context.Background() }
+
+func (s *fakeUploadStream) Recv() (*pb.ResizeImageChunk, error) {
+	if s.received == len(s.chunks) {
+		return nil, io.EOF
+	}
+	s.received++
+	return s.chunks[s.received-1], nil
+}
+
+func (s *fakeUploadStream) SendAndClose(resp *pb.ResizeImageResponse) error {
+	s.resp = resp
+	return nil
+}
+
+// uploadChunks splits an upload into a header carrying the first
+// inHeader bytes and data chunks of chunkSize bytes
+func uploadChunks(req *pb.ResizeImageRequest, data []byte, inHeader, chunkSize int) []*pb.ResizeImageChunk {
+	req.ImageData = data[:inHeader]
+	chunks := []*pb.ResizeImageChunk{{Payload: &pb.ResizeImageChunk_Header{Header: req}}}
+	for rest := data[inHeader:]; len(rest) > 0; {
+		n := min(chunkSize, len(rest))
+		chunks = append(chunks, &pb.ResizeImageChunk{Payload: &pb.ResizeImageChunk_Data{Data: rest[:n]}})
+		rest = rest[n:]
+	}
+	return chunks
+}
+
+func TestResizeImageStream(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, gradientImage(40, 30)); err != nil {
+		t.Fatal(err)
+	}
+	img := buf.Bytes()
+	resize := func() *pb.ResizeImageRequest { return &pb.ResizeImageRequest{Width: 20} }
+	dataChunk := &pb.ResizeImageChunk{Payload: &pb.ResizeImageChunk_Data{Data: img}}
+	headerChunk := &pb.ResizeImageChunk{Payload: &pb.ResizeImageChunk_Header{Header: resize()}}
+
+	tests := []struct {
+		name   string
+		chunks []*pb.ResizeImageChunk
+		limit  int
+		code   codes.Code
+	}{
+		{"chunked", uploadChunks(resize(), img, 0, 100), 1 << 20, codes.OK},
+		{"all in header", uploadChunks(resize(), img, len(img), 100), 1 << 20, codes.OK},
+		{"header and chunks", uploadChunks(resize(), img, 50, 7), 1 << 20, codes.OK},
+		{"exactly at the limit", uploadChunks(resize(), img, 0, 100), len(img), codes.OK},
+		{"one byte over the limit", uploadChunks(resize(), img, 0, 10), len(img) - 1, codes.ResourceExhausted},
+		{"far over the limit", uploadChunks(resize(), img, 0, 10), len(img) / 3, codes.ResourceExhausted},
+		{"no header", []*pb.ResizeImageChunk{dataChunk}, 1 << 20, codes.InvalidArgument},
+		{"second header", []*pb.ResizeImageChunk{headerChunk, dataChunk, headerChunk}, 1 << 20, codes.InvalidArgument},
+		{"empty", uploadChunks(resize(), nil, 0, 100), 1 << 20, codes.InvalidArgument},
+		{"not an image", uploadChunks(resize(), []byte("definitely not an image"), 0, 4), 1 << 20, codes.InvalidArgument},
+	}
+
+	r := NewRegistry()
+	if err := r.Register(cpuBackend{}, 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &server{backends: r, policy: PreferGPU, maxUploadBytes: tt.limit}
+			stream := &fakeUploadStream{chunks: tt.chunks}
+			err := s.ResizeImageStream(stream)
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("got %v, want code %v", err, tt.code)
+			}
+			// The upload stops at the 10 byte chunk that passes the limit
+			if tt.code == codes.ResourceExhausted && stream.received != tt.limit/10+2 {
+				t.Errorf("read %d of %d chunks", stream.received, len(stream.chunks))
+			}
+			if tt.code != codes.OK {
+				return
+			}
+
+			if stream.resp.Width != 20 || stream.resp.Height != 15 {
+				t.Errorf("resized to %dx%d, want 20x15", stream.resp.Width, stream.resp.Height)
+			}
+			got, err := png.Decode(bytes.NewReader(stream.resp.ResizedImage))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := resampleNRGBA(gradientImage(40, 30), 20, 15, FilterLanczos3, false); diffImages(want, toNRGBA(got), 0) != nil {
+				t.Error("the streamed image differs from the uploaded one resized")
+			}
+		})
+	}
+}
+
+// fakeDownloadStream collects what ResizeImageDownload sends
+type fakeDownloadStream struct {
+	grpc.ServerStream
+	chunks []*pb.ResizeImageDownloadChunk
+}
+
+func (s *fakeDownloadStream) Context() context.Context { return context.Background() }
+
+func (s *fakeDownloadStream) Send(chunk *pb.ResizeImageDownloadChunk) error {
+	s.chunks = append(s.chunks, chunk)
+	return nil
+}
+
+func TestResizeImageDownload(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		chunks []int // Sizes of the data chunks
+	}{
+		{"empty", 0, nil},
+		{"one byte", 1, []int{1}},
+		{"one chunk", downloadChunkSize, []int{downloadChunkSize}},
+		{"partial last chunk", 2*downloadChunkSize + 5, []int{downloadChunkSize, downloadChunkSize, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The fake backend echoes the request bytes as the result
+			image := make([]byte, tt.size)
+			for i := range image {
+				image[i] = byte(i * 31)
+			}
+			r := NewRegistry()
+			if err := r.Register(&fakeBackend{name: "cpu"}, 0); err != nil {
+				t.Fatal(err)
+			}
+			s := &server{backends: r, policy: PreferGPU}
+			stream := &fakeDownloadStream{}
+			if err := s.ResizeImageDownload(&pb.ResizeImageRequest{ImageData: image, Width: 1, Height: 1}, stream); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(stream.chunks) != len(tt.chunks)+2 {
+				t.Fatalf("sent %d messages, want metadata, %d data chunks and a checksum", len(stream.chunks), len(tt.chunks))
+			}
+			metadata := stream.chunks[0].GetMetadata()
+			if metadata == nil || metadata.ResizedImage != nil {
+				t.Errorf("first message is %v, want metadata without the image", stream.chunks[0])
+			}
+			var got []byte
+			for i, want := range tt.chunks {
+				data := stream.chunks[i+1].GetData()
+				if len(data) != want {
+					t.Errorf("chunk %d has %d bytes, want %d", i, len(data), want)
+				}
+				got = append(got, data...)
+			}
+			if !bytes.Equal(got, image) {
+				t.Error("the chunks do not add up to the image")
+			}
+			checksum := stream.chunks[len(stream.chunks)-1].GetChecksum()
+			if sum := sha256.Sum256(image); checksum == nil || checksum.Size != uint64(tt.size) || !bytes.Equal(checksum.Sha256, sum[:]) {
+				t.Errorf("checksum is %v, want %d bytes with SHA-256 %x", checksum, tt.size, sum)
+			}
+		})
+	}
+}
+
+// fakeBatchStream feeds items to ResizeBatch and collects its results
+type fakeBatchStream struct {
+	grpc.ServerStream
+	items []*pb.BatchItem
+
+	mu      sync.Mutex
+	results []*pb.BatchResult
+	sendErr error // Returned by every Send when set
+}
+
+func (s *fakeBatchStream) Context() context.Context { return context.Background() }
+
+func (s *fakeBatchStream) Recv() (*pb.BatchItem, error) {
+	if len(s.items) == 0 {
+		return nil, io.EOF
+	}
+	item := s.items[0]
+	s.items = s.items[1:]
+	return item, nil
+}
+
+func (s *fakeBatchStream) Send(result *pb.BatchResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sendErr != nil {
+		return s.sendErr
+	}
+	s.results = append(s.results, result)
+	return nil
+}
+
+func batchItems(n int) []*pb.BatchItem {
+	items := make([]*pb.BatchItem, n)
+	for i := range items {
+		items[i] = &pb.BatchItem{Id: fmt.Sprint(i), Request: &pb.ResizeImageRequest{ImageData: []byte{byte(i)}, Width: 1, Height: 1}}
+	}
+	return items
+}
+
+// fakeBatchBackend is a fakeBackend that also takes jobs in batches
+type fakeBatchBackend struct {
+	fakeBackend
+	batches atomic.Int64
+}
+
+func (f *fakeBatchBackend) ProcessBatch(ctx context.Context, jobs []*Job) ([]*Result, []error) {
+	f.batches.Add(1)
+	time.Sleep(20 * time.Millisecond)
+	results := make([]*Result, len(jobs))
+	for i, job := range jobs {
+		results[i], _ = f.Process(ctx, job)
+	}
+	return results, make([]error, len(jobs))
+}
+
+// TestResizeBatchSpreadsUnbatchedItems checks that items for a backend
+// that cannot batch are spread over the workers instead of queueing
+// behind one of them
+func TestResizeBatchSpreadsUnbatchedItems(t *testing.T) {
+	var running, peak atomic.Int64
+	cpu := &fakeBackend{name: "cpu"}
+	cpu.process = func(ctx context.Context, job *Job) (*Result, error) {
+		n := running.Add(1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		return &Result{Output: Output{Image: job.ImageData}}, nil
+	}
+	r := NewRegistry()
+	if err := r.Register(cpu, 0); err != nil {
+		t.Fatal(err)
+	}
+	s := &server{backends: r, policy: PreferGPU, batchWorkers: 4}
+
+	stream := &fakeBatchStream{items: batchItems(16)}
+	if err := s.ResizeBatch(stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.results) != 16 {
+		t.Fatalf("got %d results, want 16", len(stream.results))
+	}
+	if got := peak.Load(); got != 4 {
+		t.Errorf("at most %d items ran at once, want 4", got)
+	}
+}
+
+// TestResizeBatchGroupsBatchedItems checks that a backend that can batch
+// gets the queued items together
+func TestResizeBatchGroupsBatchedItems(t *testing.T) {
+	gpu := &fakeBatchBackend{fakeBackend: fakeBackend{name: "gpu", caps: Capabilities{GPU: true}}}
+	r := NewRegistry()
+	if err := r.Register(gpu, 0); err != nil {
+		t.Fatal(err)
+	}
+	s := &server{backends: r, policy: PreferGPU, batchWorkers: 1}
+
+	stream := &fakeBatchStream{items: batchItems(10)}
+	if err := s.ResizeBatch(stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.results) != 10 || gpu.Calls() != 10 {
+		t.Fatalf("got %d results from %d jobs, want 10", len(stream.results), gpu.Calls())
+	}
+	if got := gpu.batches.Load(); got >= 10 {
+		t.Errorf("10 queued items took %d batches", got)
+	}
+}
+
+// TestResizeBatchStopsAfterSendFails checks that the items still queued
+// when a result cannot be sent are dropped rather than processed
+func TestResizeBatchStopsAfterSendFails(t *testing.T) {
+	cpu := &fakeBackend{name: "cpu"}
+	r := NewRegistry()
+	if err := r.Register(cpu, 0); err != nil {
+		t.Fatal(err)
+	}
+	s := &server{backends: r, policy: PreferGPU, batchWorkers: 1}
+
+	broken := errors.New("stream broken")
+	stream := &fakeBatchStream{items: batchItems(20), sendErr: broken}
+	if err := s.ResizeBatch(stream); !errors.Is(err, broken) {
+		t.Fatalf("ResizeBatch: %v, want %v", err, broken)
+	}
+	if got := cpu.Calls(); got != 1 {
+		t.Errorf("processed %d items after the first send failed", got-1)
+	}
+}
+
+func TestResizeImageVariants(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, gradientImage(40, 30)); err != nil {
+		t.Fatal(err)
+	}
+	variant := func(name string, width uint32) *pb.OutputVariant {
+		return &pb.OutputVariant{Name: name, Width: width, OutputFormat: pb.OutputFormat_OUTPUT_FORMAT_PNG}
+	}
+	tooMany := make([]*pb.OutputVariant, maxVariants+1)
+	for i := range tooMany {
+		tooMany[i] = variant(fmt.Sprint(i), 10)
+	}
+
+	tests := []struct {
+		name     string
+		variants []*pb.OutputVariant
+		code     codes.Code
+	}{
+		{"none", nil, codes.OK},
+		{"in request order", []*pb.OutputVariant{variant("small", 10), variant("large", 30), variant("medium", 20)}, codes.OK},
+		{"at the limit", tooMany[:maxVariants], codes.OK},
+		{"too many", tooMany, codes.InvalidArgument},
+		{"invalid variant", []*pb.OutputVariant{variant("ok", 10), {Name: "bad", Fit: pb.Fit(99)}}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			if err := r.Register(cpuBackend{}, 0); err != nil {
+				t.Fatal(err)
+			}
+			s := &server{backends: r, policy: PreferGPU}
+			resp, err := s.ResizeImage(context.Background(), &pb.ResizeImageRequest{ImageData: buf.Bytes(), Width: 20, Variants: tt.variants})
+			if status.Code(err) != tt.code {
+				t.Fatalf("got %v, want %v", err, tt.code)
+			}
+			if err != nil {
+				return
+			}
+			if len(tt.variants) == 0 {
+				if len(resp.ResizedImage) == 0 || resp.Width != 20 || len(resp.Variants) != 0 {
+					t.Errorf("got a %dx%d image and %d variants, want only the request's output", resp.Width, resp.Height, len(resp.Variants))
+				}
+				return
+			}
+			if len(resp.ResizedImage) != 0 {
+				t.Error("the request's own output was returned alongside its variants")
+			}
+			if len(resp.Variants) != len(tt.variants) {
+				t.Fatalf("got %d variants, want %d", len(resp.Variants), len(tt.variants))
+			}
+			for i, v := range resp.Variants {
+				if v.Name != tt.variants[i].Name || v.Width != tt.variants[i].Width || len(v.Image) == 0 {
+					t.Errorf("variant %d is %q at width %d, want %q at width %d", i, v.Name, v.Width, tt.variants[i].Name, tt.variants[i].Width)
+				}
+				if v.OutputFormat != pb.OutputFormat_OUTPUT_FORMAT_PNG {
+					t.Errorf("variant %q is %v, want PNG", v.Name, v.OutputFormat)
+				}
+			}
+		})
+	}
+}
+
+func TestPipelineValidation(t *testing.T) {
+	resize := func(width uint32) *pb.Operation {
+		return &pb.Operation{Op: &pb.Operation_Resize{Resize: &pb.ResizeOperation{Width: width}}}
+	}
+	crop := func(c *pb.CropOperation) *pb.Operation { return &pb.Operation{Op: &pb.Operation_Crop{Crop: c}} }
+	pipeline := func(ops ...*pb.Operation) *pb.Pipeline { return &pb.Pipeline{Operations: ops} }
+	tooMany := make([]*pb.Operation, maxOperations+1)
+	for i := range tooMany {
+		tooMany[i] = resize(10)
+	}
+
+	tests := []struct {
+		name    string
+		req     *pb.ResizeImageRequest
+		wantErr string // Empty when the request is valid
+	}{
+		{"resize", &pb.ResizeImageRequest{Pipeline: pipeline(resize(10), resize(5))}, ""},
+		{"at the limit", &pb.ResizeImageRequest{Pipeline: pipeline(tooMany[:maxOperations]...)}, ""},
+		{"in a variant", &pb.ResizeImageRequest{Variants: []*pb.OutputVariant{{Name: "small", Pipeline: pipeline(resize(10))}}}, ""},
+		{"too many operations", &pb.ResizeImageRequest{Pipeline: pipeline(tooMany...)}, "at most 32 operations"},
+		{"empty operation", &pb.ResizeImageRequest{Pipeline: pipeline(resize(10), &pb.Operation{})}, "operation 1: invalid request: operation is empty"},
+		{"unknown filter", &pb.ResizeImageRequest{Pipeline: pipeline(
+			&pb.Operation{Op: &pb.Operation_Resize{Resize: &pb.ResizeOperation{Filter: pb.Filter(99)}}},
+		)}, "operation 0: invalid request: unknown filter"},
+		{"unknown fit", &pb.ResizeImageRequest{Pipeline: pipeline(
+			&pb.Operation{Op: &pb.Operation_Resize{Resize: &pb.ResizeOperation{Fit: pb.Fit(99)}}},
+		)}, "operation 0: invalid request: unknown fit"},
+		{"crop rect", &pb.ResizeImageRequest{Pipeline: pipeline(crop(&pb.CropOperation{Region: &pb.CropOperation_Rect{Rect: &pb.CropRect{Width: 5, Height: 5}}}))}, ""},
+		{"crop percent", &pb.ResizeImageRequest{Pipeline: pipeline(crop(&pb.CropOperation{Region: &pb.CropOperation_Percent{Percent: &pb.CropPercent{X: 50, Width: 50, Height: 100}}}))}, ""},
+		{"crop gravity", &pb.ResizeImageRequest{Pipeline: pipeline(crop(&pb.CropOperation{Region: &pb.CropOperation_Size{Size: &pb.CropSize{Width: 5, Gravity: pb.Gravity_GRAVITY_SOUTH_WEST}}}))}, ""},
+		{"crop without region", &pb.ResizeImageRequest{Pipeline: pipeline(crop(&pb.CropOperation{}))}, "crop needs a rect, percent or size"},
+		{"empty crop rect", &pb.ResizeImageRequest{Pipeline: pipeline(crop(&pb.CropOperation{Region: &pb.CropOperation_Rect{Rect: &pb.CropRect{Width: 5}}}))}, "must not be empty"},
+		{"empty crop percent", &pb.ResizeImageRequest{Pipeline: pipeline(crop(&pb.CropOperation{Region: &pb.CropOperation_Percent{Percent: &pb.CropPercent{Width: 50}}}))}, "must not be empty"},
+		{"crop percent over 100", &pb.ResizeImageRequest{Pipeline: pipeline(crop(&pb.CropOperation{Region: &pb.CropOperation_Percent{Percent: &pb.CropPercent{Width: 101, Height: 50}}}))}, "must be 0-100"},
+		{"negative crop percent", &pb.ResizeImageRequest{Pipeline: pipeline(crop(&pb.CropOperation{Region: &pb.CropOperation_Percent{Percent: &pb.CropPercent{X: -1, Width: 50, Height: 50}}}))}, "must be 0-100"},
+		{"unknown gravity", &pb.ResizeImageRequest{Pipeline: pipeline(crop(&pb.CropOperation{Region: &pb.CropOperation_Size{Size: &pb.CropSize{Gravity: pb.Gravity(99)}}}))}, "unknown gravity"},
+		{"with width", &pb.ResizeImageRequest{Width: 10, Pipeline: pipeline(resize(10))}, "cannot be combined with a pipeline"},
+		{"with fit", &pb.ResizeImageRequest{Fit: pb.Fit_FIT_COVER, Pipeline: pipeline(resize(10))}, "cannot be combined with a pipeline"},
+		{"with background", &pb.ResizeImageRequest{Background: &pb.Color{}, Pipeline: pipeline(resize(10))}, "cannot be combined with a pipeline"},
+		{"at the size limit", &pb.ResizeImageRequest{Width: 1 << 13, Height: maxOutputPixels >> 13}, ""},
+		{"over the size limit", &pb.ResizeImageRequest{Width: 1 << 13, Height: maxOutputPixels>>13 + 1}, "over the limit"},
+		{"one side over the size limit", &pb.ResizeImageRequest{Width: 1 << 31}, "over the limit"},
+		{"resize over the size limit", &pb.ResizeImageRequest{Pipeline: pipeline(
+			&pb.Operation{Op: &pb.Operation_Resize{Resize: &pb.ResizeOperation{Width: 1 << 20, Height: 1 << 20}}},
+		)}, "operation 0: invalid request: 1048576x1048576 is over the limit"},
+		{"variant over the size limit", &pb.ResizeImageRequest{Variants: []*pb.OutputVariant{{Name: "huge", Width: 1 << 20, Height: 1 << 20}}}, "over the limit"},
+		{"invalid in a variant", &pb.ResizeImageRequest{Variants: []*pb.OutputVariant{
+			{Name: "small", Height: 5, Pipeline: pipeline(resize(10))},
+		}}, "variant 0 (small)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jobFromRequest(tt.req, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !errors.Is(err, errInvalidRequest) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want an invalid request error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestOperationFromProtoMissingMessages checks that operations whose
+// message is missing, as built in-process, are converted or rejected
+// without a panic
+func TestOperationFromProtoMissingMessages(t *testing.T) {
+	ops := []*pb.Operation{
+		{Op: &pb.Operation_Resize{}},
+		{Op: &pb.Operation_Crop{}},
+		{Op: &pb.Operation_Rotate{}},
+		{Op: &pb.Operation_Flip{}},
+		{Op: &pb.Operation_Adjust{}},
+		{Op: &pb.Operation_Blur{}},
+		{Op: &pb.Operation_BoxBlur{}},
+		{Op: &pb.Operation_UnsharpMask{}},
+		{Op: &pb.Operation_Overlay{}},
+		{Op: &pb.Operation_Text{}},
+		{},
+		nil,
+	}
+	for _, op := range ops {
+		if _, err := operationFromProto(op, nil); err != nil && !errors.Is(err, errInvalidRequest) {
+			t.Errorf("%T: %v is not an invalid request", op.GetOp(), err)
+		}
+	}
+	if _, err := operationFromProto(&pb.Operation{Op: &pb.Operation_Overlay{}}, nil); !errors.Is(err, errInvalidRequest) {
+		t.Errorf("overlay without a message: %v, want an invalid request", err)
+	}
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// simParam is the type of a simulated kernel parameter
+type simParam int
+
+const (
+	ptrParam simParam = iota
+	intParam
+	floatParam
+)
+
+func (p simParam) String() string {
+	switch p {
+	case ptrParam:
+		return "pointer"
+	case intParam:
+		return "int"
+	}
+	return "float"
+}
+
+// simThread is the position of one simulated CUDA thread
+type simThread struct {
+	BlockIdx, ThreadIdx, BlockDim, GridDim Dim3
+}
+
+// X returns blockIdx.x * blockDim.x + threadIdx.x
+func (t simThread) X() int { return t.BlockIdx.X*t.BlockDim.X + t.ThreadIdx.X }
+
+// Y returns blockIdx.y * blockDim.y + threadIdx.y
+func (t simThread) Y() int { return t.BlockIdx.Y*t.BlockDim.Y + t.ThreadIdx.Y }
+
+// simArgs holds resolved kernel arguments; pointers become host slices
+type simArgs []any
+
+func (a simArgs) buf(i int) []byte { return a[i].([]byte) }
+
+func (a simArgs) int(i int) int { return int(a[i].(int32)) }
+
+func (a simArgs) float(i int) float32 { return a[i].(float32) }
+
+// simKernel is a Go twin of a CUDA kernel, run once per thread
+type simKernel struct {
+	params []simParam
+	run    func(t simThread, args simArgs)
+}
+
+// simAllocation records a device allocation
+type simAllocation struct {
+	Ptr   DevicePtr
+	Bytes int64
+	Freed bool
+}
+
+// simCopy records a host/device transfer
+type simCopy struct {
+	HostToDevice bool
+	Ptr          DevicePtr
+	Bytes        int64
+}
+
+// simLaunch records a kernel launch
+type simLaunch struct {
+	Kernel         string
+	Grid, Block    Dim3
+	SharedMemBytes int
+	Args           []any
+}
+
+// simDriver implements Driver on host memory. Kernels run through their
+// Go twins. With record set, every allocation, copy and launch is kept so
+// tests can inspect what the GPU pipeline asked the device to do; it is
+// off by default, so a long-running simulated server keeps no history.
+type simDriver struct {
+	mu          sync.Mutex
+	devices     []string
+	kernels     map[string]simKernel
+	initialized bool
+
+	memory    map[DevicePtr][]byte
+	nextPtr   DevicePtr
+	contexts  map[Context]int
+	nextCtx   Context
+	modules   map[Module][]byte
+	functions []string
+	launches  int
+
+	record          bool
+	Allocations     []simAllocation
+	Copies          []simCopy
+	Launches        []simLaunch
+	Inits           int
+	CtxCreates      int
+	ModuleLoads     int
+	FunctionLookups int
+}
+
+// simStats is a snapshot of how often the expensive setup calls were made
+type simStats struct {
+	Inits           int
+	CtxCreates      int
+	ModuleLoads     int
+	FunctionLookups int
+	Launches        int
+}
+
+// newSimDriver returns a simulated driver exposing the named devices
+func newSimDriver(devices ...string) *simDriver {
+	return &simDriver{
+		devices:  devices,
+		kernels:  simKernels(),
+		memory:   make(map[DevicePtr][]byte),
+		nextPtr:  0x10000,
+		contexts: make(map[Context]int),
+		modules:  make(map[Module][]byte),
+	}
+}
+
+var (
+	errSimNotInitialized = errors.New("simulated device: not initialized")
+	errSimNoContext      = errors.New("simulated device: no active context")
+)
+
+func (d *simDriver) ready() error {
+	if !d.initialized {
+		return errSimNotInitialized
+	}
+	if len(d.contexts) == 0 {
+		return errSimNoContext
+	}
+	return nil
+}
+
+func (d *simDriver) Init() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.initialized = true
+	d.Inits++
+	return nil
+}
+
+func (d *simDriver) DeviceCount() (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.initialized {
+		return 0, errSimNotInitialized
+	}
+	return len(d.devices), nil
+}
+
+func (d *simDriver) DeviceName(dev int) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if dev < 0 || dev >= len(d.devices) {
+		return "", fmt.Errorf("simulated device: invalid device %d", dev)
+	}
+	return d.devices[dev], nil
+}
+
+func (d *simDriver) CtxCreate(dev int) (Context, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.initialized {
+		return 0, errSimNotInitialized
+	}
+	if dev < 0 || dev >= len(d.devices) {
+		return 0, fmt.Errorf("simulated device: invalid device %d", dev)
+	}
+	d.nextCtx++
+	d.contexts[d.nextCtx] = dev
+	d.CtxCreates++
+	return d.nextCtx, nil
+}
+
+func (d *simDriver) CtxSetCurrent(ctx Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.contexts[ctx]; !ok {
+		return fmt.Errorf("simulated device: invalid context %d", ctx)
+	}
+	return nil
+}
+
+func (d *simDriver) CtxDestroy(ctx Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.contexts[ctx]; !ok {
+		return fmt.Errorf("simulated device: invalid context %d", ctx)
+	}
+	delete(d.contexts, ctx)
+	return nil
+}
+
+func (d *simDriver) MemAlloc(bytes int64) (DevicePtr, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.ready(); err != nil {
+		return 0, err
+	}
+	if bytes <= 0 {
+		return 0, fmt.Errorf("simulated device: invalid allocation size %d", bytes)
+	}
+	ptr := d.nextPtr
+	d.memory[ptr] = make([]byte, bytes)
+	// Keep allocations 256-byte aligned with a guard gap between them
+	d.nextPtr += DevicePtr((bytes+255)/256*256 + 256)
+	if d.record {
+		d.Allocations = append(d.Allocations, simAllocation{Ptr: ptr, Bytes: bytes})
+	}
+	return ptr, nil
+}
+
+func (d *simDriver) MemFree(ptr DevicePtr) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.memory[ptr]; !ok {
+		return fmt.Errorf("simulated device: free of unknown pointer %#x", ptr)
+	}
+	delete(d.memory, ptr)
+	for i := range d.Allocations {
+		if d.Allocations[i].Ptr == ptr && !d.Allocations[i].Freed {
+			d.Allocations[i].Freed = true
+		}
+	}
+	return nil
+}
+
+// resolve returns the device memory from ptr to the end of its allocation
+func (d *simDriver) resolve(ptr DevicePtr) ([]byte, error) {
+	for base, mem := range d.memory {
+		if ptr >= base && ptr < base+DevicePtr(len(mem)) {
+			return mem[ptr-base:], nil
+		}
+	}
+	return nil, fmt.Errorf("simulated device: invalid pointer %#x", ptr)
+}
+
+func (d *simDriver) MemcpyHtoD(dst DevicePtr, src []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	mem, err := d.resolve(dst)
+	if err != nil {
+		return err
+	}
+	if len(src) > len(mem) {
+		return fmt.Errorf("simulated device: copy of %d bytes overflows allocation of %d", len(src), len(mem))
+	}
+	copy(mem, src)
+	if d.record {
+		d.Copies = append(d.Copies, simCopy{HostToDevice: true, Ptr: dst, Bytes: int64(len(src))})
+	}
+	return nil
+}
+
+func (d *simDriver) MemcpyDtoH(dst []byte, src DevicePtr) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	mem, err := d.resolve(src)
+	if err != nil {
+		return err
+	}
+	if len(dst) > len(mem) {
+		return fmt.Errorf("simulated device: copy of %d bytes overflows allocation of %d", len(dst), len(mem))
+	}
+	copy(dst, mem)
+	if d.record {
+		d.Copies = append(d.Copies, simCopy{Ptr: src, Bytes: int64(len(dst))})
+	}
+	return nil
+}
+
+func (d *simDriver) ModuleLoadData(image []byte) (Module, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.ready(); err != nil {
+		return 0, err
+	}
+	if len(image) == 0 {
+		return 0, errors.New("simulated device: empty module image")
+	}
+	mod := Module(len(d.modules) + 1)
+	d.modules[mod] = image
+	d.ModuleLoads++
+	return mod, nil
+}
+
+func (d *simDriver) ModuleGetFunction(mod Module, name string) (Function, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	image, ok := d.modules[mod]
+	if !ok {
+		return 0, fmt.Errorf("simulated device: invalid module %d", mod)
+	}
+	if _, ok := d.kernels[name]; !ok || !strings.Contains(string(image), ".entry "+name+"(") {
+		return 0, fmt.Errorf("simulated device: kernel %q not found", name)
+	}
+	d.functions = append(d.functions, name)
+	d.FunctionLookups++
+	return Function(len(d.functions)), nil
+}
+
+func (d *simDriver) LaunchKernel(fn Function, grid, block Dim3, sharedMemBytes int, args ...any) (err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.ready(); err != nil {
+		return err
+	}
+	if fn < 1 || int(fn) > len(d.functions) {
+		return fmt.Errorf("simulated device: invalid function %d", fn)
+	}
+	name := d.functions[fn-1]
+	kernel := d.kernels[name]
+	d.launches++
+	if d.record {
+		d.Launches = append(d.Launches, simLaunch{
+			Kernel:         name,
+			Grid:           grid,
+			Block:          block,
+			SharedMemBytes: sharedMemBytes,
+			Args:           append([]any(nil), args...),
+		})
+	}
+
+	if err := checkKernelArgs(args); err != nil {
+		return err
+	}
+	if len(args) != len(kernel.params) {
+		return fmt.Errorf("simulated device: %s takes %d arguments, got %d", name, len(kernel.params), len(args))
+	}
+	resolved := make(simArgs, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case DevicePtr:
+			if kernel.params[i] != ptrParam {
+				return fmt.Errorf("simulated device: %s argument %d must be a %s", name, i, kernel.params[i])
+			}
+			mem, err := d.resolve(v)
+			if err != nil {
+				return fmt.Errorf("simulated device: %s argument %d: %w", name, i, err)
+			}
+			resolved[i] = mem
+		case int32:
+			if kernel.params[i] != intParam {
+				return fmt.Errorf("simulated device: %s argument %d must be a %s", name, i, kernel.params[i])
+			}
+			resolved[i] = v
+		case float32:
+			if kernel.params[i] != floatParam {
+				return fmt.Errorf("simulated device: %s argument %d must be a %s", name, i, kernel.params[i])
+			}
+			resolved[i] = v
+		}
+	}
+
+	// An out-of-bounds access in a kernel surfaces as an error, like
+	// CUDA_ERROR_ILLEGAL_ADDRESS would on a real device
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("simulated device: %s: illegal address: %v", name, r)
+		}
+	}()
+	t := simThread{BlockDim: block, GridDim: grid}
+	for t.BlockIdx.Z = 0; t.BlockIdx.Z < grid.Z; t.BlockIdx.Z++ {
+		for t.BlockIdx.Y = 0; t.BlockIdx.Y < grid.Y; t.BlockIdx.Y++ {
+			for t.BlockIdx.X = 0; t.BlockIdx.X < grid.X; t.BlockIdx.X++ {
+				for t.ThreadIdx.Z = 0; t.ThreadIdx.Z < block.Z; t.ThreadIdx.Z++ {
+					for t.ThreadIdx.Y = 0; t.ThreadIdx.Y < block.Y; t.ThreadIdx.Y++ {
+						for t.ThreadIdx.X = 0; t.ThreadIdx.X < block.X; t.ThreadIdx.X++ {
+							kernel.run(t, resolved)
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (d *simDriver) Synchronize() error {
+	return nil
+}
+
+// Stats returns a consistent snapshot of the setup counters; safe to call
+// while requests are running
+func (d *simDriver) Stats() simStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return simStats{
+		Inits:           d.Inits,
+		CtxCreates:      d.CtxCreates,
+		ModuleLoads:     d.ModuleLoads,
+		FunctionLookups: d.FunctionLookups,
+		Launches:        d.launches,
+	}
+}
+
+// LiveAllocations returns the sizes of allocations that were never freed
+func (d *simDriver) LiveAllocations() []int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var live []int64
+	for _, mem := range d.memory {
+		live = append(live, int64(len(mem)))
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i] < live[j] })
+	return live
+}
+
+// simKernelSource generates stand-in PTX for the simulator, declaring the
+// entry points and version markers the kernel registry checks for
+func simKernelSource(specs []kernelSpec) kernelSource {
+	return func(module string) ([]byte, error) {
+		var ptx strings.Builder
+		fmt.Fprintf(&ptx, "// Simulated module %s\n.version 8.0\n.target sm_52\n.address_size 64\n", module)
+		found := false
+		for _, spec := range specs {
+			if spec.Module != module {
+				continue
+			}
+			found = true
+			fmt.Fprintf(&ptx, ".visible .global .align 4 .u32 %s_version = %d;\n", spec.Name, spec.Version)
+			fmt.Fprintf(&ptx, ".visible .entry %s(\n)\n{\n\tret;\n}\n", spec.Name)
+		}
+		if !found {
+			return nil, fs.ErrNotExist
+		}
+		return []byte(ptx.String()), nil
+	}
+}
+
+// newSimCUDABackend returns a CUDA backend running on simulated devices
+func newSimCUDABackend(name string, driver *simDriver, maxPerDevice int) *cudaBackend {
+	kernels := newKernelRegistry(simKernelSource(kernelSpecs), "simulated kernels", kernelSpecs...)
+	return newCUDABackend(name, driver, kernels, maxPerDevice)
+}
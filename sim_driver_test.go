@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// encodeTestJPEG returns a w x h JPEG image
+func encodeTestJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// openSimDevice initializes d and opens its first device with the
+// simulator's kernels
+func openSimDevice(t *testing.T, d *simDriver) *cudaDevice {
+	t.Helper()
+	if err := d.Init(); err != nil {
+		t.Fatal(err)
+	}
+	kernels := newKernelRegistry(simKernelSource(kernelSpecs), "simulated kernels", kernelSpecs...)
+	dev, err := openCUDADevice(d, 0, kernels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dev
+}
+
+func TestLaunchGrid(t *testing.T) {
+	tests := []struct {
+		width, height, count int
+		grid                 Dim3
+	}{
+		{1, 1, 1, Dim3{1, 1, 1}},
+		{16, 16, 1, Dim3{1, 1, 1}},
+		{17, 16, 1, Dim3{2, 1, 1}},
+		{16, 33, 3, Dim3{1, 3, 3}},
+		{640, 480, 2, Dim3{40, 30, 2}},
+	}
+	for _, tt := range tests {
+		grid, block := launchGrid(tt.width, tt.height, tt.count)
+		if block != (Dim3{16, 16, 1}) {
+			t.Errorf("launchGrid(%d, %d, %d) block = %v, want 16x16x1", tt.width, tt.height, tt.count, block)
+		}
+		if grid != tt.grid {
+			t.Errorf("launchGrid(%d, %d, %d) grid = %v, want %v", tt.width, tt.height, tt.count, grid, tt.grid)
+		}
+	}
+}
+
+// TestSimKernelSignatures checks that every Go twin takes the parameters
+// of its CUDA kernel, in the same order
+func TestSimKernelSignatures(t *testing.T) {
+	files, err := filepath.Glob("cuda/*.cu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := regexp.MustCompile(`__global__\s+void\s+(\w+)\s*\(([^)]*)\)`)
+	found := make(map[string]bool)
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range signature.FindAllStringSubmatch(string(src), -1) {
+			name := m[1]
+			found[name] = true
+			var params []simParam
+			for _, p := range strings.Split(m[2], ",") {
+				switch p = strings.TrimSpace(p); {
+				case strings.Contains(p, "*"):
+					params = append(params, ptrParam)
+				case strings.HasPrefix(p, "float"):
+					params = append(params, floatParam)
+				default:
+					params = append(params, intParam)
+				}
+			}
+			kernel, ok := simKernels()[name]
+			if !ok {
+				t.Errorf("%s: kernel %s has no simulated twin", file, name)
+				continue
+			}
+			if !slices.Equal(kernel.params, params) {
+				t.Errorf("%s: simulated %s takes %v, the kernel takes %v", file, name, kernel.params, params)
+			}
+		}
+	}
+	for name := range simKernels() {
+		if !found[name] {
+			t.Errorf("simulated kernel %s not found in cuda/*.cu", name)
+		}
+	}
+}
+
+// TestResizeLaunches checks the transfers and the grids and arguments of
+// the two passes of a resize of a stack of two images
+func TestResizeLaunches(t *testing.T) {
+	d := newSimDriver("Simulated GPU")
+	d.record = true
+	src := []*image.NRGBA{image.NewNRGBA(image.Rect(0, 0, 40, 30)), image.NewNRGBA(image.Rect(0, 0, 40, 30))}
+	if _, _, err := runBatchGPU(context.Background(), openSimDevice(t, d), src, [][]step{{resampleStep{Width: 20, Height: 10, Filter: FilterLanczos3}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The sources, the float horizontal passes and the results, each in
+	// one allocation for the stack
+	var sizes []int64
+	for _, a := range d.Allocations {
+		sizes = append(sizes, a.Bytes)
+	}
+	if want := []int64{2 * 40 * 30 * 4, 2 * 20 * 30 * 16, 2 * 20 * 10 * 4}; !slices.Equal(sizes, want) {
+		t.Fatalf("allocations = %v, want %v", sizes, want)
+	}
+	in, temp, out := d.Allocations[0].Ptr, d.Allocations[1].Ptr, d.Allocations[2].Ptr
+	want := []simCopy{{HostToDevice: true, Ptr: in, Bytes: 2 * 40 * 30 * 4}, {Ptr: out, Bytes: 2 * 20 * 10 * 4}}
+	if !slices.Equal(d.Copies, want) {
+		t.Errorf("copies = %+v, want %+v", d.Copies, want)
+	}
+
+	launches := []struct {
+		kernel string
+		grid   Dim3
+		args   []any
+	}{
+		{"resampleHorizontal", Dim3{2, 2, 2}, []any{in, int32(40), int32(30), temp, int32(20), int32(FilterLanczos3), int32(0)}},
+		{"resampleVertical", Dim3{2, 1, 2}, []any{temp, int32(20), int32(30), out, int32(10), int32(FilterLanczos3), int32(0)}},
+	}
+	if len(d.Launches) != len(launches) {
+		t.Fatalf("got %d launches, want %d", len(d.Launches), len(launches))
+	}
+	for i, l := range launches {
+		got := d.Launches[i]
+		if got.Kernel != l.kernel || got.Grid != l.grid || got.Block != (Dim3{16, 16, 1}) {
+			t.Errorf("launch %d = %s on %v x %v, want %s on %v x 16x16x1", i, got.Kernel, got.Grid, got.Block, l.kernel, l.grid)
+		}
+		if !slices.Equal(got.Args, l.args) {
+			t.Errorf("launch %d arguments = %v, want %v", i, got.Args, l.args)
+		}
+	}
+}
+
+// TestSimDriverRecordsOnlyWhenAsked checks that a simulated server keeps
+// no history while the counters still move
+func TestSimDriverRecordsOnlyWhenAsked(t *testing.T) {
+	d := newSimDriver("Simulated GPU")
+	dev := openSimDevice(t, d)
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for range 3 {
+		if _, _, err := runBatchGPU(context.Background(), dev, []*image.NRGBA{src}, [][]step{{resampleStep{Width: 4, Height: 4, Filter: FilterBilinear}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d.Allocations != nil || d.Copies != nil || d.Launches != nil {
+		t.Errorf("recorded %d allocations, %d copies and %d launches without record set", len(d.Allocations), len(d.Copies), len(d.Launches))
+	}
+	if got := d.Stats().Launches; got != 6 {
+		t.Errorf("counted %d launches, want 6", got)
+	}
+	if live := d.LiveAllocations(); len(live) != 0 {
+		t.Errorf("allocations left after the runs: %v", live)
+	}
+}
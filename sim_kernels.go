@@ -0,0 +1,209 @@
+package main
+
+import "unsafe"
+
+// simKernels returns the Go twins of the kernels in cuda/*.cu
+func simKernels() map[string]simKernel {
+	return map[string]simKernel{
+		"resampleHorizontal": {
+			params: []simParam{ptrParam, intParam, intParam, ptrParam, intParam, intParam, intParam},
+			run:    simResampleHorizontal,
+		},
+		"resampleVertical": {
+			params: []simParam{ptrParam, intParam, intParam, ptrParam, intParam, intParam, intParam},
+			run:    simResampleVertical,
+		},
+		"placeKernel": {
+			params: []simParam{ptrParam, intParam, intParam, ptrParam, intParam, intParam, intParam, intParam, intParam},
+			run:    simPlaceKernel,
+		},
+		"orientKernel": {
+			params: []simParam{ptrParam, intParam, intParam, ptrParam, intParam, intParam, intParam},
+			run:    simOrientKernel,
+		},
+		"rotateKernel": {
+			params: []simParam{ptrParam, intParam, intParam, ptrParam, intParam, intParam, floatParam, floatParam, intParam},
+			run:    simRotateKernel,
+		},
+		"adjustKernel": {
+			params: []simParam{
+				ptrParam, ptrParam, intParam, intParam,
+				floatParam, floatParam, floatParam,
+				floatParam, floatParam, floatParam, floatParam, floatParam, floatParam, floatParam, floatParam, floatParam,
+			},
+			run: simAdjustKernel,
+		},
+		"convolveHorizontal": {
+			params: []simParam{ptrParam, ptrParam, intParam, intParam, ptrParam, intParam},
+			run:    simConvolveHorizontal,
+		},
+		"convolveVertical": {
+			params: []simParam{ptrParam, ptrParam, intParam, intParam, ptrParam, intParam},
+			run:    simConvolveVertical,
+		},
+		"unsharpKernel": {
+			params: []simParam{ptrParam, ptrParam, ptrParam, intParam, intParam, floatParam, intParam},
+			run:    simUnsharpKernel,
+		},
+		"overlayKernel": {
+			params: []simParam{
+				ptrParam, ptrParam, intParam, intParam,
+				ptrParam, intParam, intParam,
+				intParam, intParam, intParam, floatParam, intParam,
+			},
+			run: simOverlayKernel,
+		},
+	}
+}
+
+// floats views device memory as float32 values
+func (a simArgs) floats(i int) []float32 {
+	b := a.buf(i)
+	return unsafe.Slice((*float32)(unsafe.Pointer(unsafe.SliceData(b))), len(b)/4)
+}
+
+// simResampleHorizontal mirrors resampleHorizontal
+func simResampleHorizontal(t simThread, a simArgs) {
+	input, inWidth, inHeight := a.buf(0), a.int(1), a.int(2)
+	temp, outWidth, filter, linear := a.floats(3), a.int(4), Filter(a.int(5)), a.int(6) != 0
+
+	x, y, z := t.X(), t.Y(), t.BlockIdx.Z
+	if x >= outWidth || y >= inHeight {
+		return
+	}
+	input = input[z*inWidth*inHeight*4:]
+	temp = temp[z*outWidth*inHeight*4:]
+	resampleHorizontalPixel(input, inWidth, temp, outWidth, filter, linear, x, y)
+}
+
+// simResampleVertical mirrors resampleVertical
+func simResampleVertical(t simThread, a simArgs) {
+	temp, width, inHeight := a.floats(0), a.int(1), a.int(2)
+	output, outHeight, filter, linear := a.buf(3), a.int(4), Filter(a.int(5)), a.int(6) != 0
+
+	x, y, z := t.X(), t.Y(), t.BlockIdx.Z
+	if x >= width || y >= outHeight {
+		return
+	}
+	temp = temp[z*width*inHeight*4:]
+	output = output[z*width*outHeight*4:]
+	resampleVerticalPixel(temp, width, inHeight, output, outHeight, filter, linear, x, y)
+}
+
+// simPlaceKernel mirrors placeKernel
+func simPlaceKernel(t simThread, a simArgs) {
+	src, srcWidth, srcHeight := a.buf(0), a.int(1), a.int(2)
+	dst, dstWidth, dstHeight := a.buf(3), a.int(4), a.int(5)
+	dx, dy, background := a.int(6), a.int(7), uint32(a.int(8))
+
+	x, y, z := t.X(), t.Y(), t.BlockIdx.Z
+	if x >= dstWidth || y >= dstHeight {
+		return
+	}
+	src = src[z*srcWidth*srcHeight*4:]
+	dst = dst[z*dstWidth*dstHeight*4:]
+	placePixel(src, srcWidth, srcHeight, dst, dstWidth, dx, dy, background, x, y)
+}
+
+// simOrientKernel mirrors orientKernel
+func simOrientKernel(t simThread, a simArgs) {
+	src, srcWidth, srcHeight := a.buf(0), a.int(1), a.int(2)
+	dst, dstWidth, dstHeight := a.buf(3), a.int(4), a.int(5)
+	orientation := Orientation(a.int(6))
+
+	x, y, z := t.X(), t.Y(), t.BlockIdx.Z
+	if x >= dstWidth || y >= dstHeight {
+		return
+	}
+	src = src[z*srcWidth*srcHeight*4:]
+	dst = dst[z*dstWidth*dstHeight*4:]
+	orientPixel(src, srcWidth, srcHeight, dst, dstWidth, orientation, x, y)
+}
+
+// simRotateKernel mirrors rotateKernel
+func simRotateKernel(t simThread, a simArgs) {
+	src, srcWidth, srcHeight := a.buf(0), a.int(1), a.int(2)
+	dst, dstWidth, dstHeight := a.buf(3), a.int(4), a.int(5)
+	cos, sin, background := a.float(6), a.float(7), uint32(a.int(8))
+
+	x, y, z := t.X(), t.Y(), t.BlockIdx.Z
+	if x >= dstWidth || y >= dstHeight {
+		return
+	}
+	src = src[z*srcWidth*srcHeight*4:]
+	dst = dst[z*dstWidth*dstHeight*4:]
+	rotatePixel(src, srcWidth, srcHeight, dst, dstWidth, dstHeight, cos, sin, background, x, y)
+}
+
+// simAdjustKernel mirrors adjustKernel
+func simAdjustKernel(t simThread, a simArgs) {
+	src, dst, width, height := a.buf(0), a.buf(1), a.int(2), a.int(3)
+	scale, offset, invGamma := a.float(4), a.float(5), a.float(6)
+	var m colorMatrix
+	for i := range m {
+		m[i] = a.float(7 + i)
+	}
+
+	x, y, z := t.X(), t.Y(), t.BlockIdx.Z
+	if x >= width || y >= height {
+		return
+	}
+	i := ((z*height+y)*width + x) * 4
+	adjustPixel(src[i:i+4], dst[i:i+4], scale, offset, invGamma, &m)
+}
+
+// simConvolveHorizontal mirrors convolveHorizontal
+func simConvolveHorizontal(t simThread, a simArgs) {
+	input, temp, width, height := a.buf(0), a.floats(1), a.int(2), a.int(3)
+	weights := a.floats(4)[:2*a.int(5)+1]
+
+	x, y, z := t.X(), t.Y(), t.BlockIdx.Z
+	if x >= width || y >= height {
+		return
+	}
+	input = input[z*width*height*4:]
+	temp = temp[z*width*height*4:]
+	convolveHorizontalPixel(input, temp, width, weights, x, y)
+}
+
+// simConvolveVertical mirrors convolveVertical
+func simConvolveVertical(t simThread, a simArgs) {
+	temp, output, width, height := a.floats(0), a.buf(1), a.int(2), a.int(3)
+	weights := a.floats(4)[:2*a.int(5)+1]
+
+	x, y, z := t.X(), t.Y(), t.BlockIdx.Z
+	if x >= width || y >= height {
+		return
+	}
+	temp = temp[z*width*height*4:]
+	output = output[z*width*height*4:]
+	convolveVerticalPixel(temp, output, width, height, weights, x, y)
+}
+
+// simUnsharpKernel mirrors unsharpKernel
+func simUnsharpKernel(t simThread, a simArgs) {
+	src, blurred, dst := a.buf(0), a.buf(1), a.buf(2)
+	width, height, amount, threshold := a.int(3), a.int(4), a.float(5), a.int(6)
+
+	x, y, z := t.X(), t.Y(), t.BlockIdx.Z
+	if x >= width || y >= height {
+		return
+	}
+	i := ((z*height+y)*width + x) * 4
+	unsharpPixel(src[i:i+4], blurred[i:i+4], dst[i:i+4], amount, threshold)
+}
+
+// simOverlayKernel mirrors overlayKernel
+func simOverlayKernel(t simThread, a simArgs) {
+	src, dst, width, height := a.buf(0), a.buf(1), a.int(2), a.int(3)
+	overlay, ovWidth, ovHeight := a.buf(4), a.int(5), a.int(6)
+	dx, dy, tile, opacity, blend := a.int(7), a.int(8), a.int(9) != 0, a.float(10), BlendMode(a.int(11))
+
+	x, y, z := t.X(), t.Y(), t.BlockIdx.Z
+	if x >= width || y >= height {
+		return
+	}
+	src = src[z*width*height*4:]
+	dst = dst[z*width*height*4:]
+	overlayPixel(src, dst, width, overlay, ovWidth, ovHeight, dx, dy, tile, opacity, blend, x, y)
+}
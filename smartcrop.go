@@ -0,0 +1,232 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"slices"
+)
+
+const (
+	smartCropAnalysisSize = 256 // Long side of the copy the windows are scored on
+	smartCropPositions    = 32  // Window positions tried along each axis
+	smartCropLumaBins     = 16  // Histogram bins for the entropy score
+
+	// Weights of the scores, each of which is 0-1
+	smartEdgeWeight    = 2    // Edge density
+	smartEntropyWeight = 1    // Luma entropy
+	smartSkinWeight    = 1    // Fraction of skin-coloured pixels
+	smartCentreWeight  = 0.05 // Distance from the centre, so ties go to the middle
+)
+
+// smartCropStep cuts the most interesting Width x Height window out of the
+// image. The window depends on the pixels, so it is chosen when the step
+// runs; the runners report it with the output through choose.
+type smartCropStep struct {
+	Width, Height int
+}
+
+func (st smartCropStep) outputSize(image.Point) image.Point { return image.Pt(st.Width, st.Height) }
+
+func (st smartCropStep) cpu(img *image.NRGBA) *image.NRGBA {
+	out, _ := st.choose(img)
+	return out
+}
+
+func (st smartCropStep) gpu(s *gpuSession, img deviceImage) (deviceImage, error) {
+	out, _, err := st.chooseGPU(s, img)
+	return out, err
+}
+
+// choose crops img to the window smartCropRect picks, and returns the
+// window too
+func (st smartCropStep) choose(img *image.NRGBA) (*image.NRGBA, image.Rectangle) {
+	r := smartCropRect(img, image.Pt(st.Width, st.Height))
+	return placeNRGBA(img, cropLayout(img.Bounds().Size(), r), color.NRGBA{}), r
+}
+
+// chooseGPU scores the windows of every image of the stack on the CPU,
+// which needs the stack downloaded first, and returns the windows in stack
+// order. A single window for the whole stack is cut on the device;
+// otherwise each image is cut on the CPU and the stack uploaded again.
+func (st smartCropStep) chooseGPU(s *gpuSession, img deviceImage) (deviceImage, []image.Rectangle, error) {
+	imgs, err := s.download(img)
+	if err != nil {
+		return deviceImage{}, nil, err
+	}
+	chosen := make([]image.Rectangle, len(imgs))
+	parallelRows(len(imgs), func(i int) {
+		chosen[i] = smartCropRect(imgs[i], image.Pt(st.Width, st.Height))
+	})
+	if !slices.ContainsFunc(chosen, func(r image.Rectangle) bool { return r != chosen[0] }) {
+		out, err := s.place(img, cropLayout(image.Pt(img.width, img.height), chosen[0]), color.NRGBA{})
+		return out, chosen, err
+	}
+	parallelRows(len(imgs), func(i int) {
+		imgs[i] = placeNRGBA(imgs[i], cropLayout(image.Pt(img.width, img.height), chosen[i]), color.NRGBA{})
+	})
+	out, err := s.upload(imgs...)
+	return out, chosen, err
+}
+
+// cropLayout returns the layout that cuts r out of an image of the given
+// size
+func cropLayout(size image.Point, r image.Rectangle) fitLayout {
+	return fitLayout{ScaledWidth: size.X, ScaledHeight: size.Y, Width: r.Dx(), Height: r.Dy(), Offset: r.Min}
+}
+
+// smartCropRect picks the window of the given size, no larger than img,
+// with the best weighted score for edge density, luma entropy and skin
+// tones. Windows are scored on a copy of img shrunk to
+// smartCropAnalysisSize, so the cost does not grow with the image.
+func smartCropRect(img *image.NRGBA, region image.Point) image.Rectangle {
+	size := img.Bounds().Size()
+	if region == size {
+		return image.Rectangle{Max: size}
+	}
+	small := img
+	if long := max(size.X, size.Y); long > smartCropAnalysisSize {
+		scale := float64(smartCropAnalysisSize) / float64(long)
+		width := max(1, int(math.Round(float64(size.X)*scale)))
+		height := max(1, int(math.Round(float64(size.Y)*scale)))
+		small = resampleNRGBA(img, width, height, FilterBox, false)
+	} else if img.Stride != size.X*4 || img.Rect.Min != (image.Point{}) {
+		small = cloneNRGBA(img)
+	}
+
+	sw, sh := small.Rect.Dx(), small.Rect.Dy()
+	scaleX, scaleY := float64(sw)/float64(size.X), float64(sh)/float64(size.Y)
+	window := image.Pt(
+		min(max(1, int(math.Round(float64(region.X)*scaleX))), sw),
+		min(max(1, int(math.Round(float64(region.Y)*scaleY))), sh),
+	)
+
+	m := newSaliencyMaps(small)
+	var best image.Point
+	bestScore := math.Inf(-1)
+	for _, y := range windowPositions(sh, window.Y) {
+		for _, x := range windowPositions(sw, window.X) {
+			r := image.Rectangle{Min: image.Pt(x, y), Max: image.Pt(x+window.X, y+window.Y)}
+			centre := math.Hypot(
+				(float64(x)+float64(window.X)/2)/float64(sw)-0.5,
+				(float64(y)+float64(window.Y)/2)/float64(sh)-0.5,
+			)
+			if score := m.score(r) - smartCentreWeight*centre; score > bestScore {
+				best, bestScore = r.Min, score
+			}
+		}
+	}
+
+	corner := image.Pt(
+		min(max(int(math.Round(float64(best.X)/scaleX)), 0), size.X-region.X),
+		min(max(int(math.Round(float64(best.Y)/scaleY)), 0), size.Y-region.Y),
+	)
+	return image.Rectangle{Min: corner, Max: corner.Add(region)}
+}
+
+// windowPositions returns the offsets tried for a window along an axis:
+// up to smartCropPositions evenly spaced ones and the far end
+func windowPositions(length, window int) []int {
+	step := max(1, (length-window)/smartCropPositions)
+	var positions []int
+	for p := 0; p < length-window; p += step {
+		positions = append(positions, p)
+	}
+	return append(positions, length-window)
+}
+
+// saliencyMaps holds summed-area tables of per-pixel features, so any
+// window is scored in constant time. Every feature is weighted by alpha,
+// so transparent areas score nothing.
+type saliencyMaps struct {
+	stride int     // Width of the tables, one more than the image's
+	edge   []int32 // Sobel magnitude of the luma, 0-255 per pixel
+	skin   []int32 // Alpha of skin-coloured pixels
+	luma   [smartCropLumaBins][]int32
+}
+
+func newSaliencyMaps(img *image.NRGBA) *saliencyMaps {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	luma := make([]int32, w*h)
+	alpha := make([]int32, w*h)
+	skin := make([]int32, w*h)
+	for i := range luma {
+		p := img.Pix[i*4 : i*4+4]
+		alpha[i] = int32(p[3])
+		luma[i] = (299*int32(p[0]) + 587*int32(p[1]) + 114*int32(p[2])) / 1000
+		if _, cb, cr := color.RGBToYCbCr(p[0], p[1], p[2]); luma[i] > 40 && cb >= 77 && cb <= 127 && cr >= 133 && cr <= 173 {
+			skin[i] = alpha[i]
+		}
+	}
+
+	// Edges of the luma premultiplied by alpha, so the outline of an
+	// opaque shape counts but the colour hidden in transparent pixels
+	// does not
+	at := func(x, y int) int32 {
+		i := min(max(y, 0), h-1)*w + min(max(x, 0), w-1)
+		return luma[i] * alpha[i] / 255
+	}
+	edge := make([]int32, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+			edge[y*w+x] = min(int32(math.Sqrt(float64(gx*gx+gy*gy))/4), 255)
+		}
+	}
+
+	m := &saliencyMaps{stride: w + 1, edge: summedArea(edge, w, h), skin: summedArea(skin, w, h)}
+	bin := make([]int32, w*h)
+	for b := range m.luma {
+		for i, a := range alpha {
+			bin[i] = 0
+			if int(luma[i])*smartCropLumaBins/256 == b {
+				bin[i] = a
+			}
+		}
+		m.luma[b] = summedArea(bin, w, h)
+	}
+	return m
+}
+
+// summedArea returns the summed-area table of a w x h grid, with a row and
+// column of zeros before the first
+func summedArea(values []int32, w, h int) []int32 {
+	sums := make([]int32, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := (y+1)*(w+1) + x + 1
+			sums[i] = values[y*w+x] + sums[i-1] + sums[i-w-1] - sums[i-w-2]
+		}
+	}
+	return sums
+}
+
+// sum adds up a table's values inside r
+func (m *saliencyMaps) sum(sums []int32, r image.Rectangle) float64 {
+	at := func(x, y int) int32 { return sums[y*m.stride+x] }
+	return float64(at(r.Max.X, r.Max.Y) - at(r.Min.X, r.Max.Y) - at(r.Max.X, r.Min.Y) + at(r.Min.X, r.Min.Y))
+}
+
+// score rates the window r by its edge density, the entropy of its luma
+// histogram and its share of skin tones
+func (m *saliencyMaps) score(r image.Rectangle) float64 {
+	full := float64(r.Dx()*r.Dy()) * 255
+	var counts [smartCropLumaBins]float64
+	var total float64
+	for b, sums := range m.luma {
+		counts[b] = m.sum(sums, r)
+		total += counts[b]
+	}
+	var entropy float64
+	for _, c := range counts {
+		if c > 0 {
+			p := c / total
+			entropy -= p * math.Log2(p)
+		}
+	}
+	entropy /= math.Log2(smartCropLumaBins)
+	return smartEdgeWeight*m.sum(m.edge, r)/full +
+		smartEntropyWeight*entropy +
+		smartSkinWeight*m.sum(m.skin, r)/full
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"slices"
+	"testing"
+)
+
+// detailImage returns a flat grey image of the given size with a detailed
+// square, holding every luma level, of side n at corner
+func detailImage(size image.Point, corner image.Point, n int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rectangle{Max: size})
+	draw.Draw(img, img.Rect, image.NewUniform(color.NRGBA{128, 128, 128, 255}), image.Point{}, draw.Src)
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			v := uint8((x*5+y*3)%16*16 + 8)
+			img.SetNRGBA(corner.X+x, corner.Y+y, color.NRGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+// flatImage returns an image of the given size in c, with the rectangles
+// of patches filled in their colours
+func flatImage(size image.Point, c color.NRGBA, patches map[image.Rectangle]color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rectangle{Max: size})
+	draw.Draw(img, img.Rect, image.NewUniform(c), image.Point{}, draw.Src)
+	for r, pc := range patches {
+		draw.Draw(img, r, image.NewUniform(pc), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+// TestSmartCropRect checks the windows picked for synthetic images
+func TestSmartCropRect(t *testing.T) {
+	grey := color.NRGBA{128, 128, 128, 255}
+	checker := flatImage(image.Pt(64, 32), grey, nil)
+	for y := 12; y < 20; y++ {
+		for x := 44; x < 52; x++ {
+			if (x+y)%2 == 0 {
+				checker.SetNRGBA(x, y, color.NRGBA{A: 255})
+			} else {
+				checker.SetNRGBA(x, y, color.NRGBA{255, 255, 255, 255})
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		img    *image.NRGBA
+		region image.Point
+		want   image.Rectangle
+	}{
+		{
+			// Of the windows holding the whole block, the one nearest the
+			// centre
+			name:   "edge block",
+			img:    checker,
+			region: image.Pt(16, 16),
+			want:   image.Rect(37, 8, 53, 24),
+		},
+		{
+			// A skin-coloured patch beats a patch of about the same luma,
+			// nearer the centre, that is not
+			name: "skin tone",
+			img: flatImage(image.Pt(64, 32), grey, map[image.Rectangle]color.NRGBA{
+				image.Rect(4, 10, 14, 20):  {224, 172, 140, 255},
+				image.Rect(48, 10, 58, 20): {166, 190, 170, 255},
+			}),
+			region: image.Pt(16, 16),
+			want:   image.Rect(3, 8, 19, 24),
+		},
+		{
+			name:   "1x1 image",
+			img:    flatImage(image.Pt(1, 1), grey, nil),
+			region: image.Pt(1, 1),
+			want:   image.Rect(0, 0, 1, 1),
+		},
+		{
+			// Nothing stands out, so the window is centred
+			name:   "1x1 window",
+			img:    flatImage(image.Pt(5, 5), grey, nil),
+			region: image.Pt(1, 1),
+			want:   image.Rect(2, 2, 3, 3),
+		},
+		{
+			name:   "whole image",
+			img:    checker,
+			region: image.Pt(64, 32),
+			want:   image.Rect(0, 0, 64, 32),
+		},
+		{
+			// Windows are scored on a copy shrunk to a quarter, at every
+			// seventh position, and mapped back
+			name:   "large image",
+			img:    detailImage(image.Pt(1024, 256), image.Pt(672, 64), 128),
+			region: image.Pt(128, 128),
+			want:   image.Rect(672, 64, 800, 192),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for range 2 {
+				if got := smartCropRect(tc.img, tc.region); got != tc.want {
+					t.Fatalf("picked %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+// TestSmartCropStacks checks that smart crops of jobs of the same size
+// plan the same steps, so they share a GPU stack, and that each image of
+// the stack gets and reports its own window
+func TestSmartCropStacks(t *testing.T) {
+	size := image.Pt(48, 16)
+	op := CropGravityOp{Width: 16, Height: 16, Gravity: GravitySmart}
+	a, _, err := planPipeline([]Operation{op}, size, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := planPipeline([]Operation{op}, size, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(a, b) {
+		t.Fatalf("smart crops planned %v and %v", a, b)
+	}
+
+	left, right := detailImage(size, image.Pt(2, 0), 16), detailImage(size, image.Pt(30, 0), 16)
+	for _, srcs := range [][]*image.NRGBA{{left, right}, {left, left}} {
+		dev := openSimDevice(t, newSimDriver("Simulated GPU"))
+		out, crops, err := runBatchGPU(context.Background(), dev, srcs, [][]step{a})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, src := range srcs {
+			want, wantCrops, _ := runStepsCPU(context.Background(), src, a)
+			if !slices.Equal(crops[0][i], wantCrops) {
+				t.Errorf("image %d: GPU chose %v, CPU %v", i, crops[0][i], wantCrops)
+			}
+			if err := diffImages(want, out[0][i], 0); err != nil {
+				t.Errorf("image %d: %v", i, err)
+			}
+		}
+	}
+	if _, crops, _ := runStepsCPU(context.Background(), right, a); !slices.Equal(crops, []image.Rectangle{image.Rect(30, 0, 46, 16)}) {
+		t.Errorf("chose %v for the detail at x=30", crops)
+	}
+}
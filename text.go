@@ -0,0 +1,434 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// TextAlign aligns the lines of a text block. The values match the proto
+// TextAlign enum.
+type TextAlign int32
+
+const (
+	TextAlignLeft TextAlign = iota
+	TextAlignCenter
+	TextAlignRight
+)
+
+// maxTextPixels caps the area of a text block, so a request cannot make
+// the server lay out and rasterize an unbounded amount of text
+const maxTextPixels = 1 << 24
+
+// TextOp draws text onto the image. The text is rendered into a layer on
+// the CPU when the pipeline runs, which is then composited like an overlay
+// by either backend.
+type TextOp struct {
+	Text        string // UTF-8; "\n" starts a new line
+	Font        *opentype.Font
+	Size        float64 // Em size in pixels
+	Color       color.NRGBA
+	Stroke      float64 // Outline width in pixels, 0 for none
+	StrokeColor color.NRGBA
+	Shadow      image.Point // Shadow offset
+	ShadowColor color.NRGBA // Transparent for no shadow
+	ShadowBlur  float64     // Standard deviation of the shadow blur in pixels
+	Align       TextAlign
+	Box         image.Rectangle // Area the text wraps in and is placed in, the whole image when empty
+	Gravity     Gravity         // Position of the text block within Box
+	Angle       float64         // Clockwise rotation in degrees around the centre of the text
+}
+
+// plan lays the text out to place it, without rendering it
+func (op TextOp) plan(size image.Point) ([]step, error) {
+	box := op.Box
+	if box.Empty() {
+		box = image.Rectangle{Max: size}
+	}
+	face, err := op.loadFace()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidRequest, err)
+	}
+	defer face.Close()
+	l := op.layout(face, box.Dx())
+	if area := int64(l.block.X) * int64(l.block.Y); area > maxTextPixels {
+		return nil, fmt.Errorf("%w: text block of %dx%d pixels is over the limit of %d pixels", errInvalidRequest, l.block.X, l.block.Y, maxTextPixels)
+	}
+
+	// The layer is padded evenly around the text, so centring it on the
+	// text block keeps the text in place whatever the padding and rotation
+	centre := box.Min.Add(op.Gravity.anchor(box.Size(), l.block)).Add(l.block.Div(2))
+	return []step{textStep{Op: op, WrapWidth: box.Dx(), Pos: centre.Sub(l.layer().Size().Div(2))}}, nil
+}
+
+// loadFace returns the font face the text is drawn with, or why there is
+// none
+func (op TextOp) loadFace() (font.Face, error) {
+	if op.Font == nil {
+		return nil, errors.New("text has no font")
+	}
+	if !(op.Size > 0) || math.IsInf(op.Size, 1) {
+		return nil, fmt.Errorf("text size %v is not a positive number", op.Size)
+	}
+	face, err := opentype.NewFace(op.Font, &opentype.FaceOptions{Size: op.Size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil, fmt.Errorf("loading font: %v", err)
+	}
+	return face, nil
+}
+
+// textLayout is text wrapped into lines
+type textLayout struct {
+	lines      []string
+	widths     []fixed.Int26_6 // Of each line
+	width      fixed.Int26_6   // Of the widest line
+	lineHeight int
+	ascent     fixed.Int26_6
+	block      image.Point // Size of the text block
+	pad        int         // Room for the stroke and shadow around the block
+}
+
+// layout wraps the text to wrapWidth pixels
+func (op TextOp) layout(face font.Face, wrapWidth int) textLayout {
+	l := textLayout{lines: wrapText(face, op.Text, fixed.I(wrapWidth))}
+	l.widths = make([]fixed.Int26_6, len(l.lines))
+	for i, line := range l.lines {
+		l.widths[i] = font.MeasureString(face, line)
+		l.width = max(l.width, l.widths[i])
+	}
+	metrics := face.Metrics()
+	l.lineHeight, l.ascent = metrics.Height.Ceil(), metrics.Ascent
+	l.block = image.Pt(max(1, l.width.Ceil()), max(1, len(l.lines)*l.lineHeight))
+
+	l.pad = int(math.Ceil(op.Stroke)) + 1
+	if op.ShadowColor.A > 0 {
+		l.pad += max(abs(op.Shadow.X), abs(op.Shadow.Y)) + int(math.Ceil(3*op.ShadowBlur))
+	}
+	return l
+}
+
+// layer returns the bounds of the whole text layer: the block and the
+// padding around it
+func (l textLayout) layer() image.Rectangle {
+	return image.Rect(0, 0, l.block.X+2*l.pad, l.block.Y+2*l.pad)
+}
+
+// textStep renders the text layer of Op, with its top-left corner at Pos
+// before any rotation, and composites it onto the image. Only the part of
+// the layer that lands on the image is rendered.
+type textStep struct {
+	Op        TextOp
+	WrapWidth int
+	Pos       image.Point
+}
+
+func (st textStep) outputSize(in image.Point) image.Point { return in }
+
+// cpu leaves the image as it is if the face does not load, which plan
+// has already ruled out
+func (st textStep) cpu(img *image.NRGBA) *image.NRGBA {
+	overlay, err := st.overlay(img.Bounds().Size())
+	if err != nil || overlay == nil {
+		return img
+	}
+	return overlay.cpu(img)
+}
+
+// gpu renders the layer on the CPU and composites it on the device
+func (st textStep) gpu(s *gpuSession, img deviceImage) (deviceImage, error) {
+	overlay, err := st.overlay(image.Pt(img.width, img.height))
+	if err != nil || overlay == nil {
+		return img, err
+	}
+	return overlay.gpu(s, img)
+}
+
+// overlay renders the part of the text layer that lands on an image of the
+// given size, and returns the step compositing it, or nil when none does
+func (st textStep) overlay(size image.Point) (*overlayStep, error) {
+	op := st.Op
+	face, err := op.loadFace()
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+	l := op.layout(face, st.WrapWidth)
+	layer := l.layer()
+	bounds := image.Rectangle{Max: size}
+
+	if op.Angle == 0 {
+		clip := bounds.Sub(st.Pos).Intersect(layer)
+		if clip.Empty() {
+			return nil, nil
+		}
+		return &overlayStep{Image: op.render(face, l, clip), Pos: st.Pos.Add(clip.Min), Opacity: 1}, nil
+	}
+
+	// The layer turns around its centre. Only the part of the image the
+	// turned layer covers needs drawing, and only the part of the layer
+	// that turns into it, with a pixel more for the bilinear samples.
+	sin, cos := math.Sincos(op.Angle * math.Pi / 180)
+	centre := [2]float64{float64(st.Pos.X) + float64(layer.Dx())/2, float64(st.Pos.Y) + float64(layer.Dy())/2}
+	local := [2]float64{float64(layer.Dx()) / 2, float64(layer.Dy()) / 2}
+	turn := func(r image.Rectangle, from, to [2]float64, sin float64) image.Rectangle {
+		lo, hi := [2]float64{math.Inf(1), math.Inf(1)}, [2]float64{math.Inf(-1), math.Inf(-1)}
+		for _, x := range []int{r.Min.X, r.Max.X} {
+			for _, y := range []int{r.Min.Y, r.Max.Y} {
+				dx, dy := float64(x)-from[0], float64(y)-from[1]
+				p := [2]float64{cos*dx - sin*dy + to[0], sin*dx + cos*dy + to[1]}
+				for a := range p {
+					lo[a], hi[a] = min(lo[a], p[a]), max(hi[a], p[a])
+				}
+			}
+		}
+		return image.Rect(int(math.Floor(lo[0])), int(math.Floor(lo[1])), int(math.Ceil(hi[0])), int(math.Ceil(hi[1])))
+	}
+	window := turn(layer, local, centre, sin).Intersect(bounds)
+	if window.Empty() {
+		return nil, nil
+	}
+	clip := turn(window, centre, local, -sin).Inset(-1).Intersect(layer)
+	if clip.Empty() {
+		return nil, nil
+	}
+
+	// Rotate premultiplied, so edges do not pick up the colour of the
+	// transparent pixels around the text
+	part := op.render(face, l, clip)
+	premultiply(part)
+	out := image.NewNRGBA(image.Rectangle{Max: window.Size()})
+	parallelRows(window.Dy(), func(y int) {
+		for x := 0; x < window.Dx(); x++ {
+			dx := float64(window.Min.X+x) + 0.5 - centre[0]
+			dy := float64(window.Min.Y+y) + 0.5 - centre[1]
+			fx := cos*dx + sin*dy + local[0] - 0.5 - float64(clip.Min.X)
+			fy := cos*dy - sin*dx + local[1] - 0.5 - float64(clip.Min.Y)
+			sampleBilinear(part, fx, fy, out.Pix[(y*window.Dx()+x)*4:])
+		}
+	})
+	unpremultiply(out)
+	return &overlayStep{Image: out, Pos: window.Min, Opacity: 1}, nil
+}
+
+// sampleBilinear writes the colour of img at (fx, fy), in pixel centres,
+// to dst, with transparent pixels outside img
+func sampleBilinear(img *image.NRGBA, fx, fy float64, dst []uint8) {
+	x0, y0 := int(math.Floor(fx)), int(math.Floor(fy))
+	wx, wy := fx-float64(x0), fy-float64(y0)
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	sample := func(x, y, c int) float64 {
+		if x < 0 || y < 0 || x >= w || y >= h {
+			return 0
+		}
+		return float64(img.Pix[y*img.Stride+x*4+c])
+	}
+	for c := 0; c < 4; c++ {
+		top := sample(x0, y0, c)*(1-wx) + sample(x0+1, y0, c)*wx
+		bottom := sample(x0, y0+1, c)*(1-wx) + sample(x0+1, y0+1, c)*wx
+		dst[c] = clampByte(float32(top*(1-wy) + bottom*wy))
+	}
+}
+
+// render draws the part clip of the text layer: the text of the layout
+// with the stroke and shadow around it. The glyphs are drawn with a margin
+// around clip wide enough for every stroke and shadow reaching into it.
+func (op TextOp) render(face font.Face, l textLayout, clip image.Rectangle) *image.NRGBA {
+	area := clip.Inset(-l.pad).Intersect(l.layer())
+	rect := image.Rectangle{Max: area.Size()}
+
+	glyphs := image.NewAlpha(rect)
+	d := font.Drawer{Dst: glyphs, Src: image.Opaque, Face: face}
+	for i, line := range l.lines {
+		// Skip lines well clear of the area, whose glyphs cannot reach it
+		top := l.pad + i*l.lineHeight
+		if top+2*l.lineHeight < area.Min.Y || top-l.lineHeight > area.Max.Y {
+			continue
+		}
+		indent := (l.width - l.widths[i]) * fixed.Int26_6(op.Align) / 2
+		d.Dot = fixed.Point26_6{
+			X: fixed.I(l.pad-area.Min.X) + indent,
+			Y: fixed.I(top-area.Min.Y) + l.ascent,
+		}
+		d.DrawString(line)
+	}
+
+	shape := glyphs
+	if op.Stroke > 0 {
+		shape = strokeMask(glyphs, op.Stroke)
+	}
+	layer := image.NewNRGBA(rect)
+	if op.ShadowColor.A > 0 {
+		shadow := image.NewNRGBA(rect)
+		draw.DrawMask(shadow, rect.Add(op.Shadow), image.NewUniform(op.ShadowColor), image.Point{}, shape, image.Point{}, draw.Over)
+		if op.ShadowBlur > 0 {
+			shadow = convolveNRGBA(shadow, blurWeights(gaussianRadius(op.ShadowBlur), float32(op.ShadowBlur)))
+		}
+		draw.Draw(layer, rect, shadow, image.Point{}, draw.Over)
+	}
+	if op.Stroke > 0 {
+		draw.DrawMask(layer, rect, image.NewUniform(op.StrokeColor), image.Point{}, shape, image.Point{}, draw.Over)
+	}
+	draw.DrawMask(layer, rect, image.NewUniform(op.Color), image.Point{}, glyphs, image.Point{}, draw.Over)
+	return cloneNRGBA(layer.SubImage(clip.Sub(area.Min)).(*image.NRGBA))
+}
+
+// wrapText breaks text into lines no wider than maxWidth, between words
+// where possible and inside words that are too wide on their own
+func wrapText(face font.Face, text string, maxWidth fixed.Int26_6) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" {
+				if font.MeasureString(face, line+" "+word) <= maxWidth {
+					line += " " + word
+					continue
+				}
+				lines = append(lines, line)
+			}
+			for font.MeasureString(face, word) > maxWidth {
+				n := fittingPrefix(face, word, maxWidth)
+				lines = append(lines, word[:n])
+				word = word[n:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fittingPrefix returns the length in bytes of the longest prefix of word
+// no wider than maxWidth, and at least one rune
+func fittingPrefix(face font.Face, word string, maxWidth fixed.Int26_6) int {
+	n := 0
+	for i, r := range word {
+		end := i + utf8.RuneLen(r)
+		if n > 0 && font.MeasureString(face, word[:end]) > maxWidth {
+			break
+		}
+		n = end
+	}
+	return n
+}
+
+// strokeMask widens the glyph mask by width pixels in every direction. The
+// distance to the nearest inked pixel gives an anti-aliased edge.
+func strokeMask(glyphs *image.Alpha, width float64) *image.Alpha {
+	b := glyphs.Rect
+	w, h := b.Dx(), b.Dy()
+	dist := make([]float64, w*h)
+	for i, a := range glyphs.Pix {
+		if a < 128 {
+			dist[i] = math.Inf(1)
+		}
+	}
+	squaredDistanceTransform(dist, w, h)
+
+	out := image.NewAlpha(b)
+	for i, d := range dist {
+		cover := min(max(width+0.5-math.Sqrt(d), 0), 1)
+		out.Pix[i] = max(glyphs.Pix[i], uint8(cover*255+0.5))
+	}
+	return out
+}
+
+// squaredDistanceTransform replaces every value in a w x h grid of zeros
+// (inked) and infinities with the squared distance to the nearest zero,
+// using the separable algorithm of Felzenszwalb and Huttenlocher
+func squaredDistanceTransform(grid []float64, w, h int) {
+	f := make([]float64, max(w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			f[y] = grid[y*w+x]
+		}
+		d := distanceTransform1D(f[:h])
+		for y := 0; y < h; y++ {
+			grid[y*w+x] = d[y]
+		}
+	}
+	for y := 0; y < h; y++ {
+		copy(grid[y*w:(y+1)*w], distanceTransform1D(grid[y*w:(y+1)*w]))
+	}
+}
+
+// distanceTransform1D returns the lower envelope of the parabolas rooted
+// at each sample of f
+func distanceTransform1D(f []float64) []float64 {
+	n := len(f)
+	d := make([]float64, n)
+	v := make([]int, n)       // Roots of the parabolas in the envelope
+	z := make([]float64, n+1) // Boundaries between them
+	k := -1
+	for q := 0; q < n; q++ {
+		if math.IsInf(f[q], 1) {
+			continue
+		}
+		for k >= 0 {
+			s := ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*(q-v[k]))
+			if s > z[k] {
+				k++
+				v[k], z[k], z[k+1] = q, s, math.Inf(1)
+				break
+			}
+			k--
+		}
+		if k < 0 {
+			k = 0
+			v[0], z[0], z[1] = q, math.Inf(-1), math.Inf(1)
+		}
+	}
+	if k < 0 {
+		for i := range d {
+			d[i] = math.Inf(1)
+		}
+		return d
+	}
+	k = 0
+	for q := 0; q < n; q++ {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		d[q] = float64((q-v[k])*(q-v[k])) + f[v[k]]
+	}
+	return d
+}
+
+// premultiply scales the colour of every pixel by its alpha in place
+func premultiply(img *image.NRGBA) {
+	for i := 0; i < len(img.Pix); i += 4 {
+		a := uint32(img.Pix[i+3])
+		for c := 0; c < 3; c++ {
+			img.Pix[i+c] = uint8((uint32(img.Pix[i+c])*a + 127) / 255)
+		}
+	}
+}
+
+// unpremultiply reverses premultiply
+func unpremultiply(img *image.NRGBA) {
+	for i := 0; i < len(img.Pix); i += 4 {
+		a := uint32(img.Pix[i+3])
+		if a == 0 {
+			continue
+		}
+		for c := 0; c < 3; c++ {
+			img.Pix[i+c] = uint8(min((uint32(img.Pix[i+c])*255+a/2)/a, 255))
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
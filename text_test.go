@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+
+	pb "github.com/jeauchter/go-image-adjuster/proto"
+)
+
+// TestTextRejectsHugeBlocks checks that text whose block is over the
+// pixel budget is rejected when planned, before anything is rendered
+func TestTextRejectsHugeBlocks(t *testing.T) {
+	op := TextOp{Text: strings.Repeat("W", maxTextBytes), Font: builtinFonts["bold"], Size: maxTextSize, Color: color.NRGBA{A: 255}}
+	if _, err := op.plan(image.Pt(1<<20, 100)); !errors.Is(err, errInvalidRequest) {
+		t.Fatalf("plan: %v, want an invalid request", err)
+	}
+	op.Text = "W"
+	if _, err := op.plan(image.Pt(1<<20, 100)); err != nil {
+		t.Fatalf("plan of a short text: %v", err)
+	}
+}
+
+// TestTextRejectsUnusableFonts checks that a text without a font or size
+// is rejected when planned, and that a step built without planning fails
+// on the device and leaves the image alone on the CPU
+func TestTextRejectsUnusableFonts(t *testing.T) {
+	for _, op := range []TextOp{
+		{Text: "a", Size: 12},
+		{Text: "a", Font: builtinFonts["regular"]},
+		{Text: "a", Font: builtinFonts["regular"], Size: math.NaN()},
+	} {
+		if _, err := op.plan(image.Pt(100, 100)); !errors.Is(err, errInvalidRequest) {
+			t.Errorf("font %p, size %v: %v, want an invalid request", op.Font, op.Size, err)
+		}
+	}
+
+	st := textStep{Op: TextOp{Text: "a", Size: 12}, WrapWidth: 10}
+	src := uniformImage(10, 10, color.NRGBA{200, 100, 50, 255})
+	if got := st.cpu(src); got != src {
+		t.Error("the CPU changed the image without a font")
+	}
+	dev := openSimDevice(t, newSimDriver("Simulated GPU"))
+	if _, _, err := runBatchGPU(context.Background(), dev, []*image.NRGBA{src}, [][]step{{st}}); err == nil {
+		t.Error("the device drew text without a font")
+	}
+}
+
+// TestTextClipsToImage checks that text running off the image only
+// renders the part on it, and that the part looks the same as in the
+// whole layer
+func TestTextClipsToImage(t *testing.T) {
+	img := resampleNRGBA(goldenImage(), 120, 80, FilterBilinear, false)
+	for _, op := range []TextOp{
+		{Text: "Clipped text", Font: builtinFonts["regular"], Size: 60, Color: color.NRGBA{0, 0, 255, 255}, Box: image.Rect(-50, 30, 500, 200), Gravity: GravityNorthWest},
+		{Text: "Shadow and stroke", Font: builtinFonts["bold"], Size: 40, Color: color.NRGBA{255, 255, 255, 255}, Stroke: 3, StrokeColor: color.NRGBA{A: 255},
+			Shadow: image.Pt(6, -6), ShadowColor: color.NRGBA{255, 0, 0, 200}, ShadowBlur: 2, Box: image.Rect(60, -20, 400, 300), Gravity: GravityNorthWest},
+	} {
+		steps, err := op.plan(img.Rect.Size())
+		if err != nil {
+			t.Fatalf("%q: %v", op.Text, err)
+		}
+		st := steps[0].(textStep)
+		overlay, err := st.overlay(img.Rect.Size())
+		if err != nil {
+			t.Fatalf("%q: %v", op.Text, err)
+		}
+		if !overlay.Image.Rect.Size().In(image.Rectangle{Max: img.Rect.Size().Add(image.Pt(1, 1))}) {
+			t.Errorf("%q: rendered %v for a %v image", op.Text, overlay.Image.Rect.Size(), img.Rect.Size())
+		}
+
+		face, err := op.loadFace()
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := op.layout(face, st.WrapWidth)
+		whole := overlayStep{Image: op.render(face, l, l.layer()), Pos: st.Pos, Opacity: 1}.cpu(cloneNRGBA(img))
+		face.Close()
+		if err := diffImages(whole, st.cpu(cloneNRGBA(img)), 0); err != nil {
+			t.Errorf("%q: %v", op.Text, err)
+		}
+	}
+}
+
+// TestTextFromProtoLimits checks the limits that keep the text layer
+// small
+func TestTextFromProtoLimits(t *testing.T) {
+	for _, tc := range []*pb.TextOperation{
+		{Text: strings.Repeat("a", maxTextBytes+1), Size: 12},
+		{Text: "a", Size: maxTextSize + 1},
+		{Text: "a", Size: 12, StrokeWidth: maxTextStroke + 1},
+		{Text: "a", Size: 12, Shadow: &pb.TextShadow{Blur: maxShadowBlur + 1}},
+		{Text: "a", Size: 12, Shadow: &pb.TextShadow{OffsetX: maxShadowOffset + 1}},
+		{Text: "a", Size: 12, Shadow: &pb.TextShadow{OffsetY: -maxShadowOffset - 1}},
+	} {
+		if _, err := textFromProto(tc, &assetStore{}); !errors.Is(err, errInvalidRequest) {
+			t.Errorf("%v: %v, want an invalid request", tc, err)
+		}
+	}
+	if _, err := textFromProto(&pb.TextOperation{Text: "a", Size: 12, Shadow: &pb.TextShadow{OffsetX: maxShadowOffset}}, &assetStore{}); err != nil {
+		t.Errorf("shadow at the limit: %v", err)
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Orientation is one of the eight flips and quarter turns, numbered like
+// the EXIF Orientation tag. It describes the correction that turns the
+// stored image upright; 0 is treated like OrientNormal.
+type Orientation int
+
+const (
+	OrientNormal     Orientation = 1
+	OrientFlipH      Orientation = 2 // Mirror left to right
+	OrientRotate180  Orientation = 3
+	OrientFlipV      Orientation = 4 // Mirror top to bottom
+	OrientTranspose  Orientation = 5 // Mirror along the top-left to bottom-right diagonal
+	OrientRotate90   Orientation = 6 // Clockwise
+	OrientTransverse Orientation = 7 // Mirror along the top-right to bottom-left diagonal
+	OrientRotate270  Orientation = 8 // Clockwise
+)
+
+// transform decomposes o: output pixel (x, y) shows input pixel (u, v),
+// where (u, v) is (x, y), or (y, x) when swap is set, then mirrored
+// horizontally when flipX is set and vertically when flipY is set
+func (o Orientation) transform() (swap, flipX, flipY bool) {
+	switch o {
+	case OrientFlipH:
+		return false, true, false
+	case OrientRotate180:
+		return false, true, true
+	case OrientFlipV:
+		return false, false, true
+	case OrientTranspose:
+		return true, false, false
+	case OrientRotate90:
+		return true, false, true
+	case OrientTransverse:
+		return true, true, true
+	case OrientRotate270:
+		return true, true, false
+	}
+	return false, false, false
+}
+
+// identity reports whether o leaves the image unchanged
+func (o Orientation) identity() bool {
+	return o == 0 || o == OrientNormal
+}
+
+// source maps output pixel (x, y) to the pixel it shows in a width x
+// height input
+func (o Orientation) source(x, y, width, height int) (int, int) {
+	swap, flipX, flipY := o.transform()
+	if swap {
+		x, y = y, x
+	}
+	if flipX {
+		x = width - 1 - x
+	}
+	if flipY {
+		y = height - 1 - y
+	}
+	return x, y
+}
+
+// outputSize returns the size of the corrected image
+func (o Orientation) outputSize(in image.Point) image.Point {
+	if swap, _, _ := o.transform(); swap {
+		return image.Pt(in.Y, in.X)
+	}
+	return in
+}
+
+// then returns the orientation that applies o followed by next
+func (o Orientation) then(next Orientation) Orientation {
+	// Compare the pixel mappings on a small image that is not square, so
+	// swapped axes are told apart
+	in := image.Pt(2, 3)
+	mid := o.outputSize(in)
+	for c := OrientNormal; c <= OrientRotate270; c++ {
+		out := next.outputSize(mid)
+		if c.outputSize(in) != out {
+			continue
+		}
+		same := true
+		for y := 0; y < out.Y && same; y++ {
+			for x := 0; x < out.X && same; x++ {
+				mx, my := next.source(x, y, mid.X, mid.Y)
+				ax, ay := o.source(mx, my, in.X, in.Y)
+				cx, cy := c.source(x, y, in.X, in.Y)
+				same = ax == cx && ay == cy
+			}
+		}
+		if same {
+			return c
+		}
+	}
+	return OrientNormal
+}
+
+// inverse returns the orientation that undoes o
+func (o Orientation) inverse() Orientation {
+	for c := OrientNormal; c <= OrientRotate270; c++ {
+		if o.then(c) == OrientNormal {
+			return c
+		}
+	}
+	return OrientNormal
+}
+
+// plan makes Orientation an Operation, used for EXIF auto-orientation
+func (o Orientation) plan(size image.Point) ([]step, error) {
+	return []step{orientStep{Orientation: o}}, nil
+}
+
+// FlipOp mirrors the image
+type FlipOp struct {
+	Horizontal bool // Mirror left to right
+	Vertical   bool // Mirror top to bottom
+}
+
+func (op FlipOp) plan(size image.Point) ([]step, error) {
+	o := OrientNormal
+	switch {
+	case op.Horizontal && op.Vertical:
+		o = OrientRotate180
+	case op.Horizontal:
+		o = OrientFlipH
+	case op.Vertical:
+		o = OrientFlipV
+	}
+	return []step{orientStep{Orientation: o}}, nil
+}
+
+// RotateOp turns the image clockwise. Quarter turns are exact; other
+// angles enlarge the canvas to the rotated bounds, sample bilinearly and
+// fill the corners with the background.
+type RotateOp struct {
+	Angle      float64 // Degrees
+	Background color.NRGBA
+}
+
+func (op RotateOp) plan(size image.Point) ([]step, error) {
+	angle := math.Mod(op.Angle, 360)
+	if angle < 0 {
+		angle += 360
+	}
+	switch angle {
+	case 0:
+		return nil, nil
+	case 90:
+		return []step{orientStep{Orientation: OrientRotate90}}, nil
+	case 180:
+		return []step{orientStep{Orientation: OrientRotate180}}, nil
+	case 270:
+		return []step{orientStep{Orientation: OrientRotate270}}, nil
+	}
+
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	w, h := float64(size.X), float64(size.Y)
+	return []step{rotateStep{
+		Width:      max(1, int(math.Round(w*math.Abs(cos)+h*math.Abs(sin)))),
+		Height:     max(1, int(math.Round(w*math.Abs(sin)+h*math.Abs(cos)))),
+		Cos:        float32(cos),
+		Sin:        float32(sin),
+		Background: op.Background,
+	}}, nil
+}
+
+// orientStep applies a flip or quarter turn
+type orientStep struct {
+	Orientation Orientation
+}
+
+func (st orientStep) outputSize(in image.Point) image.Point { return st.Orientation.outputSize(in) }
+
+func (st orientStep) cpu(img *image.NRGBA) *image.NRGBA {
+	if img.Stride != img.Bounds().Dx()*4 {
+		img = cloneNRGBA(img)
+	}
+	in := img.Bounds().Size()
+	size := st.outputSize(in)
+	out := image.NewNRGBA(image.Rectangle{Max: size})
+	parallelRows(size.Y, func(y int) {
+		for x := 0; x < size.X; x++ {
+			orientPixel(img.Pix, in.X, in.Y, out.Pix, size.X, st.Orientation, x, y)
+		}
+	})
+	return out
+}
+
+func (st orientStep) gpu(s *gpuSession, img deviceImage) (deviceImage, error) {
+	size := st.outputSize(image.Pt(img.width, img.height))
+	out, err := s.newImage(size.X, size.Y, img.count)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	err = s.launch(orientKernelV1, size.X, size.Y, img.count,
+		img.ptr, int32(img.width), int32(img.height),
+		out.ptr, int32(size.X), int32(size.Y), int32(st.Orientation),
+	)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	return out, nil
+}
+
+// orientPixel computes output pixel (x, y) of an orientation step. Shared
+// by the CPU backend and the simulated orientKernel.
+func orientPixel(src []byte, srcWidth, srcHeight int, dst []byte, dstWidth int, o Orientation, x, y int) {
+	sx, sy := o.source(x, y, srcWidth, srcHeight)
+	s := (sy*srcWidth + sx) * 4
+	d := (y*dstWidth + x) * 4
+	copy(dst[d:d+4], src[s:s+4])
+}
+
+// rotateStep turns the image by an arbitrary angle onto a Width x Height
+// canvas centred on the image
+type rotateStep struct {
+	Width, Height int
+	Cos, Sin      float32
+	Background    color.NRGBA
+}
+
+func (st rotateStep) outputSize(image.Point) image.Point { return image.Pt(st.Width, st.Height) }
+
+func (st rotateStep) cpu(img *image.NRGBA) *image.NRGBA {
+	if img.Stride != img.Bounds().Dx()*4 {
+		img = cloneNRGBA(img)
+	}
+	in := img.Bounds().Size()
+	out := image.NewNRGBA(image.Rect(0, 0, st.Width, st.Height))
+	bg := packColor(st.Background)
+	parallelRows(st.Height, func(y int) {
+		for x := 0; x < st.Width; x++ {
+			rotatePixel(img.Pix, in.X, in.Y, out.Pix, st.Width, st.Height, st.Cos, st.Sin, bg, x, y)
+		}
+	})
+	return out
+}
+
+func (st rotateStep) gpu(s *gpuSession, img deviceImage) (deviceImage, error) {
+	out, err := s.newImage(st.Width, st.Height, img.count)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	err = s.launch(rotateKernelV2, st.Width, st.Height, img.count,
+		img.ptr, int32(img.width), int32(img.height),
+		out.ptr, int32(st.Width), int32(st.Height),
+		st.Cos, st.Sin, int32(packColor(st.Background)),
+	)
+	if err != nil {
+		return deviceImage{}, err
+	}
+	return out, nil
+}
+
+// rotatePixel computes output pixel (x, y) of a rotation: the output pixel
+// centre is turned back onto the input and sampled bilinearly, with the
+// packed background colour outside the input. The samples are mixed
+// premultiplied, so transparent pixels do not lend their colour to the
+// edges. Shared by the CPU backend and the simulated rotateKernel.
+func rotatePixel(src []byte, srcWidth, srcHeight int, dst []byte, dstWidth, dstHeight int, cos, sin float32, background uint32, x, y int) {
+	dx := float32(x) + 0.5 - float32(dstWidth)*0.5
+	dy := float32(y) + 0.5 - float32(dstHeight)*0.5
+	fx := cos*dx + sin*dy + float32(srcWidth)*0.5 - 0.5
+	fy := cos*dy - sin*dx + float32(srcHeight)*0.5 - 0.5
+
+	x0, y0 := int(math.Floor(float64(fx))), int(math.Floor(float64(fy)))
+	wx, wy := fx-float32(x0), fy-float32(y0)
+	bg := [4]uint8{uint8(background >> 24), uint8(background >> 16), uint8(background >> 8), uint8(background)}
+	var v [4]float32
+	add := func(sx, sy int, w float32) {
+		p := bg[:]
+		if sx >= 0 && sy >= 0 && sx < srcWidth && sy < srcHeight {
+			p = src[(sy*srcWidth+sx)*4:]
+		}
+		a := float32(p[3])
+		for c := 0; c < 3; c++ {
+			v[c] += w * float32(p[c]) * a / 255
+		}
+		v[3] += w * a
+	}
+	add(x0, y0, (1-wx)*(1-wy))
+	add(x0+1, y0, wx*(1-wy))
+	add(x0, y0+1, (1-wx)*wy)
+	add(x0+1, y0+1, wx*wy)
+
+	d := (y*dstWidth + x) * 4
+	a := clampByte(v[3])
+	if a == 0 {
+		clear(dst[d : d+4])
+		return
+	}
+	for c := 0; c < 3; c++ {
+		dst[d+c] = clampByte(v[c] * 255 / v[3])
+	}
+	dst[d+3] = a
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+// letterImage returns a 3x2 image whose pixels a-f, in reading order, have
+// grey levels 'a' to 'f'
+func letterImage() *image.NRGBA {
+	return letters("abc", "def")
+}
+
+// letters builds an image from rows of letters, each becoming a pixel
+// with that grey level
+func letters(rows ...string) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range []byte(row) {
+			img.SetNRGBA(x, y, grey(c))
+		}
+	}
+	return img
+}
+
+// TestOrientations turns a 3x2 image by each orientation on both backends
+// and compares it with the pixels laid out by hand
+func TestOrientations(t *testing.T) {
+	tests := []struct {
+		name        string
+		orientation Orientation
+		want        []string
+	}{
+		{"normal", OrientNormal, []string{"abc", "def"}},
+		{"flip horizontal", OrientFlipH, []string{"cba", "fed"}},
+		{"rotate 180", OrientRotate180, []string{"fed", "cba"}},
+		{"flip vertical", OrientFlipV, []string{"def", "abc"}},
+		{"transpose", OrientTranspose, []string{"ad", "be", "cf"}},
+		{"rotate 90", OrientRotate90, []string{"da", "eb", "fc"}},
+		{"transverse", OrientTransverse, []string{"fc", "eb", "da"}},
+		{"rotate 270", OrientRotate270, []string{"cf", "be", "ad"}},
+	}
+	src := letterImage()
+	dev := openSimDevice(t, newSimDriver("Simulated GPU"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := letters(tt.want...)
+			steps := []step{orientStep{Orientation: tt.orientation}}
+			cpu, _, _ := runStepsCPU(context.Background(), src, steps)
+			if err := diffImages(want, cpu, 0); err != nil {
+				t.Errorf("on the CPU: %v", err)
+			}
+			gpu, _, err := runBatchGPU(context.Background(), dev, []*image.NRGBA{src}, [][]step{steps})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := diffImages(want, gpu[0][0], 0); err != nil {
+				t.Errorf("on the simulated device: %v", err)
+			}
+		})
+	}
+}
+
+// TestOrientationThen checks every pair of orientations against running
+// the two one after the other
+func TestOrientationThen(t *testing.T) {
+	src := letterImage()
+	for a := OrientNormal; a <= OrientRotate270; a++ {
+		for b := OrientNormal; b <= OrientRotate270; b++ {
+			want, _, _ := runStepsCPU(context.Background(), src, []step{orientStep{Orientation: a}, orientStep{Orientation: b}})
+			got, _, _ := runStepsCPU(context.Background(), src, []step{orientStep{Orientation: a.then(b)}})
+			if err := diffImages(want, got, 0); err != nil {
+				t.Errorf("%d then %d = %d: %v", a, b, a.then(b), err)
+			}
+		}
+	}
+
+	named := []struct {
+		a, b, want Orientation
+	}{
+		{OrientRotate90, OrientRotate90, OrientRotate180},
+		{OrientRotate90, OrientRotate270, OrientNormal},
+		{OrientFlipH, OrientFlipV, OrientRotate180},
+		{OrientRotate90, OrientFlipH, OrientTranspose},
+	}
+	for _, tt := range named {
+		if got := tt.a.then(tt.b); got != tt.want {
+			t.Errorf("%d then %d = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFlipAndRotatePlan(t *testing.T) {
+	size := image.Pt(10, 10)
+	tests := []struct {
+		name string
+		op   Operation
+		want []step
+	}{
+		{"no flip", FlipOp{}, []step{orientStep{Orientation: OrientNormal}}},
+		{"flip horizontal", FlipOp{Horizontal: true}, []step{orientStep{Orientation: OrientFlipH}}},
+		{"flip vertical", FlipOp{Vertical: true}, []step{orientStep{Orientation: OrientFlipV}}},
+		{"flip both", FlipOp{Horizontal: true, Vertical: true}, []step{orientStep{Orientation: OrientRotate180}}},
+		{"rotate 0", RotateOp{}, nil},
+		{"rotate 360", RotateOp{Angle: 360}, nil},
+		{"rotate 90", RotateOp{Angle: 90}, []step{orientStep{Orientation: OrientRotate90}}},
+		{"rotate 450", RotateOp{Angle: 450}, []step{orientStep{Orientation: OrientRotate90}}},
+		{"rotate -90", RotateOp{Angle: -90}, []step{orientStep{Orientation: OrientRotate270}}},
+		{"rotate 180", RotateOp{Angle: 180}, []step{orientStep{Orientation: OrientRotate180}}},
+		{"rotate 45", RotateOp{Angle: 45}, []step{rotateStep{Width: 14, Height: 14, Cos: 0.70710677, Sin: 0.70710677}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := tt.op.plan(size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(steps, tt.want) {
+				t.Errorf("got %v, want %v", steps, tt.want)
+			}
+		})
+	}
+}
+
+// TestRotateValues turns a uniform 4x4 image by 45 degrees onto a 6x6
+// canvas and checks pixels worked out by hand on both backends
+func TestRotateValues(t *testing.T) {
+	fill := color.NRGBA{200, 100, 50, 255}
+	bg := color.NRGBA{0, 0, 0, 255}
+	steps, err := RotateOp{Angle: 45, Background: bg}.plan(image.Pt(4, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Output pixel (0, 2) maps to input (-0.62, 2.91): 0.379 of the fill
+	// and 0.621 of the background from the column left of the image
+	edge := color.NRGBA{76, 38, 19, 255}
+	want := map[image.Point]color.NRGBA{
+		{0, 0}: bg, {5, 0}: bg, {0, 5}: bg, {5, 5}: bg,
+		{2, 2}: fill, {3, 3}: fill, {2, 3}: fill,
+		{0, 2}: edge,
+	}
+
+	src := uniformImage(4, 4, fill)
+	dev := openSimDevice(t, newSimDriver("Simulated GPU"))
+	gpu, _, err := runBatchGPU(context.Background(), dev, []*image.NRGBA{src}, [][]step{steps})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu, _, _ := runStepsCPU(context.Background(), src, steps)
+	for name, img := range map[string]*image.NRGBA{"cpu": cpu, "cuda-sim": gpu[0][0]} {
+		if img.Bounds() != image.Rect(0, 0, 6, 6) {
+			t.Fatalf("%s: result is %v, want 6x6", name, img.Bounds())
+		}
+		for p, c := range want {
+			if got := img.NRGBAAt(p.X, p.Y); got != c {
+				t.Errorf("%s: pixel %v = %v, want %v", name, p, got, c)
+			}
+		}
+	}
+}
+
+// TestRotateKeepsEdgeColour turns an opaque image by 45 degrees onto a
+// transparent background, which must fade the edges without darkening
+// them on either backend
+func TestRotateKeepsEdgeColour(t *testing.T) {
+	fill := color.NRGBA{200, 100, 50, 255}
+	src := uniformImage(8, 8, fill)
+	dev := openSimDevice(t, newSimDriver("Simulated GPU"))
+	cpu, gpu := runBothImplementations(t, dev, src, []Operation{RotateOp{Angle: 45}})
+	for name, img := range map[string]*image.NRGBA{"cpu": cpu, "cuda-sim": gpu} {
+		var edges int
+		for y := 0; y < img.Rect.Dy(); y++ {
+			for x := 0; x < img.Rect.Dx(); x++ {
+				c := img.NRGBAAt(x, y)
+				if c.A == 0 {
+					continue
+				}
+				if c.A < 255 {
+					edges++
+				}
+				if d := max(abs(int(c.R)-int(fill.R)), abs(int(c.G)-int(fill.G)), abs(int(c.B)-int(fill.B))); d > 1 {
+					t.Fatalf("%s: pixel (%d, %d) is %v, want the colour of %v", name, x, y, c, fill)
+				}
+			}
+		}
+		if edges == 0 {
+			t.Errorf("%s: no partly covered edge pixels", name)
+		}
+	}
+}
+
+func TestOptimizeOrientations(t *testing.T) {
+	steps, _, err := planPipeline([]Operation{
+		OrientRotate90,
+		RotateOp{Angle: 90},
+		FlipOp{Horizontal: true, Vertical: true},
+	}, image.Pt(3, 2), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("a full turn in total planned %v, want no steps", steps)
+	}
+
+	steps, _, err = planPipeline([]Operation{OrientRotate90, FlipOp{Horizontal: true}}, image.Pt(3, 2), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []step{orientStep{Orientation: OrientTranspose}}; !slices.Equal(steps, want) {
+		t.Errorf("got %v, want %v", steps, want)
+	}
+}